@@ -0,0 +1,73 @@
+package main
+
+import (
+	bookingpb "bff-graphql-payment/gen/go/proto/booking/v1"
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"bff-graphql-payment/internal/infrastructure/logging"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/fake/memory"
+	"flag"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// fake-managers sirve Payment Manager y Booking Manager sobre un almacén en memoria para
+// probar el BFF con USE_MOCK=false en local (docker-compose). El estado se pierde al reiniciar.
+func main() {
+	paymentAddr := flag.String("payment-addr", ":50051", "dirección del Payment Manager")
+	bookingAddr := flag.String("booking-addr", ":50052", "dirección del Booking Manager")
+	openDelay := flag.Duration("open-delay", time.Second, "espera entre los estados de ExecuteOpen")
+	bookingDuration := flag.Duration("booking-duration", 24*time.Hour, "vigencia de las reservas nuevas")
+	paymentURL := flag.String("payment-url", "https://payment.odihnx.com/pay", "URL base de la pasarela simulada")
+	logFormat := flag.String("log-format", logging.FormatText, "formato de log (text o json)")
+	logLevel := flag.String("log-level", "info", "nivel de log")
+	flag.Parse()
+
+	logger := logging.New(logging.Settings{Format: *logFormat, Level: *logLevel})
+	store := memory.NewStore(memory.DefaultSeed(time.Now()), *bookingDuration)
+
+	paymentServer := newServer()
+	paymentpb.RegisterPaymentServiceServer(paymentServer, memory.NewPaymentServer(store, *paymentURL, logger))
+	bookingServer := newServer()
+	bookingpb.RegisterBookingServiceServer(bookingServer, memory.NewBookingServer(store, *openDelay, logger))
+
+	servers := map[string]*grpc.Server{*paymentAddr: paymentServer, *bookingAddr: bookingServer}
+	errs := make(chan error, len(servers))
+	for addr, server := range servers {
+		listener, err := net.Listen("tcp", addr)
+		if err != nil {
+			logger.Error("failed to listen", "addr", addr, "error", err)
+			os.Exit(1)
+		}
+		go func() { errs <- server.Serve(listener) }()
+	}
+	logger.Info("fake managers started", "paymentAddr", *paymentAddr, "bookingAddr", *bookingAddr)
+
+	// Esperar señal de término o la caída de alguno de los servidores
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-quit:
+		logger.Info("shutting down fake managers")
+	case err := <-errs:
+		logger.Error("fake manager stopped", "error", err)
+	}
+	for _, server := range servers {
+		server.GracefulStop()
+	}
+}
+
+// newServer crea un servidor gRPC con health check y reflection (para grpcurl)
+func newServer() *grpc.Server {
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	reflection.Register(server)
+	return server
+}
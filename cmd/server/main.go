@@ -2,121 +2,73 @@ package main
 
 import (
 	"bff-graphql-payment/config"
-	"bff-graphql-payment/graph/generated"
+	"bff-graphql-payment/internal/infrastructure/inbound/admin"
+	graphqlServer "bff-graphql-payment/internal/infrastructure/inbound/graphql/server"
+	"bff-graphql-payment/internal/infrastructure/inbound/health"
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
 	"context"
-	"log"
+	"encoding/json"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
-	"time"
 
-	"github.com/99designs/gqlgen/graphql/handler"
-	"github.com/99designs/gqlgen/graphql/handler/extension"
-	"github.com/99designs/gqlgen/graphql/handler/lru"
-	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
-	"github.com/gorilla/websocket"
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
-	"github.com/vektah/gqlparser/v2/ast"
 )
 
 func main() {
 	// Cargar variables de entorno
 	if err := godotenv.Load(); err != nil {
-		log.Printf("Warning: .env file not found: %v", err)
+		slog.Warn(".env file not found", "error", err)
 	}
 
-	// Obtener configuración
-	cfg := getConfig()
+	// Obtener configuración: defaults, archivo (-config o CONFIG_FILE), variables de entorno y flags
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		slog.Error("failed to load configuration", "error", err)
+		os.Exit(1)
+	}
 
 	// Inicializar contenedor de dependencias
 	container, err := config.NewContainer(cfg)
 	if err != nil {
-		log.Fatalf("Failed to initialize container: %v", err)
+		slog.Error("failed to initialize container", "error", err)
+		os.Exit(1)
 	}
+	logger := container.Logger.With("component", "server")
+	logger.Info("configuration loaded", "environment", cfg.General.Environment, "config", cfg.String())
 
 	// Inicializar gestor de ciclo de vida
-	lifecycle := config.NewLifecycle(container)
+	lifecycle := config.NewLifecycle(container, cfg, os.Args[1:])
 	defer func() {
 		if err := lifecycle.Shutdown(); err != nil {
-			log.Printf("Error during shutdown: %v", err)
+			logger.Error("error during shutdown", "error", err)
 		}
 	}()
 
-	// Crear servidor GraphQL con soporte completo para subscriptions vía WebSocket
-	srv := handler.New(
-		generated.NewExecutableSchema(
-			generated.Config{Resolvers: container.GraphQLResolver},
-		),
-	)
-
-	// Configurar transports (HTTP POST, WebSocket para subscriptions, GET para queries)
-	srv.AddTransport(transport.Options{})
-	srv.AddTransport(transport.GET{})
-	srv.AddTransport(transport.POST{})
-	srv.AddTransport(transport.MultipartForm{})
-
-	// WebSocket transport para subscriptions - CRÍTICO para executeOpen subscription
-	// Con CheckOrigin personalizado para permitir cross-origin desde dominios permitidos
-	allowedOrigins := []string{
-		// Frontend Board
-		"https://board.api.odihnx.com",     // Board producción
-		"https://board.api-dev.odihnx.com", // Board desarrollo
-		// Frontend Payment
-		"https://payment.api.odihnx.com",     // Payment producción
-		"https://payment.odihnx.com",         // Payment producción alternativo
-		"https://payment.api-dev.odihnx.com", // Payment desarrollo
-		// Local testing
-		"http://localhost:5173", // Vite dev server
-		"http://localhost:8080", // Local testing
-		"http://127.0.0.1:8080", // Local testing alternativo
+	// Recargar en caliente la configuración al cambiar el archivo o al recibir SIGHUP
+	if err := lifecycle.Watch(); err != nil {
+		logger.Error("failed to watch configuration", "error", err)
+		os.Exit(1)
 	}
-	srv.AddTransport(transport.Websocket{
-		KeepAlivePingInterval: 10 * time.Second,
-		Upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool {
-				origin := r.Header.Get("Origin")
-				// Permitir sin Origin (Postman, curl, etc.)
-				if origin == "" {
-					return true
-				}
-				// Verificar si origin está en lista permitida
-				for _, allowed := range allowedOrigins {
-					if origin == allowed {
-						log.Printf("✅ WebSocket origin allowed: %s", origin)
-						return true
-					}
-				}
-				log.Printf("❌ WebSocket origin rejected: %s", origin)
-				return false
-			},
-		},
-	})
 
-	// Configurar query cache y extensions
-	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
-	srv.Use(extension.Introspection{})
-	srv.Use(extension.AutomaticPersistedQuery{
-		Cache: lru.New[string](100),
+	// Crear servidor GraphQL con soporte completo para subscriptions vía WebSocket
+	srv := graphqlServer.NewHandler(container.GraphQLResolver, graphqlServer.Settings{
+		Production:    cfg.General.IsProduction(),
+		OriginPolicy:  container.OriginPolicy,
+		Subscriptions: container.Subscriptions,
+		Logger:        container.Logger,
 	})
 
 	// Configurar CORS - CRÍTICO para WebSocket cross-origin
 	c := cors.New(cors.Options{
-		AllowedOrigins: []string{
-			// Frontend Board
-			"https://board.api.odihnx.com",     // Board producción
-			"https://board.api-dev.odihnx.com", // Board desarrollo
-			// Frontend Payment
-			"https://payment.api.odihnx.com",     // Payment producción
-			"https://payment.odihnx.com",         // Payment producción alternativo
-			"https://payment.api-dev.odihnx.com", // Payment desarrollo
-			// Local testing
-			"http://localhost:5173", // Vite dev server
-			"http://localhost:8080", // Local testing
-			"http://127.0.0.1:8080", // Local testing alternativo
-		},
+		// Los orígenes permitidos vienen de la configuración (ALLOWED_ORIGINS)
+		AllowOriginFunc:  container.OriginPolicy.Allowed,
 		AllowCredentials: true, // Para cookies/auth - requiere origins explícitos (no "*")
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
 		AllowedHeaders: []string{
@@ -124,6 +76,12 @@ func main() {
 			"Authorization",
 			"Accept",
 			"Origin",
+			// Headers de correlación (propagados a Payment/Booking Manager)
+			"X-Request-Id",
+			"X-Trace-Id",
+			// Propagación W3C Trace Context
+			"Traceparent",
+			"Tracestate",
 			// Headers WebSocket específicos para handshake
 			"Sec-WebSocket-Protocol",
 			"Sec-WebSocket-Version",
@@ -134,6 +92,8 @@ func main() {
 		},
 		ExposedHeaders: []string{
 			"Sec-WebSocket-Accept",
+			"X-Request-Id",
+			"Traceparent",
 		},
 		MaxAge: 86400, // Cache preflight response por 24 horas (86400 segundos)
 	})
@@ -142,28 +102,81 @@ func main() {
 	mux := http.NewServeMux()
 
 	// Endpoint GraphQL con logging para debugging WebSocket
-	mux.HandleFunc("/query", func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("📥 [%s] %s | Origin: %s | Upgrade: %s | Connection: %s | Sec-WebSocket-Key: %s",
-			r.Method,
-			r.URL.Path,
-			r.Header.Get("Origin"),
-			r.Header.Get("Upgrade"),
-			r.Header.Get("Connection"),
-			r.Header.Get("Sec-WebSocket-Key"),
+	// requestmeta.Middleware guarda request ID, IP, user agent y origin para propagarlos a gRPC
+	// (la IP solo se toma de X-Forwarded-For si la conexión viene de un proxy confiable)
+	// telemetry.HTTPHandler abre el span HTTP y continúa el traceparent entrante
+	mux.Handle("/query", telemetry.HTTPHandler("graphql", requestmeta.Middleware(container.TrustedProxies, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		container.Logger.DebugContext(r.Context(), "graphql request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"origin", r.Header.Get("Origin"),
+			"upgrade", r.Header.Get("Upgrade"),
+			"connection", r.Header.Get("Connection"),
+			"secWebSocketKey", r.Header.Get("Sec-WebSocket-Key"),
 		)
 		c.Handler(srv).ServeHTTP(w, r)
-	})
+	}))))
 
 	// GraphQL Playground
 	mux.Handle("/", playground.Handler("GraphQL Playground", "/query"))
 
-	// Endpoint de verificación de salud
+	// Endpoint de verificación de salud (se mantiene por compatibilidad; ver /healthz)
 	mux.HandleFunc("/ping", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte(`{"message":"pong"}`))
 	})
 
+	// Métricas Prometheus
+	mux.Handle("/metrics", telemetry.MetricsHandler())
+
+	// Endpoint administrativo: invalidar el cache de respuestas de un rack
+	// POST /admin/cache/invalidate?rackId=123 (sin ADMIN_TOKEN solo queda abierto en desarrollo local)
+	mux.Handle("/admin/cache/invalidate", admin.RequireToken(cfg.Admin.Token, cfg.General.IsDevelopment(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if container.PaymentInfraCache == nil {
+			http.Error(w, "cache disabled", http.StatusNotFound)
+			return
+		}
+
+		rackID, err := strconv.Atoi(r.URL.Query().Get("rackId"))
+		if err != nil || rackID <= 0 {
+			http.Error(w, "invalid rackId", http.StatusBadRequest)
+			return
+		}
+
+		removed := container.PaymentInfraCache.InvalidateRack(rackID)
+		container.Logger.InfoContext(r.Context(), "cache invalidated", "rackId", rackID, "removed", removed)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]int{
+			"rackId":  rackID,
+			"removed": removed,
+		})
+	})))
+
+	// Endpoint de diagnóstico: estado de los circuit breakers de cada upstream (mismo token que /admin)
+	mux.Handle("/diagnostics/circuit-breakers", admin.RequireToken(cfg.Admin.Token, cfg.General.IsDevelopment(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(container.PaymentServiceClient.CircuitBreakers())
+	})))
+
+	// Endpoints de salud: liveness (proceso vivo), readiness (upstreams listos o simulados)
+	// y diagnóstico detallado para operación
+	healthHandler := health.NewHandler(container.PaymentServiceClient, container.Upstreams, health.Settings{
+		GRPCCheck:    cfg.Health.GRPCCheck,
+		CheckTimeout: cfg.Health.CheckTimeout,
+	})
+	mux.HandleFunc("/healthz", healthHandler.Liveness)
+	mux.HandleFunc("/readyz", healthHandler.Readiness)
+	mux.HandleFunc("/health/details", healthHandler.Details)
+
 	// Crear servidor HTTP
 	server := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -175,11 +188,17 @@ func main() {
 
 	// Iniciar servidor en goroutine
 	go func() {
-		log.Printf("🚀 GraphQL Payment BFF Server ready at http://localhost:%s/", cfg.Server.Port)
-		log.Printf("❤️  Health check available at http://localhost:%s/ping", cfg.Server.Port)
+		logger.Info("GraphQL Payment BFF server ready",
+			"url", "http://localhost:"+cfg.Server.Port+"/",
+			"liveness", "/healthz",
+			"readiness", "/readyz",
+			"healthDetails", "/health/details",
+			"metrics", "/metrics",
+		)
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+			logger.Error("failed to start server", "error", err)
+			os.Exit(1)
 		}
 	}()
 
@@ -187,64 +206,30 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
-	log.Println("🛑 Shutting down server...")
+	logger.Info("shutting down server")
 
 	// Dar tiempo límite a las solicitudes pendientes para completarse
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
+	// Drenar las subscriptions executeOpen en paralelo al apagado HTTP: server.Shutdown no espera
+	// las conexiones WebSocket, y una apertura cortada deja al usuario sin saber si la puerta abrió.
+	// El drenaje termina antes de que Lifecycle.Shutdown (defer) cierre las conexiones gRPC.
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		drainCtx, cancelDrain := context.WithTimeout(ctx, cfg.Server.DrainTimeout)
+		defer cancelDrain()
+		if err := container.Subscriptions.Drain(drainCtx); err != nil {
+			logger.Warn("subscriptions forced to close", "error", err)
+		}
+	}()
+
 	// Apagar servidor
 	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
-	}
-
-	log.Println("✅ Server exited")
-}
-
-// getConfig carga la configuración desde variables de entorno
-func getConfig() config.Config {
-	cfg := config.DefaultConfig()
-
-	// Sobrescribir con variables de entorno si están presentes
-	if port := os.Getenv("PORT"); port != "" {
-		cfg.Server.Port = port
-	}
-
-	if env := os.Getenv("ENV"); env != "" {
-		cfg.General.Environment = env
-	}
-
-	// Mock configuration - default based on environment
-	// In deployed environments (dev/prod), default to false (real APIs)
-	// In local development, default to true (mocks)
-	if useMockEnv := os.Getenv("USE_MOCK"); useMockEnv != "" {
-		cfg.General.UseMock = (useMockEnv == "true")
-	} else {
-		// Default: use mocks only in local development (when ENV is empty or "development")
-		cfg.General.UseMock = (cfg.General.Environment == "development" || cfg.General.Environment == "")
+		logger.Warn("server forced to shutdown", "error", err)
 	}
+	<-drained
 
-	// Payment Service gRPC configuration (concatenate HOST:PORT like legacy)
-	hostPayment := os.Getenv("HOST_API_PAYMENT")
-	portPayment := os.Getenv("PORT_API_PAYMENT")
-	if hostPayment != "" && portPayment != "" {
-		cfg.GRPC.PaymentServiceAddress = hostPayment + ":" + portPayment
-	}
-
-	// Booking Service gRPC configuration (concatenate HOST:PORT like legacy)
-	hostBooking := os.Getenv("HOST_API_BOOKING")
-	portBooking := os.Getenv("PORT_API_BOOKING")
-	if hostBooking != "" && portBooking != "" {
-		cfg.GRPC.BookingServiceAddress = hostBooking + ":" + portBooking
-	}
-
-	// Log configuration
-	log.Printf("🔧 Configuration loaded:")
-	log.Printf("   Environment: %s", cfg.General.Environment)
-	log.Printf("   Use Mock: %v", cfg.General.UseMock)
-	log.Printf("   Server Port: %s", cfg.Server.Port)
-	log.Printf("   Payment Service: %s", cfg.GRPC.PaymentServiceAddress)
-	log.Printf("   Booking Service: %s", cfg.GRPC.BookingServiceAddress)
-
-	return cfg
+	logger.Info("server exited")
 }
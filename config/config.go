@@ -1,50 +1,295 @@
 package config
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Config contiene toda la configuración de la aplicación
 type Config struct {
-	Server  ServerConfig
-	GRPC    GRPCConfig
-	General GeneralConfig
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	Origins     OriginsConfig     `yaml:"origins" toml:"origins"`
+	GRPC        GRPCConfig        `yaml:"grpc" toml:"grpc"`
+	Adapters    AdaptersConfig    `yaml:"adapters" toml:"adapters"`
+	Telemetry   TelemetryConfig   `yaml:"telemetry" toml:"telemetry"`
+	Logging     LoggingConfig     `yaml:"logging" toml:"logging"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache"`
+	RateLimit   RateLimitConfig   `yaml:"rateLimit" toml:"rateLimit"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	Admin       AdminConfig       `yaml:"admin" toml:"admin"`
+	General     GeneralConfig     `yaml:"general" toml:"general"`
 }
 
 // ServerConfig contiene la configuración del servidor HTTP
 type ServerConfig struct {
-	Port         string
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
-	IdleTimeout  time.Duration
+	Port         string        `yaml:"port" toml:"port"`
+	ReadTimeout  time.Duration `yaml:"readTimeout" toml:"readTimeout"`
+	WriteTimeout time.Duration `yaml:"writeTimeout" toml:"writeTimeout"`
+	IdleTimeout  time.Duration `yaml:"idleTimeout" toml:"idleTimeout"`
+	// ShutdownTimeout es el tiempo máximo del apagado ordenado tras SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" toml:"shutdownTimeout"`
+	// DrainTimeout es el tiempo que las subscriptions executeOpen activas tienen para llegar a un estado terminal
+	DrainTimeout time.Duration `yaml:"drainTimeout" toml:"drainTimeout"`
+	// TrustedProxies son los load balancers (IPs o CIDR) cuyos X-Forwarded-For y X-Real-Ip se aceptan;
+	// sin proxies confiables la IP del cliente es la de la conexión
+	TrustedProxies []string `yaml:"trustedProxies" toml:"trustedProxies"`
+}
+
+// OriginsConfig contiene los orígenes que pueden llamar al BFF (CORS y WebSocket)
+type OriginsConfig struct {
+	// AllowedOrigins acepta "scheme://host[:port]" y subdominios con "*." (p. ej. "https://*.odihnx.com")
+	AllowedOrigins []string `yaml:"allowedOrigins" toml:"allowedOrigins"`
+	// Strict rechaza los handshakes WebSocket sin header Origin
+	Strict bool `yaml:"strict" toml:"strict"`
+}
+
+// DefaultAllowedOrigins devuelve los orígenes permitidos por defecto según el ambiente
+func DefaultAllowedOrigins(environment string) []string {
+	production := []string{
+		"https://board.api.odihnx.com",   // Board producción
+		"https://payment.api.odihnx.com", // Payment producción
+		"https://payment.odihnx.com",     // Payment producción alternativo
+	}
+	development := []string{
+		"https://board.api-dev.odihnx.com",   // Board desarrollo
+		"https://payment.api-dev.odihnx.com", // Payment desarrollo
+	}
+	local := []string{
+		"http://localhost:5173", // Vite dev server
+		"http://localhost:8080", // Local testing
+		"http://127.0.0.1:8080", // Local testing alternativo
+	}
+
+	if (GeneralConfig{Environment: environment}).IsProduction() {
+		return production
+	}
+	return append(development, local...)
 }
 
 // GRPCConfig contiene la configuración de los clientes gRPC
 type GRPCConfig struct {
-	PaymentServiceAddress string
-	PaymentServiceTimeout time.Duration
-	BookingServiceAddress string
-	BookingServiceTimeout time.Duration
+	PaymentServiceAddress string               `yaml:"paymentServiceAddress" toml:"paymentServiceAddress"`
+	PaymentServiceTimeout time.Duration        `yaml:"paymentServiceTimeout" toml:"paymentServiceTimeout"`
+	BookingServiceAddress string               `yaml:"bookingServiceAddress" toml:"bookingServiceAddress"`
+	BookingServiceTimeout time.Duration        `yaml:"bookingServiceTimeout" toml:"bookingServiceTimeout"`
+	ExecuteOpen           ExecuteOpenConfig    `yaml:"executeOpen" toml:"executeOpen"`
+	Retry                 RetryConfig          `yaml:"retry" toml:"retry"`
+	CircuitBreaker        CircuitBreakerConfig `yaml:"circuitBreaker" toml:"circuitBreaker"`
+	TLS                   TLSConfig            `yaml:"tls" toml:"tls"`
+}
+
+// ExecuteOpenConfig contiene los plazos por fase del stream ExecuteOpen (0 desactiva el plazo)
+type ExecuteOpenConfig struct {
+	ReceivedTimeout time.Duration `yaml:"receivedTimeout" toml:"receivedTimeout"` // Desde el inicio del stream hasta RECEIVED
+	TerminalTimeout time.Duration `yaml:"terminalTimeout" toml:"terminalTimeout"` // Desde REQUESTED hasta SUCCESS o ERROR
+	StreamTimeout   time.Duration `yaml:"streamTimeout" toml:"streamTimeout"`     // Duración máxima del stream completo
+}
+
+// AdaptersConfig elige el adaptador de cada upstream: "grpc" (manager real) o "mock".
+// Vacío sigue a general.useMock.
+type AdaptersConfig struct {
+	Payment string `yaml:"payment" toml:"payment"` // Payment Manager: infraestructura, cupones, órdenes de compra y reservas
+	Booking string `yaml:"booking" toml:"booking"` // Booking Manager: checkBookingStatus y executeOpen
+}
+
+// TLSConfig contiene la seguridad de transporte de las conexiones gRPC salientes
+type TLSConfig struct {
+	Insecure          bool   `yaml:"insecure" toml:"insecure"`                   // Desactiva TLS (default solo en desarrollo local; prohibido en producción)
+	CAFile            string `yaml:"caFile" toml:"caFile"`                       // Bundle de CAs; vacío usa las CAs del sistema
+	CertFile          string `yaml:"certFile" toml:"certFile"`                   // Certificado de cliente para mTLS
+	KeyFile           string `yaml:"keyFile" toml:"keyFile"`                     // Llave del certificado de cliente para mTLS
+	PaymentServerName string `yaml:"paymentServerName" toml:"paymentServerName"` // Sobrescribe el nombre del servidor de Payment Manager
+	BookingServerName string `yaml:"bookingServerName" toml:"bookingServerName"` // Sobrescribe el nombre del servidor de Booking Manager
+}
+
+// RetryConfig contiene la política de reintentos de las operaciones gRPC de solo lectura
+type RetryConfig struct {
+	MaxAttempts       int           `yaml:"maxAttempts" toml:"maxAttempts"`
+	InitialBackoff    time.Duration `yaml:"initialBackoff" toml:"initialBackoff"`
+	MaxBackoff        time.Duration `yaml:"maxBackoff" toml:"maxBackoff"`
+	Multiplier        float64       `yaml:"multiplier" toml:"multiplier"`
+	PerAttemptTimeout time.Duration `yaml:"perAttemptTimeout" toml:"perAttemptTimeout"`
+	RetryableCodes    []string      `yaml:"retryableCodes" toml:"retryableCodes"`
+}
+
+// CircuitBreakerConfig contiene los umbrales del circuit breaker de cada upstream gRPC
+type CircuitBreakerConfig struct {
+	Enabled             bool          `yaml:"enabled" toml:"enabled"`
+	FailureThreshold    int           `yaml:"failureThreshold" toml:"failureThreshold"`
+	OpenTimeout         time.Duration `yaml:"openTimeout" toml:"openTimeout"`
+	HalfOpenMaxRequests int           `yaml:"halfOpenMaxRequests" toml:"halfOpenMaxRequests"`
+}
+
+// TelemetryConfig contiene la configuración de trazas de OpenTelemetry
+type TelemetryConfig struct {
+	ServiceName  string  `yaml:"serviceName" toml:"serviceName"`   // Nombre del servicio en las trazas
+	Exporter     string  `yaml:"exporter" toml:"exporter"`         // "otlp", "stdout" o "none"
+	OTLPEndpoint string  `yaml:"otlpEndpoint" toml:"otlpEndpoint"` // host:port del collector OTLP/gRPC
+	OTLPInsecure bool    `yaml:"otlpInsecure" toml:"otlpInsecure"` // Desactiva TLS hacia el collector
+	SampleRatio  float64 `yaml:"sampleRatio" toml:"sampleRatio"`   // Fracción de trazas raíz muestreadas (0.0 - 1.0)
+}
+
+// LoggingConfig contiene la configuración del logger estructurado
+type LoggingConfig struct {
+	Format    string `yaml:"format" toml:"format"`       // "json" (ambientes desplegados) o "text" (local)
+	Level     string `yaml:"level" toml:"level"`         // "debug", "info", "warn" o "error"
+	RedactPII bool   `yaml:"redactPII" toml:"redactPII"` // Oculta email, teléfono, códigos de apertura y cupones
+}
+
+// IdempotencyConfig contiene la ventana de idempotencia de generatePurchaseOrder y generateBooking
+type IdempotencyConfig struct {
+	TTL time.Duration `yaml:"ttl" toml:"ttl"` // Tiempo durante el cual se devuelve la primera respuesta de una clave
+}
+
+// CacheConfig contiene la configuración del cache de respuestas de Payment Manager
+type CacheConfig struct {
+	Enabled             bool          `yaml:"enabled" toml:"enabled"`
+	PaymentInfraTTL     time.Duration `yaml:"paymentInfraTTL" toml:"paymentInfraTTL"`         // Vigencia de getPaymentInfraByQrValue
+	AvailableLockersTTL time.Duration `yaml:"availableLockersTTL" toml:"availableLockersTTL"` // Vigencia de getAvailableLockers (corta: cambia con cada reserva)
+	MaxEntries          int           `yaml:"maxEntries" toml:"maxEntries"`                   // Máximo de entradas por operación (LRU)
+}
+
+// RateLimitConfig contiene los límites por cliente de las operaciones que permiten adivinar códigos
+type RateLimitConfig struct {
+	Enabled    bool                  `yaml:"enabled" toml:"enabled"`
+	Coupon     RateLimitPolicyConfig `yaml:"coupon" toml:"coupon"`         // validateDiscountCoupon, por IP y rack
+	UnlockCode RateLimitPolicyConfig `yaml:"unlockCode" toml:"unlockCode"` // checkBookingStatus y executeOpen, por IP y servicio
+}
+
+// RateLimitPolicyConfig contiene el presupuesto y el bloqueo por fallas de una operación
+type RateLimitPolicyConfig struct {
+	RequestsPerMinute int           `yaml:"requestsPerMinute" toml:"requestsPerMinute"`
+	Burst             int           `yaml:"burst" toml:"burst"`
+	MaxFailures       int           `yaml:"maxFailures" toml:"maxFailures"` // Fallas dentro de FailureWindow que bloquean al cliente (0 desactiva el bloqueo)
+	FailureWindow     time.Duration `yaml:"failureWindow" toml:"failureWindow"`
+	LockoutDuration   time.Duration `yaml:"lockoutDuration" toml:"lockoutDuration"`
+}
+
+// HealthConfig contiene la configuración del endpoint /health/details
+type HealthConfig struct {
+	GRPCCheck    bool          `yaml:"grpcCheck" toml:"grpcCheck"`       // Consultar grpc.health.v1 de cada manager en cada solicitud
+	CheckTimeout time.Duration `yaml:"checkTimeout" toml:"checkTimeout"` // Tiempo máximo de cada consulta grpc.health.v1
+}
+
+// AdminConfig contiene la configuración de los endpoints administrativos
+type AdminConfig struct {
+	Token string `yaml:"token" toml:"token"` // Se exige "Authorization: Bearer <token>"; obligatorio en producción
 }
 
 // GeneralConfig contiene configuración general de la aplicación
 type GeneralConfig struct {
-	Environment string
-	UseMock     bool
+	Environment string `yaml:"environment" toml:"environment"`
+	UseMock     bool   `yaml:"useMock" toml:"useMock"` // Adaptador por defecto de los upstreams sin adapters.* explícito
+	// MockFixtures es el archivo YAML/JSON con las respuestas del adaptador mock (vacío usa los fixtures por defecto).
+	// El archivo se recarga al cambiar; cambiar la ruta requiere reiniciar.
+	MockFixtures string `yaml:"mockFixtures" toml:"mockFixtures"`
+}
+
+// UpstreamAdapters devuelve el adaptador efectivo de Payment y Booking Manager
+func (c Config) UpstreamAdapters() (payment string, booking string) {
+	resolve := func(adapter string) string {
+		if adapter = strings.ToLower(strings.TrimSpace(adapter)); adapter != "" {
+			return adapter
+		}
+		if c.General.UseMock {
+			return "mock"
+		}
+		return "grpc"
+	}
+	return resolve(c.Adapters.Payment), resolve(c.Adapters.Booking)
+}
+
+// IsDevelopment indica si la aplicación se ejecuta en desarrollo local
+func (g GeneralConfig) IsDevelopment() bool {
+	return g.Environment == "development"
+}
+
+// IsProduction indica si la aplicación se ejecuta en el ambiente de producción
+func (g GeneralConfig) IsProduction() bool {
+	return g.Environment == "prod" || g.Environment == "production"
 }
 
 // DefaultConfig devuelve la configuración por defecto
 func DefaultConfig() Config {
 	return Config{
 		Server: ServerConfig{
-			Port:         "8080",
-			ReadTimeout:  15 * time.Second,
-			WriteTimeout: 15 * time.Second,
-			IdleTimeout:  60 * time.Second,
+			Port:            "8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+			DrainTimeout:    20 * time.Second,
+		},
+		Origins: OriginsConfig{
+			AllowedOrigins: DefaultAllowedOrigins("development"),
 		},
 		GRPC: GRPCConfig{
 			PaymentServiceAddress: "localhost:50051",
 			PaymentServiceTimeout: 10 * time.Second,
 			BookingServiceAddress: "localhost:50052",
 			BookingServiceTimeout: 10 * time.Second,
+			ExecuteOpen: ExecuteOpenConfig{
+				ReceivedTimeout: 10 * time.Second,
+				TerminalTimeout: 30 * time.Second,
+				StreamTimeout:   60 * time.Second,
+			},
+			Retry: RetryConfig{
+				MaxAttempts:       3,
+				InitialBackoff:    100 * time.Millisecond,
+				MaxBackoff:        1 * time.Second,
+				Multiplier:        2.0,
+				PerAttemptTimeout: 3 * time.Second,
+				RetryableCodes:    []string{"UNAVAILABLE", "DEADLINE_EXCEEDED", "ABORTED"},
+			},
+			CircuitBreaker: CircuitBreakerConfig{
+				Enabled:             true,
+				FailureThreshold:    5,
+				OpenTimeout:         30 * time.Second,
+				HalfOpenMaxRequests: 1,
+			},
+			TLS: TLSConfig{
+				Insecure: true,
+			},
+		},
+		Telemetry: TelemetryConfig{
+			ServiceName: "bff-graphql-payment",
+			Exporter:    "none",
+			SampleRatio: 1.0,
+		},
+		Logging: LoggingConfig{
+			Format:    "text",
+			Level:     "info",
+			RedactPII: true,
+		},
+		Idempotency: IdempotencyConfig{
+			TTL: 10 * time.Minute,
+		},
+		Cache: CacheConfig{
+			Enabled:             true,
+			PaymentInfraTTL:     5 * time.Minute,
+			AvailableLockersTTL: 5 * time.Second,
+			MaxEntries:          1000,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled: true,
+			Coupon: RateLimitPolicyConfig{
+				RequestsPerMinute: 10,
+				Burst:             5,
+				MaxFailures:       5,
+				FailureWindow:     10 * time.Minute,
+				LockoutDuration:   15 * time.Minute,
+			},
+			UnlockCode: RateLimitPolicyConfig{
+				RequestsPerMinute: 20,
+				Burst:             10,
+				MaxFailures:       5,
+				FailureWindow:     10 * time.Minute,
+				LockoutDuration:   15 * time.Minute,
+			},
+		},
+		Health: HealthConfig{
+			CheckTimeout: 2 * time.Second,
 		},
 		General: GeneralConfig{
 			Environment: "development",
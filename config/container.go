@@ -1,11 +1,25 @@
 package config
 
 import (
+	appPorts "bff-graphql-payment/internal/application/ports"
 	"bff-graphql-payment/internal/application/service"
 	"bff-graphql-payment/internal/domain/ports"
 	"bff-graphql-payment/internal/infrastructure/inbound/graphql/resolver"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/subscription"
+	"bff-graphql-payment/internal/infrastructure/logging"
+	"bff-graphql-payment/internal/infrastructure/origin"
+	"bff-graphql-payment/internal/infrastructure/outbound/cache"
 	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"bff-graphql-payment/internal/infrastructure/outbound/idempotency"
+	"bff-graphql-payment/internal/infrastructure/outbound/mock"
+	"bff-graphql-payment/internal/infrastructure/outbound/routing"
+	"bff-graphql-payment/internal/infrastructure/ratelimit"
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 )
 
 // Container contiene todas las dependencias de la aplicación
@@ -15,32 +29,282 @@ type Container struct {
 
 	// Resolvers
 	GraphQLResolver *resolver.Resolver
+	Subscriptions   *subscription.Registry // Subscriptions activas, se drenan al apagar el servidor
 
 	// Infraestructura
+	Logger               *slog.Logger
+	LogLevel             *slog.LevelVar
+	OriginPolicy         *origin.Policy
+	TrustedProxies       *requestmeta.TrustedProxies // Load balancers cuyos headers de IP del cliente se aceptan
 	PaymentServiceClient *client.PaymentServiceGRPCClient
+	MockRepository       *mock.MockPaymentInfraRepository // nil mientras ningún upstream use el adaptador mock
+	Upstreams            *routing.Repository              // Elige el adaptador (gRPC o mock) de cada upstream
+	PaymentInfraCache    *cache.CachingRepository         // nil si el cache está desactivado
+	RateLimiter          *ratelimit.RateLimitedService
+	ShutdownTracing      telemetry.ShutdownFunc
 }
 
 // NewContainer crea un nuevo contenedor de inyección de dependencias
 func NewContainer(config Config) (*Container, error) {
 	container := &Container{}
 
-	// Inicializar cliente gRPC (mock o real según configuración)
-	paymentClient, err := client.NewPaymentServiceGRPCClient(
+	// Inicializar logger estructurado; también recibe los mensajes del paquete log estándar
+	container.LogLevel = new(slog.LevelVar)
+	container.Logger = logging.New(logging.Settings{
+		Format:    config.Logging.Format,
+		Level:     config.Logging.Level,
+		LevelVar:  container.LogLevel,
+		RedactPII: config.Logging.RedactPII,
+	})
+	slog.SetDefault(container.Logger)
+
+	// Política de orígenes compartida por CORS y el upgrade WebSocket
+	originPolicy, err := origin.NewPolicy(config.Origins.AllowedOrigins, config.Origins.Strict)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed origins: %w", err)
+	}
+	container.OriginPolicy = originPolicy
+
+	// Proxies confiables para obtener la IP del cliente (rate limiting y metadata de los upstreams)
+	trustedProxies, err := requestmeta.ParseTrustedProxies(config.Server.TrustedProxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	container.TrustedProxies = trustedProxies
+
+	// Inicializar tracing antes que los clientes para que sus spans usen el provider configurado
+	shutdownTracing, err := telemetry.SetupTracing(context.Background(), telemetry.TracingSettings{
+		Exporter:     config.Telemetry.Exporter,
+		OTLPEndpoint: config.Telemetry.OTLPEndpoint,
+		OTLPInsecure: config.Telemetry.OTLPInsecure,
+		SampleRatio:  config.Telemetry.SampleRatio,
+		ServiceName:  config.Telemetry.ServiceName,
+		Environment:  config.General.Environment,
+		Logger:       container.Logger.With("component", "telemetry"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup tracing: %w", err)
+	}
+	container.ShutdownTracing = shutdownTracing
+
+	// Construir política de reintentos para operaciones de solo lectura
+	retryableCodes, err := client.ParseRetryableCodes(config.GRPC.Retry.RetryableCodes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry configuration: %w", err)
+	}
+	retryPolicy := client.RetryPolicy{
+		MaxAttempts:       config.GRPC.Retry.MaxAttempts,
+		InitialBackoff:    config.GRPC.Retry.InitialBackoff,
+		MaxBackoff:        config.GRPC.Retry.MaxBackoff,
+		Multiplier:        config.GRPC.Retry.Multiplier,
+		PerAttemptTimeout: config.GRPC.Retry.PerAttemptTimeout,
+		RetryableCodes:    retryableCodes,
+	}
+
+	// Construir configuración de circuit breakers (uno por upstream)
+	breakerSettings := client.CircuitBreakerSettings{
+		Enabled:             config.GRPC.CircuitBreaker.Enabled,
+		FailureThreshold:    config.GRPC.CircuitBreaker.FailureThreshold,
+		OpenTimeout:         config.GRPC.CircuitBreaker.OpenTimeout,
+		HalfOpenMaxRequests: config.GRPC.CircuitBreaker.HalfOpenMaxRequests,
+	}
+
+	// Construir configuración TLS de las conexiones salientes
+	tlsSettings := client.TLSSettings{
+		Insecure:          config.GRPC.TLS.Insecure,
+		CAFile:            config.GRPC.TLS.CAFile,
+		CertFile:          config.GRPC.TLS.CertFile,
+		KeyFile:           config.GRPC.TLS.KeyFile,
+		PaymentServerName: config.GRPC.TLS.PaymentServerName,
+		BookingServerName: config.GRPC.TLS.BookingServerName,
+	}
+
+	// Adaptador gRPC: solo se conecta a los upstreams que lo usan
+	container.PaymentServiceClient = client.NewPaymentServiceGRPCClient(
 		config.GRPC.PaymentServiceAddress,
 		config.GRPC.BookingServiceAddress,
 		config.GRPC.PaymentServiceTimeout,
-		config.General.UseMock,
+		config.GRPC.BookingServiceTimeout,
+		retryPolicy,
+		breakerSettings,
+		tlsSettings,
+		container.Logger,
+	)
+
+	// Enrutar cada upstream a su adaptador. El mock solo se crea si algún upstream lo usa
+	// (o cuando se pasa a él en caliente).
+	selection := upstreamSelection(config)
+	if err := container.connectUpstreams(selection); err != nil {
+		return nil, err
+	}
+	adapters := map[string]appPorts.PaymentInfraRepository{
+		routing.AdapterGRPC: container.PaymentServiceClient,
+	}
+	if usesMock(selection) {
+		if err := container.createMockRepository(config.General.MockFixtures); err != nil {
+			return nil, err
+		}
+		adapters[routing.AdapterMock] = container.MockRepository
+	}
+	container.Upstreams, err = routing.NewRepository(
+		adapters,
+		selection,
+		routing.OpenTimeouts{
+			Received: config.GRPC.ExecuteOpen.ReceivedTimeout,
+			Terminal: config.GRPC.ExecuteOpen.TerminalTimeout,
+			Stream:   config.GRPC.ExecuteOpen.StreamTimeout,
+		},
+		container.Logger,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create payment service client: %w", err)
+		return nil, err
 	}
-	container.PaymentServiceClient = paymentClient
+	container.Logger.Info("upstream adapters selected", "payment", selection.Payment, "booking", selection.Booking)
 
 	// Inicializar servicios de aplicación
-	container.PaymentInfraService = service.NewPaymentInfraService(paymentClient)
+	// Cache read-through entre el servicio y el repositorio
+	var repository appPorts.PaymentInfraRepository = container.Upstreams
+	if config.Cache.Enabled {
+		container.PaymentInfraCache = cache.NewCachingRepository(container.Upstreams, cacheSettings(config.Cache))
+		repository = container.PaymentInfraCache
+	}
+
+	// El store en memoria sirve para una réplica; con varias réplicas se debe usar un store compartido
+	idempotencyStore := idempotency.NewMemoryStore()
+	container.PaymentInfraService = service.NewPaymentInfraService(
+		repository,
+		idempotencyStore,
+		service.IdempotencySettings{TTL: config.Idempotency.TTL},
+		container.Logger,
+	)
+
+	// Limitar por cliente las operaciones que permiten adivinar cupones y códigos de apertura.
+	// El decorador se instala siempre para poder activarlo en caliente.
+	container.RateLimiter = ratelimit.NewRateLimitedService(
+		container.PaymentInfraService,
+		config.RateLimit.Enabled,
+		rateLimitSettings(config.RateLimit),
+		container.Logger,
+	)
+	container.PaymentInfraService = container.RateLimiter
 
 	// Inicializar resolvers GraphQL
-	container.GraphQLResolver = resolver.NewResolver(container.PaymentInfraService)
+	container.Subscriptions = subscription.NewRegistry(container.Logger)
+	container.GraphQLResolver = resolver.NewResolver(container.PaymentInfraService, container.Subscriptions, container.Logger)
 
 	return container, nil
 }
+
+// ApplyConfig aplica a los componentes los valores que se pueden recargar en caliente
+func (c *Container) ApplyConfig(previous Config, current Config) error {
+	var errs []error
+
+	if err := c.OriginPolicy.Update(current.Origins.AllowedOrigins, current.Origins.Strict); err != nil {
+		errs = append(errs, fmt.Errorf("origins: %w", err))
+	}
+
+	c.LogLevel.Set(logging.ParseLevel(current.Logging.Level))
+
+	if c.PaymentInfraCache != nil && previous.Cache != current.Cache {
+		c.PaymentInfraCache.UpdateSettings(cacheSettings(current.Cache))
+	}
+
+	c.RateLimiter.Update(current.RateLimit.Enabled, rateLimitSettings(current.RateLimit))
+
+	// Cambio de adaptadores: conectar primero los upstreams que pasan a gRPC (o crear el mock
+	// si es la primera vez que se usa) y descartar las respuestas cacheadas del adaptador anterior
+	if selection := upstreamSelection(current); selection != upstreamSelection(previous) {
+		if err := c.connectUpstreams(selection); err != nil {
+			errs = append(errs, fmt.Errorf("adapters: %w", err))
+		} else if err := c.ensureMockRepository(selection, current.General.MockFixtures); err != nil {
+			errs = append(errs, fmt.Errorf("adapters: %w", err))
+		} else if err := c.Upstreams.Select(selection); err != nil {
+			errs = append(errs, fmt.Errorf("adapters: %w", err))
+		} else if c.PaymentInfraCache != nil {
+			c.PaymentInfraCache.Purge()
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// connectUpstreams conecta el cliente gRPC a los upstreams que usan el adaptador gRPC
+func (c *Container) connectUpstreams(selection routing.Selection) error {
+	if selection.Payment == routing.AdapterGRPC {
+		if err := c.PaymentServiceClient.Connect(client.UpstreamPayment); err != nil {
+			return err
+		}
+	}
+	if selection.Booking == routing.AdapterGRPC {
+		if err := c.PaymentServiceClient.Connect(client.UpstreamBooking); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ensureMockRepository crea el adaptador mock y lo registra en el enrutador si la selección lo usa
+// y todavía no existe. Una vez creado se conserva: los streams en curso pueden seguir usándolo.
+func (c *Container) ensureMockRepository(selection routing.Selection, fixturesPath string) error {
+	if !usesMock(selection) || c.MockRepository != nil {
+		return nil
+	}
+	if err := c.createMockRepository(fixturesPath); err != nil {
+		return err
+	}
+	c.Upstreams.AddAdapter(routing.AdapterMock, c.MockRepository)
+	return nil
+}
+
+// createMockRepository crea el adaptador mock con los fixtures por defecto o el archivo indicado
+// y vigila el archivo para recargarlo al cambiar
+func (c *Container) createMockRepository(fixturesPath string) error {
+	mockRepository, err := mock.NewMockPaymentInfraRepository(fixturesPath, c.Logger)
+	if err != nil {
+		return fmt.Errorf("failed to create mock repository: %w", err)
+	}
+	if err := mockRepository.Watch(); err != nil {
+		return errors.Join(err, mockRepository.Close())
+	}
+	c.MockRepository = mockRepository
+	return nil
+}
+
+// usesMock indica si algún upstream usa el adaptador mock
+func usesMock(selection routing.Selection) bool {
+	return selection.Payment == routing.AdapterMock || selection.Booking == routing.AdapterMock
+}
+
+// upstreamSelection convierte la elección de adaptadores al tipo del enrutador
+func upstreamSelection(config Config) routing.Selection {
+	payment, booking := config.UpstreamAdapters()
+	return routing.Selection{Payment: payment, Booking: booking}
+}
+
+// cacheSettings convierte la configuración del cache al tipo del decorador
+func cacheSettings(config CacheConfig) cache.Settings {
+	return cache.Settings{
+		PaymentInfraTTL:     config.PaymentInfraTTL,
+		AvailableLockersTTL: config.AvailableLockersTTL,
+		MaxEntries:          config.MaxEntries,
+	}
+}
+
+// rateLimitSettings convierte la configuración de rate limiting al tipo del decorador
+func rateLimitSettings(config RateLimitConfig) ratelimit.Settings {
+	return ratelimit.Settings{
+		Coupon:     rateLimitPolicy(config.Coupon),
+		UnlockCode: rateLimitPolicy(config.UnlockCode),
+	}
+}
+
+// rateLimitPolicy convierte la configuración de una política al tipo del limitador
+func rateLimitPolicy(policy RateLimitPolicyConfig) ratelimit.Policy {
+	return ratelimit.Policy{
+		RequestsPerMinute: policy.RequestsPerMinute,
+		Burst:             policy.Burst,
+		MaxFailures:       policy.MaxFailures,
+		FailureWindow:     policy.FailureWindow,
+		LockoutDuration:   policy.LockoutDuration,
+	}
+}
@@ -0,0 +1,74 @@
+package config
+
+import (
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"bff-graphql-payment/internal/infrastructure/outbound/routing"
+	"testing"
+)
+
+// containerConfig devuelve una configuración de desarrollo con los adaptadores indicados y
+// upstreams gRPC en direcciones que no se contactan
+func containerConfig(payment string, booking string) Config {
+	cfg := validConfig("development")
+	cfg.Adapters = AdaptersConfig{Payment: payment, Booking: booking}
+	cfg.GRPC.PaymentServiceAddress = "passthrough:///payment:50051"
+	cfg.GRPC.BookingServiceAddress = "passthrough:///booking:50052"
+	cfg.Logging.Level = "error"
+	return cfg
+}
+
+// newTestContainer crea el contenedor y lo cierra al terminar el test
+func newTestContainer(t *testing.T, cfg Config) *Container {
+	t.Helper()
+	container, err := NewContainer(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := NewLifecycle(container, cfg, nil).Shutdown(); err != nil {
+			t.Error(err)
+		}
+	})
+	return container
+}
+
+func TestContainerCreatesMockOnlyWhenUsed(t *testing.T) {
+	cfg := containerConfig(routing.AdapterGRPC, routing.AdapterGRPC)
+	container := newTestContainer(t, cfg)
+	if container.MockRepository != nil {
+		t.Fatal("the mock repository must not be created when no upstream uses it")
+	}
+
+	// Pasar Booking Manager al mock en caliente crea el adaptador
+	next := cfg
+	next.Adapters.Booking = routing.AdapterMock
+	if err := container.ApplyConfig(cfg, next); err != nil {
+		t.Fatal(err)
+	}
+	if container.MockRepository == nil {
+		t.Fatal("expected the mock repository to be created on the switch")
+	}
+	if adapter := container.Upstreams.Adapters()[client.UpstreamBooking]; adapter != routing.AdapterMock {
+		t.Errorf("expected booking to use the mock adapter, got %q", adapter)
+	}
+
+	// Volver a gRPC conserva el mock ya creado para los streams en curso
+	mockRepository := container.MockRepository
+	if err := container.ApplyConfig(next, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if container.MockRepository != mockRepository {
+		t.Error("the mock repository must be kept after switching back")
+	}
+}
+
+func TestContainerCreatesMockAtStartup(t *testing.T) {
+	cfg := containerConfig(routing.AdapterMock, routing.AdapterGRPC)
+	container := newTestContainer(t, cfg)
+	if container.MockRepository == nil {
+		t.Fatal("expected the mock repository when the payment upstream uses it")
+	}
+	if adapter := container.Upstreams.Adapters()[client.UpstreamPayment]; adapter != routing.AdapterMock {
+		t.Errorf("expected payment to use the mock adapter, got %q", adapter)
+	}
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envReader aplica las variables de entorno sobre la configuración y acumula los valores mal formados
+type envReader struct {
+	errs []error
+}
+
+// apply sobrescribe la configuración con las variables de entorno presentes
+func (e *envReader) apply(cfg *Config) {
+	// Servidor HTTP
+	e.str("PORT", &cfg.Server.Port)
+	e.duration("SERVER_READ_TIMEOUT", &cfg.Server.ReadTimeout)
+	e.duration("SERVER_WRITE_TIMEOUT", &cfg.Server.WriteTimeout)
+	e.duration("SERVER_IDLE_TIMEOUT", &cfg.Server.IdleTimeout)
+	e.duration("SERVER_SHUTDOWN_TIMEOUT", &cfg.Server.ShutdownTimeout)
+	e.duration("SERVER_DRAIN_TIMEOUT", &cfg.Server.DrainTimeout)
+	e.list("TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+
+	e.str("ENV", &cfg.General.Environment)
+	e.boolean("USE_MOCK", &cfg.General.UseMock)
+	e.str("MOCK_FIXTURES", &cfg.General.MockFixtures)
+	e.str("PAYMENT_ADAPTER", &cfg.Adapters.Payment)
+	e.str("BOOKING_ADAPTER", &cfg.Adapters.Booking)
+
+	// Orígenes permitidos (CORS y WebSocket)
+	if originsFile := os.Getenv("ALLOWED_ORIGINS_FILE"); originsFile != "" {
+		origins, err := readOriginsFile(originsFile)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("ALLOWED_ORIGINS_FILE: %w", err))
+		} else {
+			cfg.Origins.AllowedOrigins = origins
+		}
+	}
+	e.list("ALLOWED_ORIGINS", &cfg.Origins.AllowedOrigins)
+	e.boolean("ORIGINS_STRICT", &cfg.Origins.Strict)
+
+	// Upstreams gRPC (HOST:PORT como en el legacy; ambas variables son obligatorias)
+	e.address("HOST_API_PAYMENT", "PORT_API_PAYMENT", &cfg.GRPC.PaymentServiceAddress)
+	e.address("HOST_API_BOOKING", "PORT_API_BOOKING", &cfg.GRPC.BookingServiceAddress)
+	e.duration("GRPC_PAYMENT_TIMEOUT", &cfg.GRPC.PaymentServiceTimeout)
+	e.duration("GRPC_BOOKING_TIMEOUT", &cfg.GRPC.BookingServiceTimeout)
+
+	// Plazos por fase de ExecuteOpen
+	e.duration("GRPC_EXECUTE_OPEN_RECEIVED_TIMEOUT", &cfg.GRPC.ExecuteOpen.ReceivedTimeout)
+	e.duration("GRPC_EXECUTE_OPEN_TERMINAL_TIMEOUT", &cfg.GRPC.ExecuteOpen.TerminalTimeout)
+	e.duration("GRPC_EXECUTE_OPEN_STREAM_TIMEOUT", &cfg.GRPC.ExecuteOpen.StreamTimeout)
+
+	// Retry policy para operaciones gRPC de solo lectura
+	e.integer("GRPC_RETRY_MAX_ATTEMPTS", &cfg.GRPC.Retry.MaxAttempts)
+	e.duration("GRPC_RETRY_INITIAL_BACKOFF", &cfg.GRPC.Retry.InitialBackoff)
+	e.duration("GRPC_RETRY_MAX_BACKOFF", &cfg.GRPC.Retry.MaxBackoff)
+	e.float("GRPC_RETRY_MULTIPLIER", &cfg.GRPC.Retry.Multiplier)
+	e.duration("GRPC_RETRY_PER_ATTEMPT_TIMEOUT", &cfg.GRPC.Retry.PerAttemptTimeout)
+	e.list("GRPC_RETRY_CODES", &cfg.GRPC.Retry.RetryableCodes)
+
+	// Circuit breaker por upstream gRPC
+	e.boolean("GRPC_BREAKER_ENABLED", &cfg.GRPC.CircuitBreaker.Enabled)
+	e.integer("GRPC_BREAKER_FAILURE_THRESHOLD", &cfg.GRPC.CircuitBreaker.FailureThreshold)
+	e.duration("GRPC_BREAKER_OPEN_TIMEOUT", &cfg.GRPC.CircuitBreaker.OpenTimeout)
+	e.integer("GRPC_BREAKER_HALF_OPEN_MAX_REQUESTS", &cfg.GRPC.CircuitBreaker.HalfOpenMaxRequests)
+
+	// TLS/mTLS para las conexiones gRPC salientes
+	e.str("GRPC_TLS_CA_FILE", &cfg.GRPC.TLS.CAFile)
+	e.str("GRPC_TLS_CERT_FILE", &cfg.GRPC.TLS.CertFile)
+	e.str("GRPC_TLS_KEY_FILE", &cfg.GRPC.TLS.KeyFile)
+	e.str("GRPC_TLS_PAYMENT_SERVER_NAME", &cfg.GRPC.TLS.PaymentServerName)
+	e.str("GRPC_TLS_BOOKING_SERVER_NAME", &cfg.GRPC.TLS.BookingServerName)
+	if os.Getenv("GRPC_TLS_INSECURE") != "" {
+		e.boolean("GRPC_TLS_INSECURE", &cfg.GRPC.TLS.Insecure)
+	} else if os.Getenv("GRPC_TLS_CA_FILE") != "" || os.Getenv("GRPC_TLS_CERT_FILE") != "" {
+		// Si se configuraron certificados, activar TLS por defecto
+		cfg.GRPC.TLS.Insecure = false
+	}
+
+	// Tracing OpenTelemetry (OTEL_TRACES_EXPORTER: otlp, stdout o none)
+	e.str("OTEL_TRACES_EXPORTER", &cfg.Telemetry.Exporter)
+	e.str("OTEL_EXPORTER_OTLP_ENDPOINT", &cfg.Telemetry.OTLPEndpoint)
+	e.boolean("OTEL_EXPORTER_OTLP_INSECURE", &cfg.Telemetry.OTLPInsecure)
+	e.float("OTEL_TRACES_SAMPLER_ARG", &cfg.Telemetry.SampleRatio)
+	e.str("OTEL_SERVICE_NAME", &cfg.Telemetry.ServiceName)
+
+	// Logging estructurado
+	e.str("LOG_FORMAT", &cfg.Logging.Format)
+	e.str("LOG_LEVEL", &cfg.Logging.Level)
+	e.boolean("LOG_REDACT_PII", &cfg.Logging.RedactPII)
+
+	// Ventana de idempotencia para generatePurchaseOrder y generateBooking
+	e.duration("IDEMPOTENCY_TTL", &cfg.Idempotency.TTL)
+
+	// Cache de respuestas de Payment Manager
+	e.boolean("CACHE_ENABLED", &cfg.Cache.Enabled)
+	e.duration("CACHE_PAYMENT_INFRA_TTL", &cfg.Cache.PaymentInfraTTL)
+	e.duration("CACHE_AVAILABLE_LOCKERS_TTL", &cfg.Cache.AvailableLockersTTL)
+	e.integer("CACHE_MAX_ENTRIES", &cfg.Cache.MaxEntries)
+
+	// Rate limiting de cupones y códigos de apertura
+	e.boolean("RATE_LIMIT_ENABLED", &cfg.RateLimit.Enabled)
+	e.rateLimitPolicy("RATE_LIMIT_COUPON", &cfg.RateLimit.Coupon)
+	e.rateLimitPolicy("RATE_LIMIT_UNLOCK", &cfg.RateLimit.UnlockCode)
+
+	// Diagnóstico detallado de salud
+	e.boolean("HEALTH_GRPC_CHECK", &cfg.Health.GRPCCheck)
+	e.duration("HEALTH_CHECK_TIMEOUT", &cfg.Health.CheckTimeout)
+
+	// Token de los endpoints administrativos
+	e.str("ADMIN_TOKEN", &cfg.Admin.Token)
+}
+
+// rateLimitPolicy sobrescribe una política de rate limiting con las variables <prefix>_*
+func (e *envReader) rateLimitPolicy(prefix string, policy *RateLimitPolicyConfig) {
+	e.integer(prefix+"_PER_MINUTE", &policy.RequestsPerMinute)
+	e.integer(prefix+"_BURST", &policy.Burst)
+	e.integer(prefix+"_MAX_FAILURES", &policy.MaxFailures)
+	e.duration(prefix+"_FAILURE_WINDOW", &policy.FailureWindow)
+	e.duration(prefix+"_LOCKOUT", &policy.LockoutDuration)
+}
+
+// address arma "host:port" a partir de dos variables; definir solo una es un error
+func (e *envReader) address(hostName string, portName string, target *string) {
+	host, port := os.Getenv(hostName), os.Getenv(portName)
+	switch {
+	case host != "" && port != "":
+		*target = host + ":" + port
+	case host != "":
+		e.errs = append(e.errs, fmt.Errorf("%s is set but %s is missing", hostName, portName))
+	case port != "":
+		e.errs = append(e.errs, fmt.Errorf("%s is set but %s is missing", portName, hostName))
+	}
+}
+
+func (e *envReader) str(name string, target *string) {
+	if value := os.Getenv(name); value != "" {
+		*target = value
+	}
+}
+
+func (e *envReader) list(name string, target *[]string) {
+	if value := os.Getenv(name); value != "" {
+		*target = strings.Split(value, ",")
+	}
+}
+
+func (e *envReader) boolean(name string, target *bool) {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid boolean %q", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (e *envReader) integer(name string, target *int) {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid integer %q", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (e *envReader) float(name string, target *float64) {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid number %q", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+func (e *envReader) duration(name string, target *time.Duration) {
+	if value := os.Getenv(name); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s: invalid duration %q (e.g. 500ms, 10s, 5m)", name, value))
+			return
+		}
+		*target = parsed
+	}
+}
+
+// readOriginsFile lee un origen permitido por línea; ignora líneas vacías y comentarios (#)
+func readOriginsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var origins []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		origins = append(origins, line)
+	}
+	return origins, nil
+}
@@ -1,32 +1,259 @@
 package config
 
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// ErrRestartRequired se devuelve cuando una recarga modifica valores que solo se aplican reiniciando
+var ErrRestartRequired = errors.New("configuration change requires a restart")
+
+// reloadDebounce agrupa los eventos del archivo (los editores y Kubernetes escriben en varios pasos)
+const reloadDebounce = 500 * time.Millisecond
+
 // Lifecycle gestiona el ciclo de vida de los recursos de la aplicación
+// y la recarga en caliente de la configuración
 type Lifecycle struct {
 	container *Container
+	// args son los argumentos de línea de comandos con los que se vuelve a cargar la configuración
+	args []string
+
+	// reloadMu serializa las recargas; mu protege la configuración vigente y los suscriptores
+	reloadMu    sync.Mutex
+	mu          sync.Mutex
+	current     Config
+	subscribers []ReloadFunc
+
+	stopWatch context.CancelFunc
+	watchDone chan struct{}
 }
 
-// NewLifecycle crea un nuevo gestor de ciclo de vida
-func NewLifecycle(container *Container) *Lifecycle {
-	return &Lifecycle{
+// NewLifecycle crea un nuevo gestor de ciclo de vida. Los componentes del contenedor
+// quedan suscritos a las recargas de configuración.
+func NewLifecycle(container *Container, current Config, args []string) *Lifecycle {
+	lifecycle := &Lifecycle{
 		container: container,
+		args:      args,
+		current:   current,
+	}
+	if container != nil {
+		lifecycle.Subscribe(container.ApplyConfig)
+	}
+	return lifecycle
+}
+
+// Subscribe registra una función que se ejecuta cada vez que se aplica una recarga
+func (l *Lifecycle) Subscribe(fn ReloadFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.subscribers = append(l.subscribers, fn)
+}
+
+// Config devuelve la configuración vigente
+func (l *Lifecycle) Config() Config {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.current
+}
+
+// Reload vuelve a cargar la configuración (archivo, variables de entorno y flags) y la aplica.
+// Si cambió algún valor que requiere reinicio, la recarga completa se rechaza.
+func (l *Lifecycle) Reload() error {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	logger := l.logger()
+
+	next, err := Load(l.args)
+	if err != nil {
+		logger.Error("config reload failed, keeping current configuration", "error", err)
+		return err
+	}
+
+	previous := l.Config()
+	if keys := restartRequired(previous, next); len(keys) > 0 {
+		logger.Error("config reload rejected: these settings only apply after a restart", "keys", keys)
+		return fmt.Errorf("%w: %s", ErrRestartRequired, strings.Join(keys, ", "))
+	}
+
+	changed := changedConfigKeys(previous, next)
+	if len(changed) == 0 {
+		logger.Info("config reload: no changes")
+		return nil
+	}
+
+	l.mu.Lock()
+	subscribers := append([]ReloadFunc(nil), l.subscribers...)
+	l.mu.Unlock()
+
+	var errs []error
+	for _, subscriber := range subscribers {
+		if err := subscriber(previous, next); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	// Si algún suscriptor falla se conserva la configuración vigente: la próxima recarga
+	// vuelve a detectar los cambios y reintenta aplicarlos
+	if err := errors.Join(errs...); err != nil {
+		logger.Error("config reload failed to apply, keeping current configuration", "keys", changed, "error", err)
+		return err
+	}
+
+	l.mu.Lock()
+	l.current = next
+	l.mu.Unlock()
+
+	logger.Info("config reloaded", "keys", changed)
+	return nil
+}
+
+// Watch recarga la configuración al recibir SIGHUP y, si hay archivo de configuración,
+// cada vez que su contenido cambia. Se detiene con Shutdown.
+func (l *Lifecycle) Watch() error {
+	configFile, err := ConfigFilePath(l.args)
+	if err != nil {
+		return err
+	}
+
+	var watcher *fsnotify.Watcher
+	if configFile != "" {
+		watcher, err = fsnotify.NewWatcher()
+		if err != nil {
+			return fmt.Errorf("failed to watch config file: %w", err)
+		}
+		// Se observa el directorio para detectar reemplazos atómicos y symlinks de ConfigMaps
+		if err := watcher.Add(filepath.Dir(configFile)); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch config file: %w", err)
+		}
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.stopWatch = cancel
+	l.watchDone = make(chan struct{})
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+
+	go func() {
+		defer close(l.watchDone)
+		defer signal.Stop(hangup)
+
+		var events <-chan fsnotify.Event
+		var watchErrors <-chan error
+		if watcher != nil {
+			defer watcher.Close()
+			events = watcher.Events
+			watchErrors = watcher.Errors
+		}
+
+		lastHash := fileHash(configFile)
+		debounce := time.NewTimer(reloadDebounce)
+		debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangup:
+				l.logger().Info("SIGHUP received, reloading configuration")
+				_ = l.Reload()
+				lastHash = fileHash(configFile)
+			case <-events:
+				debounce.Reset(reloadDebounce)
+			case <-debounce.C:
+				// Solo recargar si el contenido cambió (evita recargas por eventos de otros archivos)
+				if hash := fileHash(configFile); !bytes.Equal(hash, lastHash) {
+					lastHash = hash
+					l.logger().Info("config file changed, reloading configuration", "file", configFile)
+					_ = l.Reload()
+				}
+			case err := <-watchErrors:
+				l.logger().Warn("config file watcher error", "error", err)
+			}
+		}
+	}()
+
+	if configFile != "" {
+		l.logger().Info("watching configuration for changes", "file", configFile)
+	}
+	return nil
 }
 
-// Shutdown cierra todos los recursos de forma ordenada
+// Shutdown cierra todos los recursos de forma ordenada. Un error no interrumpe el cierre del resto:
+// se devuelven todos juntos.
 func (l *Lifecycle) Shutdown() error {
+	// Dejar de observar la configuración antes de cerrar los componentes
+	if l.stopWatch != nil {
+		l.stopWatch()
+		<-l.watchDone
+	}
+
 	if l.container == nil {
 		return nil
 	}
 
+	var errs []error
+
 	// Cerrar cliente gRPC de pagos
 	if l.container.PaymentServiceClient != nil {
 		if err := l.container.PaymentServiceClient.Close(); err != nil {
-			return err
+			errs = append(errs, fmt.Errorf("failed to close gRPC clients: %w", err))
+		}
+	}
+
+	// Dejar de observar los fixtures del adaptador mock
+	if l.container.MockRepository != nil {
+		if err := l.container.MockRepository.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close mock repository: %w", err))
+		}
+	}
+
+	// Vaciar las trazas pendientes antes de salir
+	if l.container.ShutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := l.container.ShutdownTracing(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush traces: %w", err))
 		}
 	}
 
 	// Aquí se pueden agregar más recursos a cerrar en el futuro
 	// Por ejemplo: conexiones a base de datos, caches, etc.
 
-	return nil
+	return errors.Join(errs...)
+}
+
+// logger devuelve el logger del contenedor o el logger por defecto
+func (l *Lifecycle) logger() *slog.Logger {
+	if l.container != nil && l.container.Logger != nil {
+		return l.container.Logger.With("component", "lifecycle")
+	}
+	return slog.Default().With("component", "lifecycle")
+}
+
+// fileHash devuelve el hash del contenido del archivo (nil si no existe o no se puede leer)
+func fileHash(path string) []byte {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	hash := sha256.Sum256(data)
+	return hash[:]
 }
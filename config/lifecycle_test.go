@@ -0,0 +1,98 @@
+package config
+
+import (
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShutdownClosesEverythingAndJoinsErrors(t *testing.T) {
+	grpcClient := client.NewPaymentServiceGRPCClient("passthrough:///payment:50051", "passthrough:///booking:50052", time.Second, time.Second,
+		client.RetryPolicy{}, client.CircuitBreakerSettings{}, client.TLSSettings{Insecure: true}, slog.New(slog.DiscardHandler))
+	if err := grpcClient.Connect(client.UpstreamPayment); err != nil {
+		t.Fatal(err)
+	}
+	// Cerrar una conexión ya cerrada falla: simula un recurso que no se puede cerrar
+	if err := grpcClient.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tracingFlushed := false
+	errTracing := errors.New("collector unreachable")
+	lifecycle := NewLifecycle(&Container{
+		PaymentServiceClient: grpcClient,
+		ShutdownTracing: func(ctx context.Context) error {
+			tracingFlushed = true
+			return errTracing
+		},
+	}, DefaultConfig(), nil)
+
+	err := lifecycle.Shutdown()
+	if !tracingFlushed {
+		t.Error("traces were not flushed after the gRPC client failed to close")
+	}
+	if !errors.Is(err, errTracing) {
+		t.Errorf("expected the tracing error, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "gRPC clients") {
+		t.Errorf("expected the gRPC client error, got %v", err)
+	}
+}
+
+func TestShutdownWithoutErrors(t *testing.T) {
+	lifecycle := NewLifecycle(&Container{
+		ShutdownTracing: func(ctx context.Context) error { return nil },
+	}, DefaultConfig(), nil)
+	if err := lifecycle.Shutdown(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestReloadRetriesAfterSubscriberFailure(t *testing.T) {
+	isolateEnv(t)
+	path := writeConfigFile(t, "config.yaml", "general:\n  environment: development\n  useMock: true\nlogging:\n  level: info\n")
+	args := []string{"-config", path}
+
+	current, err := Load(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lifecycle := NewLifecycle(nil, current, args)
+
+	errApply := errors.New("apply failed")
+	var applied []string
+	lifecycle.Subscribe(func(previous Config, next Config) error {
+		applied = append(applied, next.Logging.Level)
+		if len(applied) == 1 {
+			return errApply
+		}
+		return nil
+	})
+
+	if err := os.WriteFile(path, []byte("general:\n  environment: development\n  useMock: true\nlogging:\n  level: debug\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lifecycle.Reload(); !errors.Is(err, errApply) {
+		t.Fatalf("expected the subscriber error, got %v", err)
+	}
+	if got := lifecycle.Config().Logging.Level; got != "info" {
+		t.Errorf("a failed reload must keep the current configuration, got level %q", got)
+	}
+
+	// La segunda recarga vuelve a ver el cambio y lo reintenta
+	if err := lifecycle.Reload(); err != nil {
+		t.Fatalf("unexpected error on retry: %v", err)
+	}
+	if len(applied) != 2 || applied[1] != "debug" {
+		t.Errorf("expected the change to be applied again, got %v", applied)
+	}
+	if got := lifecycle.Config().Logging.Level; got != "debug" {
+		t.Errorf("expected level debug after the retry, got %q", got)
+	}
+}
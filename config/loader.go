@@ -0,0 +1,227 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// EnvConfigFile es la variable de entorno con la ruta del archivo de configuración
+const EnvConfigFile = "CONFIG_FILE"
+
+// flagValues son los valores recibidos por línea de comandos (última capa)
+type flagValues struct {
+	configFile string
+	port       string
+	env        string
+	useMock    bool
+	logLevel   string
+	logFormat  string
+
+	// set contiene los flags presentes en la línea de comandos
+	set map[string]bool
+}
+
+// Load arma la configuración por capas: defaults, archivo YAML/TOML, variables de entorno y flags.
+// Devuelve todos los problemas encontrados (valores mal formados y validación) en un solo error.
+func Load(args []string) (Config, error) {
+	flags, err := parseFlags(args)
+	if err != nil {
+		return Config{}, err
+	}
+
+	configFile := flags.configFilePath()
+
+	var fileConfig []byte
+	if configFile != "" {
+		fileConfig, err = os.ReadFile(configFile)
+		if err != nil {
+			return Config{}, fmt.Errorf("failed to read config file: %w", err)
+		}
+	}
+
+	// El ambiente define los defaults de orígenes, mocks y formato de logs,
+	// por lo que se resuelve antes de aplicar las capas
+	environment, err := resolveEnvironment(configFile, fileConfig, flags)
+	if err != nil {
+		return Config{}, err
+	}
+
+	cfg := DefaultConfig()
+	cfg.applyEnvironmentDefaults(environment)
+
+	if configFile != "" {
+		if err := decodeFile(configFile, fileConfig, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	env := &envReader{}
+	env.apply(&cfg)
+
+	flags.apply(&cfg)
+
+	problems := env.errs
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	if len(problems) > 0 {
+		return Config{}, fmt.Errorf("invalid configuration:\n%w", errors.Join(problems...))
+	}
+	return cfg, nil
+}
+
+// applyEnvironmentDefaults ajusta los defaults que dependen del ambiente
+func (c *Config) applyEnvironmentDefaults(environment string) {
+	c.General.Environment = environment
+	// Mocks solo en desarrollo local; en ambientes desplegados se usan las APIs reales
+	c.General.UseMock = c.General.IsDevelopment()
+	c.Origins.AllowedOrigins = DefaultAllowedOrigins(environment)
+	c.Origins.Strict = c.General.IsProduction()
+	// gRPC sin TLS solo en desarrollo local
+	c.GRPC.TLS.Insecure = c.General.IsDevelopment()
+	// JSON en ambientes desplegados, texto en local
+	if !c.General.IsDevelopment() {
+		c.Logging.Format = "json"
+	}
+}
+
+// ConfigFilePath devuelve la ruta del archivo de configuración indicada por -config o CONFIG_FILE
+func ConfigFilePath(args []string) (string, error) {
+	flags, err := parseFlags(args)
+	if err != nil {
+		return "", err
+	}
+	return flags.configFilePath(), nil
+}
+
+// configFilePath resuelve la ruta del archivo: el flag tiene precedencia sobre CONFIG_FILE
+func (f *flagValues) configFilePath() string {
+	if f.set["config"] {
+		return f.configFile
+	}
+	return os.Getenv(EnvConfigFile)
+}
+
+// parseFlags interpreta los flags de línea de comandos
+func parseFlags(args []string) (*flagValues, error) {
+	values := &flagValues{set: map[string]bool{}}
+
+	flagSet := flag.NewFlagSet("bff-graphql-payment", flag.ContinueOnError)
+	flagSet.StringVar(&values.configFile, "config", "", "ruta del archivo de configuración YAML o TOML (también CONFIG_FILE)")
+	flagSet.StringVar(&values.port, "port", "", "puerto HTTP del servidor")
+	flagSet.StringVar(&values.env, "env", "", "ambiente (development, dev, prod)")
+	flagSet.BoolVar(&values.useMock, "use-mock", false, "usar mocks en lugar de Payment y Booking Manager")
+	flagSet.StringVar(&values.logLevel, "log-level", "", "nivel de log (debug, info, warn, error)")
+	flagSet.StringVar(&values.logFormat, "log-format", "", "formato de log (json, text)")
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	flagSet.Visit(func(f *flag.Flag) {
+		values.set[f.Name] = true
+	})
+	return values, nil
+}
+
+// apply sobrescribe la configuración con los flags presentes
+func (f *flagValues) apply(cfg *Config) {
+	if f.set["env"] {
+		cfg.General.Environment = f.env
+	}
+	if f.set["port"] {
+		cfg.Server.Port = f.port
+	}
+	if f.set["use-mock"] {
+		cfg.General.UseMock = f.useMock
+	}
+	if f.set["log-level"] {
+		cfg.Logging.Level = f.logLevel
+	}
+	if f.set["log-format"] {
+		cfg.Logging.Format = f.logFormat
+	}
+}
+
+// resolveEnvironment obtiene el ambiente con la misma precedencia que el resto: flag, ENV, archivo
+func resolveEnvironment(configFile string, fileConfig []byte, flags *flagValues) (string, error) {
+	if flags.set["env"] {
+		return flags.env, nil
+	}
+	if environment := os.Getenv("ENV"); environment != "" {
+		return environment, nil
+	}
+	if configFile != "" {
+		var probe struct {
+			General struct {
+				Environment string `yaml:"environment" toml:"environment"`
+			} `yaml:"general" toml:"general"`
+		}
+		if err := decodeFile(configFile, fileConfig, &probe); err != nil {
+			return "", err
+		}
+		if probe.General.Environment != "" {
+			return probe.General.Environment, nil
+		}
+	}
+	return "development", nil
+}
+
+// decodeFile decodifica el archivo según su extensión. Las claves desconocidas son un error
+// para detectar errores de tipeo.
+func decodeFile(path string, data []byte, target interface{}) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		_, isConfig := target.(*Config)
+		decoder.KnownFields(isConfig)
+		if err := decoder.Decode(target); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		metadata, err := toml.NewDecoder(bytes.NewReader(data)).Decode(target)
+		if err != nil {
+			return fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		if _, isConfig := target.(*Config); isConfig {
+			if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+				keys := make([]string, 0, len(undecoded))
+				for _, key := range undecoded {
+					keys = append(keys, key.String())
+				}
+				return fmt.Errorf("failed to parse config file %s: unknown keys %s", path, strings.Join(keys, ", "))
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+	return nil
+}
+
+// redactedValue reemplaza los secretos al imprimir la configuración
+const redactedValue = "[REDACTED]"
+
+// Redacted devuelve una copia de la configuración con los secretos ocultos
+func (c Config) Redacted() Config {
+	if c.Admin.Token != "" {
+		c.Admin.Token = redactedValue
+	}
+	return c
+}
+
+// String devuelve la configuración efectiva en YAML con los secretos ocultos
+func (c Config) String() string {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return fmt.Sprintf("<unable to print config: %v>", err)
+	}
+	return string(data)
+}
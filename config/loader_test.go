@@ -0,0 +1,185 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeConfigFile escribe el archivo de configuración en un directorio temporal
+func writeConfigFile(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// isolateEnv vacía las variables que pueden venir del entorno del test (las vacías se ignoran)
+func isolateEnv(t *testing.T) {
+	for _, name := range []string{EnvConfigFile, "ENV", "PORT", "LOG_LEVEL", "LOG_FORMAT", "USE_MOCK", "ADMIN_TOKEN"} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	files := map[string]string{
+		"config.yaml": `
+general:
+  environment: dev
+server:
+  port: "9000"
+logging:
+  level: warn
+grpc:
+  paymentServiceAddress: payment:50051
+  bookingServiceAddress: booking:50052
+  paymentServiceTimeout: 3s
+admin:
+  token: from-file
+`,
+		"config.toml": `
+[general]
+environment = "dev"
+
+[server]
+port = "9000"
+
+[logging]
+level = "warn"
+
+[grpc]
+paymentServiceAddress = "payment:50051"
+bookingServiceAddress = "booking:50052"
+paymentServiceTimeout = "3s"
+
+[admin]
+token = "from-file"
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			isolateEnv(t)
+			t.Setenv("PORT", "9100")
+			t.Setenv("LOG_LEVEL", "debug")
+
+			cfg, err := Load([]string{"-config", writeConfigFile(t, name, content), "-port", "9200"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// flag > env > archivo > defaults
+			if cfg.Server.Port != "9200" {
+				t.Errorf("expected the flag port 9200, got %q", cfg.Server.Port)
+			}
+			if cfg.Logging.Level != "debug" {
+				t.Errorf("expected the env log level, got %q", cfg.Logging.Level)
+			}
+			if cfg.GRPC.PaymentServiceTimeout != 3*time.Second {
+				t.Errorf("expected the file payment timeout, got %s", cfg.GRPC.PaymentServiceTimeout)
+			}
+			if cfg.Server.ShutdownTimeout != DefaultConfig().Server.ShutdownTimeout {
+				t.Errorf("expected the default shutdown timeout, got %s", cfg.Server.ShutdownTimeout)
+			}
+
+			// Los defaults del ambiente salen del archivo: fuera de development no hay mocks ni gRPC sin TLS
+			if cfg.General.Environment != "dev" || cfg.General.UseMock || cfg.GRPC.TLS.Insecure || cfg.Logging.Format != "json" {
+				t.Errorf("expected the dev environment defaults, got environment=%q useMock=%v insecure=%v format=%q",
+					cfg.General.Environment, cfg.General.UseMock, cfg.GRPC.TLS.Insecure, cfg.Logging.Format)
+			}
+		})
+	}
+}
+
+func TestLoadEnvironmentPrecedence(t *testing.T) {
+	path := writeConfigFile(t, "config.yaml", "general:\n  environment: dev\nadmin:\n  token: s3cret\n")
+
+	tests := []struct {
+		name string
+		env  string
+		args []string
+		want string
+	}{
+		{name: "file", want: "dev"},
+		{name: "env over file", env: "development", want: "development"},
+		{name: "flag over env", env: "dev", args: []string{"-env", "development"}, want: "development"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnv(t)
+			t.Setenv("ENV", tt.env)
+			// El adaptador mock evita exigir las direcciones de los upstreams
+			t.Setenv("USE_MOCK", "true")
+
+			cfg, err := Load(append([]string{"-config", path}, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.General.Environment != tt.want {
+				t.Errorf("expected environment %q, got %q", tt.want, cfg.General.Environment)
+			}
+			if wantInsecure := tt.want == "development"; cfg.GRPC.TLS.Insecure != wantInsecure {
+				t.Errorf("expected the %s defaults (insecure=%v), got insecure=%v", tt.want, wantInsecure, cfg.GRPC.TLS.Insecure)
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	isolateEnv(t)
+	path := writeConfigFile(t, "config.yaml", `
+server:
+  shutdownTimeout: 5s
+  drainTimeout: 10s
+`)
+	t.Setenv("HOST_API_PAYMENT", "payment")
+	t.Setenv("GRPC_BOOKING_TIMEOUT", "soon")
+	t.Setenv("CACHE_ENABLED", "sometimes")
+
+	_, err := Load([]string{"-config", path, "-port", "70000"})
+	if err == nil {
+		t.Fatal("expected a configuration error")
+	}
+	for _, want := range []string{
+		"HOST_API_PAYMENT is set but PORT_API_PAYMENT is missing",
+		"GRPC_BOOKING_TIMEOUT: invalid duration",
+		"CACHE_ENABLED: invalid boolean",
+		"server.port",
+		"server.drainTimeout",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in the error, got:\n%v", want, err)
+		}
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	for name, content := range map[string]string{
+		"config.yaml": "server:\n  prot: \"9000\"\n",
+		"config.toml": "[server]\nprot = \"9000\"\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			isolateEnv(t)
+			_, err := Load([]string{"-config", writeConfigFile(t, name, content)})
+			if err == nil || !strings.Contains(err.Error(), "prot") {
+				t.Errorf("expected an unknown key error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigStringRedactsSecrets(t *testing.T) {
+	cfg := validConfig("prod")
+	printed := cfg.String()
+	if strings.Contains(printed, "s3cret") || !strings.Contains(printed, redactedValue) {
+		t.Errorf("expected the admin token to be redacted:\n%s", printed)
+	}
+	if cfg.Admin.Token != "s3cret" {
+		t.Error("printing the config must not modify it")
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// reloadableKeys son las claves (o prefijos de clave) que se pueden cambiar sin reiniciar
+var reloadableKeys = []string{
+	"origins.",
+	"logging.level",
+	"cache.paymentInfraTTL",
+	"cache.availableLockersTTL",
+	"cache.maxEntries",
+	"rateLimit.",
+	"general.useMock",
+	"adapters.",
+}
+
+// ReloadFunc recibe la configuración anterior y la nueva cuando se aplica una recarga
+type ReloadFunc func(previous Config, current Config) error
+
+// isReloadable indica si la clave se puede cambiar en caliente
+func isReloadable(key string) bool {
+	for _, reloadable := range reloadableKeys {
+		if key == reloadable || (strings.HasSuffix(reloadable, ".") && strings.HasPrefix(key, reloadable)) {
+			return true
+		}
+	}
+	return false
+}
+
+// restartRequired devuelve las claves modificadas que solo se aplican reiniciando el servicio
+func restartRequired(previous Config, current Config) []string {
+	var keys []string
+	for _, key := range changedConfigKeys(previous, current) {
+		if !isReloadable(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// changedConfigKeys devuelve todas las claves que difieren entre dos configuraciones
+func changedConfigKeys(previous Config, current Config) []string {
+	return changedKeys(reflect.ValueOf(previous), reflect.ValueOf(current), "")
+}
+
+// changedKeys compara dos configuraciones y devuelve las claves (según el tag yaml) que difieren
+func changedKeys(previous reflect.Value, current reflect.Value, prefix string) []string {
+	if previous.Kind() != reflect.Struct {
+		if reflect.DeepEqual(previous.Interface(), current.Interface()) {
+			return nil
+		}
+		return []string{strings.TrimSuffix(prefix, ".")}
+	}
+
+	var keys []string
+	for i := 0; i < previous.NumField(); i++ {
+		field := previous.Type().Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		keys = append(keys, changedKeys(previous.Field(i), current.Field(i), prefix+name+".")...)
+	}
+	return keys
+}
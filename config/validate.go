@@ -0,0 +1,184 @@
+package config
+
+import (
+	"bff-graphql-payment/internal/infrastructure/origin"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"bff-graphql-payment/internal/infrastructure/outbound/mock"
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validator acumula los problemas de la configuración con la ruta de la clave afectada
+type validator struct {
+	errs []error
+}
+
+func (v *validator) failf(key string, format string, args ...interface{}) {
+	v.errs = append(v.errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+}
+
+func (v *validator) positive(key string, value time.Duration) {
+	if value <= 0 {
+		v.failf(key, "must be greater than 0 (got %s)", value)
+	}
+}
+
+func (v *validator) nonNegative(key string, value time.Duration) {
+	if value < 0 {
+		v.failf(key, "must not be negative (got %s)", value)
+	}
+}
+
+func (v *validator) atLeast(key string, value int, minimum int) {
+	if value < minimum {
+		v.failf(key, "must be at least %d (got %d)", minimum, value)
+	}
+}
+
+func (v *validator) oneOf(key string, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return
+		}
+	}
+	v.failf(key, "must be one of %s (got %q)", strings.Join(allowed, ", "), value)
+}
+
+func (v *validator) address(key string, value string) {
+	host, port, err := net.SplitHostPort(value)
+	if err != nil || host == "" || port == "" {
+		v.failf(key, "must be host:port (got %q)", value)
+	}
+}
+
+// Validate revisa toda la configuración y devuelve todos los problemas encontrados en un solo error
+func (c Config) Validate() error {
+	v := &validator{}
+
+	// Servidor HTTP
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		v.failf("server.port", "must be a TCP port between 1 and 65535 (got %q)", c.Server.Port)
+	}
+	v.nonNegative("server.readTimeout", c.Server.ReadTimeout)
+	v.nonNegative("server.writeTimeout", c.Server.WriteTimeout)
+	v.nonNegative("server.idleTimeout", c.Server.IdleTimeout)
+	v.positive("server.shutdownTimeout", c.Server.ShutdownTimeout)
+	v.positive("server.drainTimeout", c.Server.DrainTimeout)
+	if c.Server.DrainTimeout > c.Server.ShutdownTimeout {
+		v.failf("server.drainTimeout", "must not exceed server.shutdownTimeout (%s)", c.Server.ShutdownTimeout)
+	}
+	if _, err := requestmeta.ParseTrustedProxies(c.Server.TrustedProxies); err != nil {
+		v.failf("server.trustedProxies", "%v", err)
+	}
+
+	// Orígenes permitidos
+	if _, err := origin.NewPolicy(c.Origins.AllowedOrigins, c.Origins.Strict); err != nil {
+		v.failf("origins.allowedOrigins", "%v", err)
+	}
+
+	// Upstreams gRPC (la dirección solo se exige al upstream que usa el adaptador gRPC)
+	if c.Adapters.Payment != "" {
+		v.oneOf("adapters.payment", c.Adapters.Payment, "grpc", "mock")
+	}
+	if c.Adapters.Booking != "" {
+		v.oneOf("adapters.booking", c.Adapters.Booking, "grpc", "mock")
+	}
+	paymentAdapter, bookingAdapter := c.UpstreamAdapters()
+	if paymentAdapter == "grpc" {
+		v.address("grpc.paymentServiceAddress", c.GRPC.PaymentServiceAddress)
+	}
+	if bookingAdapter == "grpc" {
+		v.address("grpc.bookingServiceAddress", c.GRPC.BookingServiceAddress)
+	}
+	v.positive("grpc.paymentServiceTimeout", c.GRPC.PaymentServiceTimeout)
+	v.positive("grpc.bookingServiceTimeout", c.GRPC.BookingServiceTimeout)
+	v.nonNegative("grpc.executeOpen.receivedTimeout", c.GRPC.ExecuteOpen.ReceivedTimeout)
+	v.nonNegative("grpc.executeOpen.terminalTimeout", c.GRPC.ExecuteOpen.TerminalTimeout)
+	v.nonNegative("grpc.executeOpen.streamTimeout", c.GRPC.ExecuteOpen.StreamTimeout)
+
+	retry := c.GRPC.Retry
+	v.atLeast("grpc.retry.maxAttempts", retry.MaxAttempts, 1)
+	v.positive("grpc.retry.initialBackoff", retry.InitialBackoff)
+	v.positive("grpc.retry.maxBackoff", retry.MaxBackoff)
+	if retry.InitialBackoff > retry.MaxBackoff {
+		v.failf("grpc.retry.initialBackoff", "must not exceed grpc.retry.maxBackoff (%s > %s)", retry.InitialBackoff, retry.MaxBackoff)
+	}
+	if retry.Multiplier < 1 {
+		v.failf("grpc.retry.multiplier", "must be at least 1 (got %v)", retry.Multiplier)
+	}
+	v.positive("grpc.retry.perAttemptTimeout", retry.PerAttemptTimeout)
+	if _, err := client.ParseRetryableCodes(retry.RetryableCodes); err != nil {
+		v.failf("grpc.retry.retryableCodes", "%v", err)
+	}
+
+	if breaker := c.GRPC.CircuitBreaker; breaker.Enabled {
+		v.atLeast("grpc.circuitBreaker.failureThreshold", breaker.FailureThreshold, 1)
+		v.positive("grpc.circuitBreaker.openTimeout", breaker.OpenTimeout)
+		v.atLeast("grpc.circuitBreaker.halfOpenMaxRequests", breaker.HalfOpenMaxRequests, 1)
+	}
+
+	if tls := c.GRPC.TLS; (tls.CertFile == "") != (tls.KeyFile == "") {
+		v.failf("grpc.tls", "certFile and keyFile must be set together for mTLS")
+	}
+	if c.GRPC.TLS.Insecure && c.General.IsProduction() {
+		v.failf("grpc.tls.insecure", "must be false in production")
+	}
+
+	// Telemetría y logging
+	v.oneOf("telemetry.exporter", c.Telemetry.Exporter, "otlp", "stdout", "none")
+	if c.Telemetry.SampleRatio < 0 || c.Telemetry.SampleRatio > 1 {
+		v.failf("telemetry.sampleRatio", "must be between 0 and 1 (got %v)", c.Telemetry.SampleRatio)
+	}
+	if strings.TrimSpace(c.Telemetry.ServiceName) == "" {
+		v.failf("telemetry.serviceName", "must not be empty")
+	}
+	v.oneOf("logging.format", c.Logging.Format, "json", "text")
+	v.oneOf("logging.level", c.Logging.Level, "debug", "info", "warn", "warning", "error")
+
+	// Idempotencia, cache y rate limiting
+	v.positive("idempotency.ttl", c.Idempotency.TTL)
+	if c.Cache.Enabled {
+		v.positive("cache.paymentInfraTTL", c.Cache.PaymentInfraTTL)
+		v.positive("cache.availableLockersTTL", c.Cache.AvailableLockersTTL)
+		v.atLeast("cache.maxEntries", c.Cache.MaxEntries, 1)
+	}
+	if c.RateLimit.Enabled {
+		v.rateLimitPolicy("rateLimit.coupon", c.RateLimit.Coupon)
+		v.rateLimitPolicy("rateLimit.unlockCode", c.RateLimit.UnlockCode)
+	}
+
+	if c.Health.GRPCCheck {
+		v.positive("health.checkTimeout", c.Health.CheckTimeout)
+	}
+
+	// Sin token los endpoints administrativos se deshabilitan (salvo en desarrollo local); producción los necesita
+	if c.Admin.Token == "" && c.General.IsProduction() {
+		v.failf("admin.token", "is required in production")
+	}
+
+	if strings.TrimSpace(c.General.Environment) == "" {
+		v.failf("general.environment", "must not be empty")
+	}
+	if c.General.MockFixtures != "" {
+		if _, err := mock.LoadFixtures(c.General.MockFixtures); err != nil {
+			v.failf("general.mockFixtures", "%v", err)
+		}
+	}
+
+	return errors.Join(v.errs...)
+}
+
+func (v *validator) rateLimitPolicy(key string, policy RateLimitPolicyConfig) {
+	v.atLeast(key+".requestsPerMinute", policy.RequestsPerMinute, 1)
+	v.atLeast(key+".burst", policy.Burst, 1)
+	v.atLeast(key+".maxFailures", policy.MaxFailures, 0)
+	if policy.MaxFailures > 0 {
+		v.positive(key+".failureWindow", policy.FailureWindow)
+		v.positive(key+".lockoutDuration", policy.LockoutDuration)
+	}
+}
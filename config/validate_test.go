@@ -0,0 +1,86 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// validConfig devuelve una configuración válida para el ambiente indicado
+func validConfig(environment string) Config {
+	cfg := DefaultConfig()
+	cfg.applyEnvironmentDefaults(environment)
+	cfg.Admin.Token = "s3cret"
+	return cfg
+}
+
+func TestGRPCTLSDefaults(t *testing.T) {
+	tests := []struct {
+		environment  string
+		wantInsecure bool
+	}{
+		{environment: "development", wantInsecure: true},
+		{environment: "dev", wantInsecure: false},
+		{environment: "prod", wantInsecure: false},
+	}
+	for _, tt := range tests {
+		cfg := validConfig(tt.environment)
+		if cfg.GRPC.TLS.Insecure != tt.wantInsecure {
+			t.Errorf("%s: expected grpc.tls.insecure=%v, got %v", tt.environment, tt.wantInsecure, cfg.GRPC.TLS.Insecure)
+		}
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("%s: unexpected validation error: %v", tt.environment, err)
+		}
+	}
+}
+
+func TestValidateRejectsInsecureGRPCInProduction(t *testing.T) {
+	cfg := validConfig("prod")
+	cfg.GRPC.TLS.Insecure = true
+
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "grpc.tls.insecure") {
+		t.Errorf("expected grpc.tls.insecure error, got %v", err)
+	}
+
+	cfg = validConfig("dev")
+	cfg.GRPC.TLS.Insecure = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("insecure gRPC should be allowed outside production: %v", err)
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	cfg := validConfig("prod")
+	cfg.Server.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.10"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected validation error: %v", err)
+	}
+
+	cfg.Server.TrustedProxies = []string{"load-balancer"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "server.trustedProxies") {
+		t.Errorf("expected server.trustedProxies error, got %v", err)
+	}
+}
+
+func TestValidateAdminToken(t *testing.T) {
+	tests := []struct {
+		environment string
+		wantErr     bool
+	}{
+		{environment: "development"},
+		{environment: "dev"},
+		{environment: "prod", wantErr: true},
+		{environment: "production", wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := validConfig(tt.environment)
+		cfg.Admin.Token = ""
+		err := cfg.Validate()
+		if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "admin.token")) {
+			t.Errorf("%s: expected admin.token error, got %v", tt.environment, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected validation error: %v", tt.environment, err)
+		}
+	}
+}
@@ -111,8 +111,8 @@ type ComplexityRoot struct {
 	}
 
 	Mutation struct {
-		GenerateBooking       func(childComplexity int, input model.GenerateBookingInput) int
-		GeneratePurchaseOrder func(childComplexity int, input model.GeneratePurchaseOrderInput) int
+		GenerateBooking       func(childComplexity int, input model.GenerateBookingInput, idempotencyKey *string) int
+		GeneratePurchaseOrder func(childComplexity int, input model.GeneratePurchaseOrderInput, idempotencyKey *string) int
 	}
 
 	PaymentBookingTime struct {
@@ -202,8 +202,8 @@ type ComplexityRoot struct {
 }
 
 type MutationResolver interface {
-	GeneratePurchaseOrder(ctx context.Context, input model.GeneratePurchaseOrderInput) (*model.GeneratePurchaseOrderResponse, error)
-	GenerateBooking(ctx context.Context, input model.GenerateBookingInput) (*model.GenerateBookingResponse, error)
+	GeneratePurchaseOrder(ctx context.Context, input model.GeneratePurchaseOrderInput, idempotencyKey *string) (*model.GeneratePurchaseOrderResponse, error)
+	GenerateBooking(ctx context.Context, input model.GenerateBookingInput, idempotencyKey *string) (*model.GenerateBookingResponse, error)
 }
 type QueryResolver interface {
 	GetPaymentInfraByQRValue(ctx context.Context, input model.GetPaymentInfraByQRValueInput) (*model.PaymentInfraResponse, error)
@@ -532,7 +532,7 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 			return 0, false
 		}
 
-		return e.complexity.Mutation.GenerateBooking(childComplexity, args["input"].(model.GenerateBookingInput)), true
+		return e.complexity.Mutation.GenerateBooking(childComplexity, args["input"].(model.GenerateBookingInput), args["idempotencyKey"].(*string)), true
 
 	case "Mutation.generatePurchaseOrder":
 		if e.complexity.Mutation.GeneratePurchaseOrder == nil {
@@ -544,7 +544,7 @@ func (e *executableSchema) Complexity(ctx context.Context, typeName, field strin
 			return 0, false
 		}
 
-		return e.complexity.Mutation.GeneratePurchaseOrder(childComplexity, args["input"].(model.GeneratePurchaseOrderInput)), true
+		return e.complexity.Mutation.GeneratePurchaseOrder(childComplexity, args["input"].(model.GeneratePurchaseOrderInput), args["idempotencyKey"].(*string)), true
 
 	case "PaymentBookingTime.amount":
 		if e.complexity.PaymentBookingTime.Amount == nil {
@@ -1110,10 +1110,12 @@ var sources = []*ast.Source{
 
 type Mutation {
   # Generate Purchase Order
-  generatePurchaseOrder(input: GeneratePurchaseOrderInput!): GeneratePurchaseOrderResponse!
+  # idempotencyKey (optional): replays with the same key return the first response
+  generatePurchaseOrder(input: GeneratePurchaseOrderInput!, idempotencyKey: String): GeneratePurchaseOrderResponse!
 
   # Generate Booking
-  generateBooking(input: GenerateBookingInput!): GenerateBookingResponse!
+  # idempotencyKey (optional): replays with the same key return the first response
+  generateBooking(input: GenerateBookingInput!, idempotencyKey: String): GenerateBookingResponse!
 }
 
 type Subscription {
@@ -1362,6 +1364,11 @@ func (ec *executionContext) field_Mutation_generateBooking_args(ctx context.Cont
 		return nil, err
 	}
 	args["input"] = arg0
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "idempotencyKey", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["idempotencyKey"] = arg1
 	return args, nil
 }
 
@@ -1373,6 +1380,11 @@ func (ec *executionContext) field_Mutation_generatePurchaseOrder_args(ctx contex
 		return nil, err
 	}
 	args["input"] = arg0
+	arg1, err := graphql.ProcessArgField(ctx, rawArgs, "idempotencyKey", ec.unmarshalOString2ᚖstring)
+	if err != nil {
+		return nil, err
+	}
+	args["idempotencyKey"] = arg1
 	return args, nil
 }
 
@@ -3360,7 +3372,7 @@ func (ec *executionContext) _Mutation_generatePurchaseOrder(ctx context.Context,
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (any, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Mutation().GeneratePurchaseOrder(rctx, fc.Args["input"].(model.GeneratePurchaseOrderInput))
+		return ec.resolvers.Mutation().GeneratePurchaseOrder(rctx, fc.Args["input"].(model.GeneratePurchaseOrderInput), fc.Args["idempotencyKey"].(*string))
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -3427,7 +3439,7 @@ func (ec *executionContext) _Mutation_generateBooking(ctx context.Context, field
 	}()
 	resTmp, err := ec.ResolverMiddleware(ctx, func(rctx context.Context) (any, error) {
 		ctx = rctx // use context from middleware stack in children
-		return ec.resolvers.Mutation().GenerateBooking(rctx, fc.Args["input"].(model.GenerateBookingInput))
+		return ec.resolvers.Mutation().GenerateBooking(rctx, fc.Args["input"].(model.GenerateBookingInput), fc.Args["idempotencyKey"].(*string))
 	})
 	if err != nil {
 		ec.Error(ctx, err)
@@ -1,6 +1,10 @@
 package exception
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 var (
 	// ErrValidationFailed se devuelve cuando falla la validación de entrada
@@ -8,4 +12,44 @@ var (
 
 	// ErrServiceUnavailable se devuelve cuando un servicio requerido no está disponible
 	ErrServiceUnavailable = errors.New("service unavailable")
+
+	// ErrIdempotencyKeyConflict se devuelve cuando una clave de idempotencia se reutiliza con un payload distinto
+	ErrIdempotencyKeyConflict = errors.New("idempotency key already used with a different request")
+
+	// ErrIdempotencyKeyInProgress se devuelve cuando la solicitud original con la misma clave aún no termina
+	ErrIdempotencyKeyInProgress = errors.New("request with the same idempotency key is still in progress")
+
+	// ErrIdempotentResponseUnavailable se devuelve cuando la solicitud original con la misma clave terminó
+	// pero su respuesta no pudo guardarse; repetirla podría duplicar la operación
+	ErrIdempotentResponseUnavailable = errors.New("request with the same idempotency key already completed but its response is not available")
+
+	// ErrInvalidIdempotencyKey se devuelve cuando la clave de idempotencia no es válida
+	ErrInvalidIdempotencyKey = errors.New("invalid idempotency key")
+
+	// ErrShuttingDown se devuelve cuando el servidor se está apagando y no acepta operaciones nuevas
+	ErrShuttingDown = errors.New("server is shutting down")
 )
+
+// ErrRateLimited se devuelve cuando el cliente superó el límite de solicitudes de una operación
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimitError indica cuánto debe esperar el cliente antes de reintentar
+type RateLimitError struct {
+	Operation  string
+	RetryAfter time.Duration
+	// Locked indica que el cliente está bloqueado por fallas repetidas (no solo por volumen)
+	Locked bool
+}
+
+// Error implementa error
+func (e *RateLimitError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("%s: too many failed attempts, retry after %s", e.Operation, e.RetryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("%s: %v, retry after %s", e.Operation, ErrRateLimited, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap permite usar errors.Is(err, ErrRateLimited)
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
@@ -0,0 +1,29 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+// IdempotencyRecord representa una ejecución registrada bajo una clave de idempotencia
+type IdempotencyRecord struct {
+	// Fingerprint identifica la operación y el payload de la solicitud original
+	Fingerprint string
+	// Completed indica si la ejecución original terminó con éxito
+	Completed bool
+	// Response es la respuesta serializada de la ejecución original; vacía si la ejecución terminó
+	// pero su respuesta no pudo serializarse
+	Response []byte
+}
+
+// IdempotencyStore define el almacenamiento de claves de idempotencia.
+// La implementación en memoria sirve para una instancia; para varias réplicas se requiere un store compartido.
+type IdempotencyStore interface {
+	// Reserve reserva la clave para una nueva ejecución. Si la clave ya existe devuelve el registro
+	// existente y reserved=false.
+	Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (record *IdempotencyRecord, reserved bool, err error)
+	// Complete guarda la respuesta de una ejecución exitosa durante la ventana indicada
+	Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error
+	// Release libera una clave reservada cuya ejecución falló, para permitir un reintento
+	Release(ctx context.Context, key string) error
+}
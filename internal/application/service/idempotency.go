@@ -0,0 +1,152 @@
+package service
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"bff-graphql-payment/internal/application/ports"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+const (
+	// maxIdempotencyKeyLength limita el tamaño de las claves recibidas desde el frontend
+	maxIdempotencyKeyLength = 128
+	// idempotencyPollInterval es la espera entre consultas mientras la solicitud original está en curso
+	idempotencyPollInterval = 100 * time.Millisecond
+	// defaultIdempotencyMaxWait es la espera máxima de una repetición si no se configura otra
+	defaultIdempotencyMaxWait = 30 * time.Second
+)
+
+// IdempotencySettings define la ventana durante la cual se reutiliza la primera respuesta
+type IdempotencySettings struct {
+	TTL time.Duration
+	// MaxWait limita la espera de una repetición mientras la original sigue en curso,
+	// aunque el contexto no tenga deadline (0 usa defaultIdempotencyMaxWait)
+	MaxWait time.Duration
+}
+
+// purchaseOrderPayload son los campos de generatePurchaseOrder que identifican una solicitud
+type purchaseOrderPayload struct {
+	RackIDReference int
+	GroupID         int
+	CouponCode      *string
+	UserEmail       string
+	UserPhone       string
+	GatewayName     string
+}
+
+// bookingPayload son los campos de generateBooking que identifican una solicitud
+type bookingPayload struct {
+	RackIDReference int
+	GroupID         int
+	CouponCode      *string
+	UserEmail       string
+	UserPhone       string
+}
+
+// idempotencyFingerprint identifica la operación y el payload de una solicitud
+func idempotencyFingerprint(operation string, payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize idempotency payload: %w", err)
+	}
+	hash := sha256.Sum256(append([]byte(operation+":"), data...))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// executeIdempotent ejecuta execute una sola vez por clave dentro de la ventana configurada.
+// Las repeticiones con el mismo payload reciben la primera respuesta; con otro payload, un conflicto.
+// Si la solicitud original sigue en curso, se espera su resultado hasta que el contexto expire
+// o pase settings.MaxWait.
+func executeIdempotent[T any](ctx context.Context, store ports.IdempotencyStore, settings IdempotencySettings, logger *slog.Logger, key string, operation string, payload interface{}, execute func(ctx context.Context) (*T, error)) (*T, error) {
+	key = strings.TrimSpace(key)
+	if store == nil || key == "" {
+		return execute(ctx)
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return nil, fmt.Errorf("%w: must be at most %d characters", appException.ErrInvalidIdempotencyKey, maxIdempotencyKeyLength)
+	}
+
+	fingerprint, err := idempotencyFingerprint(operation, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	maxWait := settings.MaxWait
+	if maxWait <= 0 {
+		maxWait = defaultIdempotencyMaxWait
+	}
+	waitTimer := time.NewTimer(maxWait)
+	defer waitTimer.Stop()
+
+	for {
+		record, reserved, err := store.Reserve(ctx, key, fingerprint, settings.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+		}
+
+		if reserved {
+			result, execErr := execute(ctx)
+			if execErr != nil {
+				// Liberar la clave para que el cliente pueda reintentar con la misma clave
+				if releaseErr := store.Release(context.WithoutCancel(ctx), key); releaseErr != nil {
+					return nil, fmt.Errorf("%w (failed to release idempotency key: %v)", execErr, releaseErr)
+				}
+				return nil, execErr
+			}
+
+			// La operación ya se ejecutó: si no se puede guardar la respuesta se devuelve igual el resultado,
+			// para no ocultar al cliente una orden creada
+			completeIdempotent(ctx, store, settings, logger, key, operation, result)
+			return result, nil
+		}
+
+		if record.Fingerprint != fingerprint {
+			return nil, appException.ErrIdempotencyKeyConflict
+		}
+
+		if record.Completed {
+			if len(record.Response) == 0 {
+				return nil, appException.ErrIdempotentResponseUnavailable
+			}
+			var result T
+			if err := json.Unmarshal(record.Response, &result); err != nil {
+				return nil, fmt.Errorf("failed to deserialize idempotent response: %w", err)
+			}
+			return &result, nil
+		}
+
+		// La solicitud original sigue en curso: esperar su resultado
+		select {
+		case <-ctx.Done():
+			return nil, appException.ErrIdempotencyKeyInProgress
+		case <-waitTimer.C:
+			return nil, appException.ErrIdempotencyKeyInProgress
+		case <-time.After(idempotencyPollInterval):
+		}
+	}
+}
+
+// completeIdempotent guarda la respuesta de una ejecución exitosa. Si no se puede serializar, la clave
+// queda completada sin respuesta para que las repeticiones no dupliquen la operación; si el store falla,
+// la clave se libera para no dejar a las repeticiones esperando hasta que venza la reserva.
+func completeIdempotent(ctx context.Context, store ports.IdempotencyStore, settings IdempotencySettings, logger *slog.Logger, key string, operation string, result interface{}) {
+	ctx = context.WithoutCancel(ctx)
+
+	response, err := json.Marshal(result)
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to serialize idempotent response, repeated requests will be rejected", "operation", operation, "error", err)
+	}
+
+	if err := store.Complete(ctx, key, response, settings.TTL); err != nil {
+		logger.ErrorContext(ctx, "failed to store idempotent response, releasing key", "operation", operation, "error", err)
+		if err := store.Release(ctx, key); err != nil {
+			logger.ErrorContext(ctx, "failed to release idempotency key", "operation", operation, "error", err)
+		}
+	}
+}
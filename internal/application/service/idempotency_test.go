@@ -0,0 +1,245 @@
+package service
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"bff-graphql-payment/internal/application/ports"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// fakeIdempotencyStore guarda las claves en un mapa sin expiración; completeErr simula un store caído
+type fakeIdempotencyStore struct {
+	mu          sync.Mutex
+	records     map[string]*ports.IdempotencyRecord
+	completeErr error
+	released    int
+}
+
+func newFakeIdempotencyStore() *fakeIdempotencyStore {
+	return &fakeIdempotencyStore{records: map[string]*ports.IdempotencyRecord{}}
+}
+
+func (s *fakeIdempotencyStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*ports.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok {
+		copied := *record
+		return &copied, false, nil
+	}
+	s.records[key] = &ports.IdempotencyRecord{Fingerprint: fingerprint}
+	return nil, true, nil
+}
+
+func (s *fakeIdempotencyStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.completeErr != nil {
+		return s.completeErr
+	}
+	s.records[key].Completed = true
+	s.records[key].Response = response
+	return nil
+}
+
+func (s *fakeIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.released++
+	if record, ok := s.records[key]; ok && !record.Completed {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+type testOrder struct {
+	ID string
+}
+
+var testIdempotencySettings = IdempotencySettings{TTL: time.Minute}
+
+// runOrder ejecuta una orden idempotente que cuenta las ejecuciones reales
+func runOrder(store ports.IdempotencyStore, key string, payload interface{}, calls *atomic.Int32) (*testOrder, error) {
+	return executeIdempotent(context.Background(), store, testIdempotencySettings, discardLogger, key, "generatePurchaseOrder", payload, func(ctx context.Context) (*testOrder, error) {
+		n := calls.Add(1)
+		return &testOrder{ID: string(rune('A' + n - 1))}, nil
+	})
+}
+
+func TestIdempotentReplay(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	var calls atomic.Int32
+
+	first, err := runOrder(store, "key-1", purchaseOrderPayload{GroupID: 1}, &calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := runOrder(store, "key-1", purchaseOrderPayload{GroupID: 1}, &calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 execution, got %d", calls.Load())
+	}
+	if first.ID != second.ID {
+		t.Errorf("expected the first response to be replayed, got %q and %q", first.ID, second.ID)
+	}
+
+	// Otra clave ejecuta de nuevo
+	if _, err := runOrder(store, "key-2", purchaseOrderPayload{GroupID: 1}, &calls); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected 2 executions, got %d", calls.Load())
+	}
+}
+
+func TestIdempotentConflict(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	var calls atomic.Int32
+
+	if _, err := runOrder(store, "key-1", purchaseOrderPayload{GroupID: 1}, &calls); err != nil {
+		t.Fatal(err)
+	}
+	_, err := runOrder(store, "key-1", purchaseOrderPayload{GroupID: 2}, &calls)
+	if !errors.Is(err, appException.ErrIdempotencyKeyConflict) {
+		t.Errorf("expected ErrIdempotencyKeyConflict, got %v", err)
+	}
+
+	// La misma clave con otra operación también es un conflicto
+	_, err = executeIdempotent(context.Background(), store, testIdempotencySettings, discardLogger, "key-1", "generateBooking", purchaseOrderPayload{GroupID: 1}, func(ctx context.Context) (*testOrder, error) {
+		return &testOrder{}, nil
+	})
+	if !errors.Is(err, appException.ErrIdempotencyKeyConflict) {
+		t.Errorf("expected ErrIdempotencyKeyConflict for another operation, got %v", err)
+	}
+}
+
+func TestIdempotentFailureReleasesKey(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	upstreamErr := errors.New("upstream failed")
+
+	_, err := executeIdempotent(context.Background(), store, testIdempotencySettings, discardLogger, "key-1", "generatePurchaseOrder", purchaseOrderPayload{}, func(ctx context.Context) (*testOrder, error) {
+		return nil, upstreamErr
+	})
+	if !errors.Is(err, upstreamErr) {
+		t.Fatalf("expected the upstream error, got %v", err)
+	}
+
+	var calls atomic.Int32
+	if _, err := runOrder(store, "key-1", purchaseOrderPayload{}, &calls); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("expected the retry to execute, got %d executions", calls.Load())
+	}
+}
+
+func TestIdempotentWaitsForInFlightRequest(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	done := make(chan *testOrder)
+	go func() {
+		order, _ := executeIdempotent(context.Background(), store, testIdempotencySettings, discardLogger, "key-1", "generatePurchaseOrder", purchaseOrderPayload{}, func(ctx context.Context) (*testOrder, error) {
+			close(started)
+			<-release
+			return &testOrder{ID: "original"}, nil
+		})
+		done <- order
+	}()
+	<-started
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+
+	var calls atomic.Int32
+	replayed, err := runOrder(store, "key-1", purchaseOrderPayload{}, &calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 0 || replayed.ID != "original" {
+		t.Errorf("expected the original response without executing again, got %+v (%d executions)", replayed, calls.Load())
+	}
+	<-done
+}
+
+func TestIdempotentWaitIsBounded(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	if _, reserved, _ := store.Reserve(context.Background(), "key-1", mustFingerprint(t, "generatePurchaseOrder", purchaseOrderPayload{}), time.Minute); !reserved {
+		t.Fatal("expected to reserve the key")
+	}
+
+	// El contexto no tiene deadline: la espera termina por MaxWait
+	settings := IdempotencySettings{TTL: time.Minute, MaxWait: 150 * time.Millisecond}
+	start := time.Now()
+	_, err := executeIdempotent(context.Background(), store, settings, discardLogger, "key-1", "generatePurchaseOrder", purchaseOrderPayload{}, func(ctx context.Context) (*testOrder, error) {
+		t.Error("the request must not execute while the original is in progress")
+		return nil, nil
+	})
+	if !errors.Is(err, appException.ErrIdempotencyKeyInProgress) {
+		t.Errorf("expected ErrIdempotencyKeyInProgress, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("wait was not bounded: %v", elapsed)
+	}
+}
+
+func TestIdempotentCompleteFailureReleasesKey(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	store.completeErr = errors.New("store unavailable")
+	var calls atomic.Int32
+
+	// El resultado se entrega aunque no se pueda guardar
+	order, err := runOrder(store, "key-1", purchaseOrderPayload{}, &calls)
+	if err != nil || order == nil {
+		t.Fatalf("expected the result despite the store failure, got %v, %v", order, err)
+	}
+	if store.released != 1 {
+		t.Errorf("expected the key to be released, got %d releases", store.released)
+	}
+}
+
+// unserializableOrder no se puede serializar a JSON
+type unserializableOrder struct {
+	Callback func()
+}
+
+func TestIdempotentUnserializableResponse(t *testing.T) {
+	store := newFakeIdempotencyStore()
+	execute := func(ctx context.Context) (*unserializableOrder, error) {
+		return &unserializableOrder{}, nil
+	}
+
+	if _, err := executeIdempotent(context.Background(), store, testIdempotencySettings, discardLogger, "key-1", "generateBooking", bookingPayload{}, execute); err != nil {
+		t.Fatal(err)
+	}
+
+	// La repetición no vuelve a ejecutar la operación
+	_, err := executeIdempotent(context.Background(), store, testIdempotencySettings, discardLogger, "key-1", "generateBooking", bookingPayload{}, func(ctx context.Context) (*unserializableOrder, error) {
+		t.Error("the operation must not execute twice")
+		return nil, nil
+	})
+	if !errors.Is(err, appException.ErrIdempotentResponseUnavailable) {
+		t.Errorf("expected ErrIdempotentResponseUnavailable, got %v", err)
+	}
+}
+
+func mustFingerprint(t *testing.T, operation string, payload interface{}) string {
+	t.Helper()
+	fingerprint, err := idempotencyFingerprint(operation, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fingerprint
+}
@@ -6,32 +6,54 @@ import (
 	domainException "bff-graphql-payment/internal/domain/exception"
 	"bff-graphql-payment/internal/domain/model"
 	"context"
+	"log/slog"
 	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// tracer crea los spans de los casos de uso
+var tracer = otel.Tracer("bff-graphql-payment/application/service")
+
+// incompleteOpenSendTimeout es cuánto se espera para entregar el ERROR sintetizado de un stream cancelado
+const incompleteOpenSendTimeout = 5 * time.Second
+
 // PaymentInfraService implementa los casos de uso de infraestructura de pagos
 type PaymentInfraService struct {
-	repo ports.PaymentInfraRepository
+	repo                ports.PaymentInfraRepository
+	idempotencyStore    ports.IdempotencyStore
+	idempotencySettings IdempotencySettings
+	logger              *slog.Logger
 }
 
 // NewPaymentInfraService crea un nuevo servicio de infraestructura de pagos
-func NewPaymentInfraService(repo ports.PaymentInfraRepository) *PaymentInfraService {
+func NewPaymentInfraService(repo ports.PaymentInfraRepository, idempotencyStore ports.IdempotencyStore, idempotencySettings IdempotencySettings, logger *slog.Logger) *PaymentInfraService {
 	return &PaymentInfraService{
-		repo: repo,
+		repo:                repo,
+		idempotencyStore:    idempotencyStore,
+		idempotencySettings: idempotencySettings,
+		logger:              logger.With("component", "payment-service"),
 	}
 }
 
 // GetPaymentInfraByQrValue obtiene la infraestructura de pagos por valor QR
 func (s *PaymentInfraService) GetPaymentInfraByQrValue(ctx context.Context, qrValue string) (*model.PaymentInfra, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.GetPaymentInfraByQrValue")
+	defer span.End()
+
 	// Validar entrada
 	if strings.TrimSpace(qrValue) == "" {
-		return nil, exception.ErrInvalidPaymentRackID
+		return nil, recordSpanError(span, exception.ErrInvalidPaymentRackID)
 	}
 
 	// Llamar al repositorio
 	paymentInfra, err := s.repo.GetPaymentInfraByQrValue(ctx, qrValue)
 	if err != nil {
-		return nil, err
+		return nil, recordSpanError(span, err)
 	}
 
 	return paymentInfra, nil
@@ -39,23 +61,26 @@ func (s *PaymentInfraService) GetPaymentInfraByQrValue(ctx context.Context, qrVa
 
 // GetAvailableLockers obtiene los lockers disponibles por ID de rack y tiempo de reserva
 func (s *PaymentInfraService) GetAvailableLockers(ctx context.Context, paymentRackID int, bookingTimeID int, traceID string) (*model.AvailableLockers, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.GetAvailableLockers")
+	defer span.End()
+
 	// Validar entrada
 	if paymentRackID <= 0 {
-		return nil, exception.ErrInvalidPaymentRackID
+		return nil, recordSpanError(span, exception.ErrInvalidPaymentRackID)
 	}
 
 	if bookingTimeID <= 0 {
-		return nil, exception.ErrInvalidBookingTimeID
+		return nil, recordSpanError(span, exception.ErrInvalidBookingTimeID)
 	}
 
 	if strings.TrimSpace(traceID) == "" {
-		return nil, exception.ErrInvalidTraceID
+		return nil, recordSpanError(span, exception.ErrInvalidTraceID)
 	}
 
 	// Llamar al repositorio
 	lockers, err := s.repo.GetAvailableLockers(ctx, paymentRackID, bookingTimeID, traceID)
 	if err != nil {
-		return nil, err
+		return nil, recordSpanError(span, err)
 	}
 
 	return lockers, nil
@@ -63,78 +88,111 @@ func (s *PaymentInfraService) GetAvailableLockers(ctx context.Context, paymentRa
 
 // ValidateDiscountCoupon valida un cupón de descuento
 func (s *PaymentInfraService) ValidateDiscountCoupon(ctx context.Context, couponCode string, rackID int, traceID string) (*model.DiscountCouponValidation, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.ValidateDiscountCoupon")
+	defer span.End()
+
 	// Validar entrada
 	if strings.TrimSpace(couponCode) == "" {
-		return nil, exception.ErrInvalidCouponCode
+		return nil, recordSpanError(span, exception.ErrInvalidCouponCode)
 	}
 
 	if rackID <= 0 {
-		return nil, exception.ErrInvalidPaymentRackID
+		return nil, recordSpanError(span, exception.ErrInvalidPaymentRackID)
 	}
 
 	if strings.TrimSpace(traceID) == "" {
-		return nil, exception.ErrInvalidTraceID
+		return nil, recordSpanError(span, exception.ErrInvalidTraceID)
 	}
 
 	// Llamar al repositorio
-	return s.repo.ValidateDiscountCoupon(ctx, couponCode, rackID, traceID)
+	validation, err := s.repo.ValidateDiscountCoupon(ctx, couponCode, rackID, traceID)
+	if err != nil {
+		return nil, recordSpanError(span, err)
+	}
+
+	return validation, nil
 }
 
 // GeneratePurchaseOrder genera una orden de compra
-func (s *PaymentInfraService) GeneratePurchaseOrder(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string, gatewayName string) (*model.PurchaseOrder, error) {
+func (s *PaymentInfraService) GeneratePurchaseOrder(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string, gatewayName string, idempotencyKey string) (*model.PurchaseOrder, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.GeneratePurchaseOrder")
+	defer span.End()
+
 	// Validar entrada
 	if rackIdReference <= 0 {
-		return nil, domainException.ErrInvalidPaymentRackID
+		return nil, recordSpanError(span, domainException.ErrInvalidPaymentRackID)
 	}
 
 	if groupID <= 0 {
-		return nil, exception.ErrInvalidGroupID
+		return nil, recordSpanError(span, exception.ErrInvalidGroupID)
 	}
 
 	if strings.TrimSpace(userEmail) == "" {
-		return nil, exception.ErrInvalidEmail
+		return nil, recordSpanError(span, exception.ErrInvalidEmail)
 	}
 
 	if strings.TrimSpace(userPhone) == "" {
-		return nil, exception.ErrInvalidPhone
+		return nil, recordSpanError(span, exception.ErrInvalidPhone)
 	}
 
 	if strings.TrimSpace(traceID) == "" {
-		return nil, exception.ErrInvalidTraceID
+		return nil, recordSpanError(span, exception.ErrInvalidTraceID)
 	}
 
 	if strings.TrimSpace(gatewayName) == "" {
-		return nil, exception.ErrInvalidGatewayName
+		return nil, recordSpanError(span, exception.ErrInvalidGatewayName)
 	}
 
-	// Llamar al repositorio
-	order, err := s.repo.GeneratePurchaseOrder(ctx, rackIdReference, groupID, couponCode, userEmail, userPhone, traceID, gatewayName)
+	// Llamar al repositorio una sola vez por clave de idempotencia (el traceId no forma parte del payload)
+	payload := purchaseOrderPayload{
+		RackIDReference: rackIdReference,
+		GroupID:         groupID,
+		CouponCode:      couponCode,
+		UserEmail:       userEmail,
+		UserPhone:       userPhone,
+		GatewayName:     gatewayName,
+	}
+	order, err := executeIdempotent(ctx, s.idempotencyStore, s.idempotencySettings, s.logger, idempotencyKey, "generatePurchaseOrder", payload, func(ctx context.Context) (*model.PurchaseOrder, error) {
+		return s.repo.GeneratePurchaseOrder(ctx, rackIdReference, groupID, couponCode, userEmail, userPhone, traceID, gatewayName)
+	})
 	if err != nil {
-		return nil, err
+		return nil, recordSpanError(span, err)
 	}
 
 	return order, nil
 }
 
 // GenerateBooking genera una reserva de locker
-func (s *PaymentInfraService) GenerateBooking(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string) (*model.Booking, error) {
+func (s *PaymentInfraService) GenerateBooking(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string, idempotencyKey string) (*model.Booking, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.GenerateBooking")
+	defer span.End()
+
 	// Validar entrada
 	if rackIdReference <= 0 {
-		return nil, domainException.ErrInvalidPaymentRackID
+		return nil, recordSpanError(span, domainException.ErrInvalidPaymentRackID)
 	}
 
 	if groupID <= 0 {
-		return nil, exception.ErrInvalidGroupID
+		return nil, recordSpanError(span, exception.ErrInvalidGroupID)
 	}
 
 	if strings.TrimSpace(traceID) == "" {
-		return nil, exception.ErrInvalidTraceID
+		return nil, recordSpanError(span, exception.ErrInvalidTraceID)
 	}
 
-	// Llamar al repositorio
-	booking, err := s.repo.GenerateBooking(ctx, rackIdReference, groupID, couponCode, userEmail, userPhone, traceID)
+	// Llamar al repositorio una sola vez por clave de idempotencia (el traceId no forma parte del payload)
+	payload := bookingPayload{
+		RackIDReference: rackIdReference,
+		GroupID:         groupID,
+		CouponCode:      couponCode,
+		UserEmail:       userEmail,
+		UserPhone:       userPhone,
+	}
+	booking, err := executeIdempotent(ctx, s.idempotencyStore, s.idempotencySettings, s.logger, idempotencyKey, "generateBooking", payload, func(ctx context.Context) (*model.Booking, error) {
+		return s.repo.GenerateBooking(ctx, rackIdReference, groupID, couponCode, userEmail, userPhone, traceID)
+	})
 	if err != nil {
-		return nil, err
+		return nil, recordSpanError(span, err)
 	}
 
 	return booking, nil
@@ -142,19 +200,22 @@ func (s *PaymentInfraService) GenerateBooking(ctx context.Context, rackIdReferen
 
 // GetPurchaseOrderByPo obtiene una orden de compra por su PO
 func (s *PaymentInfraService) GetPurchaseOrderByPo(ctx context.Context, purchaseOrder string, traceID string) (*model.PurchaseOrderData, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.GetPurchaseOrderByPo")
+	defer span.End()
+
 	// Validar entrada
 	if strings.TrimSpace(purchaseOrder) == "" {
-		return nil, exception.ErrInvalidPurchaseOrder
+		return nil, recordSpanError(span, exception.ErrInvalidPurchaseOrder)
 	}
 
 	if strings.TrimSpace(traceID) == "" {
-		return nil, exception.ErrInvalidTraceID
+		return nil, recordSpanError(span, exception.ErrInvalidTraceID)
 	}
 
 	// Llamar al repositorio
 	orderData, err := s.repo.GetPurchaseOrderByPo(ctx, purchaseOrder, traceID)
 	if err != nil {
-		return nil, err
+		return nil, recordSpanError(span, err)
 	}
 
 	return orderData, nil
@@ -162,40 +223,99 @@ func (s *PaymentInfraService) GetPurchaseOrderByPo(ctx context.Context, purchase
 
 // CheckBookingStatus verifica el estado de una reserva
 func (s *PaymentInfraService) CheckBookingStatus(ctx context.Context, serviceName string, currentCode string) (*model.BookingStatusCheck, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.CheckBookingStatus")
+	defer span.End()
+
 	// Validar entrada
 	if strings.TrimSpace(serviceName) == "" {
-		return nil, exception.ErrInvalidServiceName
+		return nil, recordSpanError(span, exception.ErrInvalidServiceName)
 	}
 
 	if strings.TrimSpace(currentCode) == "" {
-		return nil, exception.ErrInvalidCurrentCode
+		return nil, recordSpanError(span, exception.ErrInvalidCurrentCode)
 	}
 
 	// Llamar al repositorio
 	bookingStatus, err := s.repo.CheckBookingStatus(ctx, serviceName, currentCode)
 	if err != nil {
-		return nil, err
+		return nil, recordSpanError(span, err)
 	}
 
 	return bookingStatus, nil
 }
 
-// ExecuteOpenStream ejecuta la apertura de un locker con streaming de estados
+// ExecuteOpenStream ejecuta la apertura de un locker con streaming de estados.
+// Los estados del upstream pasan por la máquina de estados de la apertura: los que llegan fuera de orden,
+// repetidos, con un estado físico que no corresponde o después de SUCCESS/ERROR se descartan, y si el
+// stream termina o se cancela sin estado final se emite
+// un OPEN_STATUS_ERROR con PHYSICAL_STATUS_UNEXPECTED. El span dura lo mismo que el stream.
 func (s *PaymentInfraService) ExecuteOpenStream(ctx context.Context, serviceName string, currentCode string) (<-chan *model.ExecuteOpenResult, error) {
+	ctx, span := tracer.Start(ctx, "PaymentInfraService.ExecuteOpenStream")
+
 	// Validar entrada
 	if strings.TrimSpace(serviceName) == "" {
-		return nil, exception.ErrInvalidServiceName
+		defer span.End()
+		return nil, recordSpanError(span, exception.ErrInvalidServiceName)
 	}
 
 	if strings.TrimSpace(currentCode) == "" {
-		return nil, exception.ErrInvalidCurrentCode
+		defer span.End()
+		return nil, recordSpanError(span, exception.ErrInvalidCurrentCode)
 	}
 
 	// Llamar al repositorio que retorna un canal
 	resultChan, err := s.repo.ExecuteOpenStream(ctx, serviceName, currentCode)
 	if err != nil {
-		return nil, err
+		defer span.End()
+		return nil, recordSpanError(span, err)
 	}
 
-	return resultChan, nil
+	validated := make(chan *model.ExecuteOpenResult, cap(resultChan))
+	go func() {
+		defer span.End()
+		defer close(validated)
+
+		stateMachine := model.NewOpenStateMachine()
+		send := func(ctx context.Context, result *model.ExecuteOpenResult) bool {
+			select {
+			case validated <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for result := range resultChan {
+			if err := stateMachine.Apply(result); err != nil {
+				span.AddEvent("open status dropped", trace.WithAttributes(attribute.String("error", err.Error())))
+				continue
+			}
+			if !send(ctx, result) {
+				// Drenar el canal original para no bloquear al productor
+				for range resultChan {
+				}
+				break
+			}
+		}
+
+		if !stateMachine.Terminated() {
+			incomplete := stateMachine.IncompleteResult()
+			span.SetStatus(codes.Error, "open stream ended without terminal status")
+			span.AddEvent("open stream incomplete", trace.WithAttributes(attribute.String("lastStatus", string(stateMachine.Current()))))
+			// Si el stream se canceló el estado final igual se emite, con un plazo propio para no
+			// quedar bloqueado si ya nadie lee el canal
+			sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), incompleteOpenSendTimeout)
+			defer cancel()
+			send(sendCtx, incomplete)
+		}
+	}()
+
+	return validated, nil
+}
+
+// recordSpanError registra el error en el span y lo devuelve sin modificar
+func recordSpanError(span trace.Span, err error) error {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+	return err
 }
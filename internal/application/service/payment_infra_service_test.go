@@ -0,0 +1,54 @@
+package service
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"bff-graphql-payment/internal/domain/model"
+	"context"
+	"testing"
+	"time"
+)
+
+// openStreamRepository responde ExecuteOpenStream con un canal que el test controla
+type openStreamRepository struct {
+	ports.PaymentInfraRepository
+	results chan *model.ExecuteOpenResult
+}
+
+func (r *openStreamRepository) ExecuteOpenStream(ctx context.Context, serviceName string, currentCode string) (<-chan *model.ExecuteOpenResult, error) {
+	return r.results, nil
+}
+
+func TestExecuteOpenStreamCancelledEmitsIncompleteResult(t *testing.T) {
+	repo := &openStreamRepository{results: make(chan *model.ExecuteOpenResult, 1)}
+	service := NewPaymentInfraService(repo, newFakeIdempotencyStore(), testIdempotencySettings, discardLogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := service.ExecuteOpenStream(ctx, "booking", "123456")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo.results <- &model.ExecuteOpenResult{TransactionID: "tx-1", OpenStatus: model.OpenStatusReceived, PhysicalStatus: model.PhysicalStatusWaiting}
+	if result := <-stream; result.OpenStatus != model.OpenStatusReceived {
+		t.Fatalf("expected RECEIVED, got %s", result.OpenStatus)
+	}
+
+	// El stream se cancela antes del estado final: el productor cierra su canal
+	cancel()
+	close(repo.results)
+
+	select {
+	case result, ok := <-stream:
+		if !ok {
+			t.Fatal("stream closed without a terminal status")
+		}
+		if result.OpenStatus != model.OpenStatusError || result.PhysicalStatus != model.PhysicalStatusUnexpected || result.TransactionID != "tx-1" {
+			t.Errorf("expected ERROR with UNEXPECTED for tx-1, got %+v", result)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the terminal status")
+	}
+	if _, ok := <-stream; ok {
+		t.Error("expected the stream to close after the terminal status")
+	}
+}
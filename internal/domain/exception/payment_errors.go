@@ -12,6 +12,9 @@ var (
 	// ErrPaymentInfraServiceUnavailable se devuelve cuando el servicio de infraestructura de pagos no está disponible
 	ErrPaymentInfraServiceUnavailable = errors.New("payment infrastructure service unavailable")
 
+	// ErrPaymentInfraServiceTimeout se devuelve cuando el servicio de infraestructura de pagos no responde a tiempo
+	ErrPaymentInfraServiceTimeout = errors.New("payment infrastructure service timed out")
+
 	// ErrInvalidBookingTimeID se devuelve cuando el ID del tiempo de reserva es inválido
 	ErrInvalidBookingTimeID = errors.New("invalid booking time ID")
 
@@ -65,4 +68,13 @@ var (
 
 	// ErrExecuteOpenFailed se devuelve cuando falla la ejecución de apertura
 	ErrExecuteOpenFailed = errors.New("execute open failed")
+
+	// ErrInvalidOpenTransition se devuelve cuando un estado de apertura llega fuera de orden, repetido o es desconocido
+	ErrInvalidOpenTransition = errors.New("invalid open status transition")
+
+	// ErrInvalidPhysicalStatus se devuelve cuando el estado físico no corresponde al estado de apertura
+	ErrInvalidPhysicalStatus = errors.New("physical status does not match open status")
+
+	// ErrOpenStatusAfterTerminal se devuelve cuando llega un estado de apertura después de SUCCESS o ERROR
+	ErrOpenStatusAfterTerminal = errors.New("open status received after terminal status")
 )
@@ -0,0 +1,48 @@
+package exception
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldViolation describe un campo inválido reportado por un servicio externo
+type FieldViolation struct {
+	Field       string
+	Description string
+}
+
+// UpstreamError envuelve un error de dominio conservando el detalle original del servicio externo
+type UpstreamError struct {
+	// Operation es la operación que falló (p. ej. "ValidateDiscountCoupon")
+	Operation string
+	// Code es el código de estado original del servicio externo (p. ej. "NOT_FOUND")
+	Code string
+	// Message es el mensaje original devuelto por el servicio externo. Queda vacío en las fallas
+	// propias del servicio (Unavailable, Internal, etc.), cuyo texto puede exponer detalles internos.
+	Message string
+	// FieldViolations contiene los campos inválidos reportados, si existen
+	FieldViolations []FieldViolation
+	// Details contiene el resto de detalles del estado en forma legible
+	Details []string
+	// Err es el error de dominio al que se tradujo la falla
+	Err error
+}
+
+// Error implementa la interfaz error
+func (e *UpstreamError) Error() string {
+	var sb strings.Builder
+	sb.WriteString(e.Err.Error())
+	if e.Message != "" {
+		sb.WriteString(": ")
+		sb.WriteString(e.Message)
+	}
+	for _, violation := range e.FieldViolations {
+		sb.WriteString(fmt.Sprintf(" [%s: %s]", violation.Field, violation.Description))
+	}
+	return sb.String()
+}
+
+// Unwrap permite usar errors.Is/errors.As contra el error de dominio
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,98 @@
+package model
+
+import (
+	"bff-graphql-payment/internal/domain/exception"
+	"fmt"
+	"slices"
+)
+
+// IncompleteOpenMessage es el mensaje del error sintetizado cuando el stream termina sin estado final
+const IncompleteOpenMessage = "La apertura terminó sin confirmación del dispositivo"
+
+// openStatusPredecessor es el único estado desde el que se puede llegar a cada estado.
+// ERROR no figura: es válido desde cualquier estado no terminal.
+var openStatusPredecessor = map[OpenStatus]OpenStatus{
+	OpenStatusReceived:  OpenStatusUnspecified,
+	OpenStatusRequested: OpenStatusReceived,
+	OpenStatusExecuted:  OpenStatusRequested,
+	OpenStatusSuccess:   OpenStatusExecuted,
+}
+
+// openPhysicalStatuses son los estados físicos válidos para cada estado de apertura
+var openPhysicalStatuses = map[OpenStatus][]PhysicalStatus{
+	OpenStatusReceived:  {PhysicalStatusUnspecified, PhysicalStatusWaiting},
+	OpenStatusRequested: {PhysicalStatusUnspecified, PhysicalStatusWaiting},
+	OpenStatusExecuted:  {PhysicalStatusUnspecified, PhysicalStatusWaiting},
+	OpenStatusSuccess:   {PhysicalStatusSuccess, PhysicalStatusAlreadyOpen},
+	OpenStatusError:     {PhysicalStatusFailed, PhysicalStatusUnexpected},
+}
+
+// IsTerminal indica si el estado cierra la apertura (SUCCESS o ERROR)
+func (s OpenStatus) IsTerminal() bool {
+	return s == OpenStatusSuccess || s == OpenStatusError
+}
+
+// OpenStateMachine valida la secuencia de estados de una apertura:
+// RECEIVED → REQUESTED → EXECUTED → SUCCESS | ERROR.
+// Cada estado exige el anterior exacto (no se omiten ni se repiten pasos); ERROR es válido desde
+// cualquier estado no terminal. El estado físico debe corresponder al estado de apertura: WAITING
+// mientras la apertura avanza, SUCCESS o ALREADY_OPEN con SUCCESS y FAILED o UNEXPECTED con ERROR.
+type OpenStateMachine struct {
+	current       OpenStatus
+	transactionID string
+}
+
+// NewOpenStateMachine crea la máquina de estados de una apertura que aún no recibió estados
+func NewOpenStateMachine() *OpenStateMachine {
+	return &OpenStateMachine{current: OpenStatusUnspecified}
+}
+
+// Current devuelve el último estado aceptado
+func (m *OpenStateMachine) Current() OpenStatus {
+	return m.current
+}
+
+// Terminated indica si la apertura ya llegó a SUCCESS o ERROR
+func (m *OpenStateMachine) Terminated() bool {
+	return m.current.IsTerminal()
+}
+
+// Apply valida el siguiente resultado del stream y, si es válido, avanza la máquina.
+// Devuelve ErrOpenStatusAfterTerminal si la apertura ya terminó, ErrInvalidOpenTransition
+// si el estado es desconocido o no sigue al actual y ErrInvalidPhysicalStatus si el estado físico
+// no corresponde.
+func (m *OpenStateMachine) Apply(result *ExecuteOpenResult) error {
+	if result == nil {
+		return fmt.Errorf("%w: empty result after %s", exception.ErrInvalidOpenTransition, m.current)
+	}
+	if m.Terminated() {
+		return fmt.Errorf("%w: %s after %s", exception.ErrOpenStatusAfterTerminal, result.OpenStatus, m.current)
+	}
+
+	if result.OpenStatus != OpenStatusError {
+		predecessor, known := openStatusPredecessor[result.OpenStatus]
+		if !known || predecessor != m.current {
+			return fmt.Errorf("%w: %s -> %s", exception.ErrInvalidOpenTransition, m.current, result.OpenStatus)
+		}
+	}
+	if !slices.Contains(openPhysicalStatuses[result.OpenStatus], result.PhysicalStatus) {
+		return fmt.Errorf("%w: %s with %s", exception.ErrInvalidPhysicalStatus, result.OpenStatus, result.PhysicalStatus)
+	}
+
+	m.current = result.OpenStatus
+	if result.TransactionID != "" {
+		m.transactionID = result.TransactionID
+	}
+	return nil
+}
+
+// IncompleteResult devuelve el ERROR que se emite cuando el stream termina sin SUCCESS ni ERROR.
+// El estado físico es UNEXPECTED: no se sabe si la puerta se abrió.
+func (m *OpenStateMachine) IncompleteResult() *ExecuteOpenResult {
+	return &ExecuteOpenResult{
+		TransactionID:  m.transactionID,
+		Message:        IncompleteOpenMessage,
+		OpenStatus:     OpenStatusError,
+		PhysicalStatus: PhysicalStatusUnexpected,
+	}
+}
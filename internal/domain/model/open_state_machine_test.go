@@ -0,0 +1,109 @@
+package model
+
+import (
+	"bff-graphql-payment/internal/domain/exception"
+	"errors"
+	"testing"
+)
+
+// step arma un resultado del stream con el estado físico indicado
+func step(openStatus OpenStatus, physicalStatus PhysicalStatus) *ExecuteOpenResult {
+	return &ExecuteOpenResult{OpenStatus: openStatus, PhysicalStatus: physicalStatus}
+}
+
+var (
+	received  = step(OpenStatusReceived, PhysicalStatusWaiting)
+	requested = step(OpenStatusRequested, PhysicalStatusWaiting)
+	executed  = step(OpenStatusExecuted, PhysicalStatusWaiting)
+	succeeded = step(OpenStatusSuccess, PhysicalStatusSuccess)
+	failed    = step(OpenStatusError, PhysicalStatusFailed)
+)
+
+func TestOpenStateMachineApply(t *testing.T) {
+	tests := []struct {
+		name    string
+		applied []*ExecuteOpenResult
+		next    *ExecuteOpenResult
+		wantErr error
+	}{
+		{name: "received first", next: received},
+		{name: "requested after received", applied: []*ExecuteOpenResult{received}, next: requested},
+		{name: "executed after requested", applied: []*ExecuteOpenResult{received, requested}, next: executed},
+		{name: "success after executed", applied: []*ExecuteOpenResult{received, requested, executed}, next: succeeded},
+		{name: "already open after executed", applied: []*ExecuteOpenResult{received, requested, executed}, next: step(OpenStatusSuccess, PhysicalStatusAlreadyOpen)},
+		{name: "error after executed", applied: []*ExecuteOpenResult{received, requested, executed}, next: failed},
+		{name: "error after received", applied: []*ExecuteOpenResult{received}, next: failed},
+		{name: "error before received", next: step(OpenStatusError, PhysicalStatusUnexpected)},
+		{name: "unspecified physical status while waiting", next: step(OpenStatusReceived, PhysicalStatusUnspecified)},
+
+		{name: "requested before received", next: requested, wantErr: exception.ErrInvalidOpenTransition},
+		{name: "executed skips requested", applied: []*ExecuteOpenResult{received}, next: executed, wantErr: exception.ErrInvalidOpenTransition},
+		{name: "success skips executed", applied: []*ExecuteOpenResult{received, requested}, next: succeeded, wantErr: exception.ErrInvalidOpenTransition},
+		{name: "success right after received", applied: []*ExecuteOpenResult{received}, next: succeeded, wantErr: exception.ErrInvalidOpenTransition},
+		{name: "repeated status", applied: []*ExecuteOpenResult{received, requested}, next: requested, wantErr: exception.ErrInvalidOpenTransition},
+		{name: "going back", applied: []*ExecuteOpenResult{received, requested}, next: received, wantErr: exception.ErrInvalidOpenTransition},
+		{name: "unknown status", applied: []*ExecuteOpenResult{received}, next: step(OpenStatusUnspecified, PhysicalStatusWaiting), wantErr: exception.ErrInvalidOpenTransition},
+		{name: "empty result", next: nil, wantErr: exception.ErrInvalidOpenTransition},
+
+		{name: "success with failed door", applied: []*ExecuteOpenResult{received, requested, executed}, next: step(OpenStatusSuccess, PhysicalStatusFailed), wantErr: exception.ErrInvalidPhysicalStatus},
+		{name: "success while waiting", applied: []*ExecuteOpenResult{received, requested, executed}, next: step(OpenStatusSuccess, PhysicalStatusWaiting), wantErr: exception.ErrInvalidPhysicalStatus},
+		{name: "error with open door", applied: []*ExecuteOpenResult{received, requested, executed}, next: step(OpenStatusError, PhysicalStatusSuccess), wantErr: exception.ErrInvalidPhysicalStatus},
+		{name: "error while waiting", applied: []*ExecuteOpenResult{received}, next: step(OpenStatusError, PhysicalStatusWaiting), wantErr: exception.ErrInvalidPhysicalStatus},
+		{name: "executed with final physical status", applied: []*ExecuteOpenResult{received, requested}, next: step(OpenStatusExecuted, PhysicalStatusSuccess), wantErr: exception.ErrInvalidPhysicalStatus},
+
+		{name: "status after success", applied: []*ExecuteOpenResult{received, requested, executed, succeeded}, next: failed, wantErr: exception.ErrOpenStatusAfterTerminal},
+		{name: "status after error", applied: []*ExecuteOpenResult{received, failed}, next: requested, wantErr: exception.ErrOpenStatusAfterTerminal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewOpenStateMachine()
+			for _, result := range tt.applied {
+				if err := m.Apply(result); err != nil {
+					t.Fatalf("applying %s: %v", result.OpenStatus, err)
+				}
+			}
+			before := m.Current()
+
+			err := m.Apply(tt.next)
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Fatalf("expected %s to be accepted, got %v", tt.next.OpenStatus, err)
+				}
+				if m.Current() != tt.next.OpenStatus {
+					t.Errorf("expected current %s, got %s", tt.next.OpenStatus, m.Current())
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if m.Current() != before {
+				t.Errorf("a rejected result must not change the state: %s -> %s", before, m.Current())
+			}
+		})
+	}
+}
+
+func TestOpenStateMachineIncompleteResult(t *testing.T) {
+	m := NewOpenStateMachine()
+	for _, result := range []*ExecuteOpenResult{
+		{TransactionID: "tx-1", OpenStatus: OpenStatusReceived, PhysicalStatus: PhysicalStatusWaiting},
+		{OpenStatus: OpenStatusRequested, PhysicalStatus: PhysicalStatusWaiting},
+	} {
+		if err := m.Apply(result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if m.Terminated() {
+		t.Fatal("the open must not be terminated before SUCCESS or ERROR")
+	}
+
+	incomplete := m.IncompleteResult()
+	if incomplete.OpenStatus != OpenStatusError || incomplete.PhysicalStatus != PhysicalStatusUnexpected {
+		t.Errorf("expected ERROR with UNEXPECTED, got %s with %s", incomplete.OpenStatus, incomplete.PhysicalStatus)
+	}
+	if incomplete.TransactionID != "tx-1" {
+		t.Errorf("expected the last known transaction id, got %q", incomplete.TransactionID)
+	}
+}
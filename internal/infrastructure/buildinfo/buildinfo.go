@@ -0,0 +1,45 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Version es la versión del binario. Se define al compilar:
+//
+//	go build -ldflags "-X bff-graphql-payment/internal/infrastructure/buildinfo.Version=1.2.3"
+var Version = "dev"
+
+// startedAt es el instante en que arrancó el proceso
+var startedAt = time.Now()
+
+// Info describe el binario en ejecución
+type Info struct {
+	Version   string    `json:"version"`
+	Commit    string    `json:"commit,omitempty"`
+	GoVersion string    `json:"goVersion"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// Get devuelve la información del binario. El commit se obtiene de los datos VCS que embebe el compilador.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		StartedAt: startedAt,
+	}
+	if build, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range build.Settings {
+			if setting.Key == "vcs.revision" {
+				info.Commit = setting.Value
+			}
+		}
+	}
+	return info
+}
+
+// Uptime devuelve el tiempo transcurrido desde que arrancó el proceso
+func Uptime() time.Duration {
+	return time.Since(startedAt)
+}
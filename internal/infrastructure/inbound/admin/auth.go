@@ -0,0 +1,34 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireToken exige "Authorization: Bearer <token>" antes de llamar a next.
+// Sin token el endpoint queda abierto solo si allowOpen (desarrollo local); en el resto de los
+// ambientes que no exigen el token (producción lo exige al validar la configuración) responde 404.
+func RequireToken(token string, allowOpen bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" && !allowOpen {
+			http.NotFound(w, r)
+			return
+		}
+		if token != "" && !validToken(r.Header.Get("Authorization"), token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// validToken compara el token Bearer recibido en tiempo constante
+func validToken(authorization string, token string) bool {
+	received, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(received), []byte(token)) == 1
+}
@@ -0,0 +1,46 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequireToken(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name          string
+		token         string
+		allowOpen     bool
+		authorization string
+		want          int
+	}{
+		{name: "valid token", token: "s3cret", authorization: "Bearer s3cret", want: http.StatusNoContent},
+		{name: "missing header", token: "s3cret", want: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", authorization: "Bearer guess", want: http.StatusUnauthorized},
+		{name: "wrong scheme", token: "s3cret", authorization: "Basic s3cret", want: http.StatusUnauthorized},
+		{name: "token prefix", token: "s3cret", authorization: "Bearer s3c", want: http.StatusUnauthorized},
+		{name: "no token in local development", allowOpen: true, want: http.StatusNoContent},
+		{name: "no token outside local development", want: http.StatusNotFound},
+		{name: "token ignores allowOpen", token: "s3cret", allowOpen: true, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodPost, "/admin/cache/invalidate", nil)
+			if tt.authorization != "" {
+				request.Header.Set("Authorization", tt.authorization)
+			}
+			recorder := httptest.NewRecorder()
+
+			RequireToken(tt.token, tt.allowOpen, ok).ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, recorder.Code)
+			}
+		})
+	}
+}
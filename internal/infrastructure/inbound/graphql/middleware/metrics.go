@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Valores de la etiqueta operation que no son un campo raíz del schema
+const (
+	metricsOperationMultiple = "multiple"
+	metricsOperationOther    = "other"
+)
+
+// Metrics es una extensión de gqlgen que registra en Prometheus cada operación GraphQL,
+// su latencia y los códigos de error (extensions.code) devueltos.
+// La operación se etiqueta con el campo raíz del schema que ejecuta (no con el operationName que
+// elige el cliente) para que la cantidad de series quede acotada.
+type Metrics struct {
+	rootFields map[string]bool
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+} = (*Metrics)(nil)
+
+// NewMetrics crea la extensión de métricas
+func NewMetrics() *Metrics {
+	return &Metrics{rootFields: map[string]bool{}}
+}
+
+// ExtensionName implementa graphql.HandlerExtension
+func (m *Metrics) ExtensionName() string {
+	return "PrometheusMetrics"
+}
+
+// Validate implementa graphql.HandlerExtension; guarda los campos raíz de Query, Mutation y Subscription
+func (m *Metrics) Validate(schema graphql.ExecutableSchema) error {
+	definitions := schema.Schema()
+	for _, root := range []*ast.Definition{definitions.Query, definitions.Mutation, definitions.Subscription} {
+		if root == nil {
+			continue
+		}
+		for _, field := range root.Fields {
+			if !strings.HasPrefix(field.Name, "__") {
+				m.rootFields[field.Name] = true
+			}
+		}
+	}
+	return nil
+}
+
+// InterceptResponse mide cada respuesta (en subscriptions, cada evento emitido)
+func (m *Metrics) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	operationType := "unknown"
+	if opCtx.Operation != nil {
+		operationType = string(opCtx.Operation.Operation)
+	}
+
+	start := time.Now()
+	response := next(ctx)
+
+	// Las subscriptions terminan con una respuesta nil que no corresponde a un evento
+	if response == nil {
+		return response
+	}
+
+	errorCodes := make([]string, 0, len(response.Errors))
+	for _, err := range response.Errors {
+		code := "UNKNOWN"
+		if err.Extensions != nil {
+			if value, ok := err.Extensions["code"]; ok {
+				code = fmt.Sprintf("%v", value)
+			}
+		}
+		errorCodes = append(errorCodes, code)
+	}
+
+	telemetry.ObserveGraphQLOperation(m.operationLabel(opCtx), operationType, time.Since(start), errorCodes)
+	return response
+}
+
+// operationLabel devuelve el campo raíz que ejecuta la operación, "multiple" si ejecuta varios
+// campos del schema y "other" en cualquier otro caso (introspección, fragments o campos desconocidos)
+func (m *Metrics) operationLabel(opCtx *graphql.OperationContext) string {
+	if opCtx.Operation == nil {
+		return metricsOperationOther
+	}
+	label := ""
+	for _, selection := range opCtx.Operation.SelectionSet {
+		field, ok := selection.(*ast.Field)
+		if !ok || !m.rootFields[field.Name] {
+			return metricsOperationOther
+		}
+		if label != "" && label != field.Name {
+			return metricsOperationMultiple
+		}
+		label = field.Name
+	}
+	if label == "" {
+		return metricsOperationOther
+	}
+	return label
+}
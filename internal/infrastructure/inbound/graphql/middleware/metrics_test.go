@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bff-graphql-payment/graph/generated"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2"
+)
+
+func TestMetricsOperationLabel(t *testing.T) {
+	schema := generated.NewExecutableSchema(generated.Config{})
+	metrics := NewMetrics()
+	if err := metrics.Validate(schema); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{name: "root field", query: `query Cualquiera { validateDiscountCoupon(input: {couponCode: "A", rackId: 1, traceId: "t"}) { discountPercentage } }`, want: "validateDiscountCoupon"},
+		{name: "alias", query: `query { cupon: validateDiscountCoupon(input: {couponCode: "A", rackId: 1, traceId: "t"}) { discountPercentage } }`, want: "validateDiscountCoupon"},
+		{name: "mutation", query: `mutation Random123 { generateBooking(input: {rackIdReference: 1, groupId: 1, userEmail: "a@b.c", userPhone: "1", traceId: "t"}) { transactionId } }`, want: "generateBooking"},
+		{name: "several root fields", query: `query { a: validateDiscountCoupon(input: {couponCode: "A", rackId: 1, traceId: "t"}) { discountPercentage } b: getPaymentInfraByQrValue(input: {qrValue: "QR"}) { transactionId } }`, want: metricsOperationMultiple},
+		{name: "introspection", query: `query { __schema { queryType { name } } }`, want: metricsOperationOther},
+		{name: "root fragment", query: `query { ...F } fragment F on Query { __typename }`, want: metricsOperationOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document, errs := gqlparser.LoadQuery(schema.Schema(), tt.query)
+			if errs != nil {
+				t.Fatalf("invalid query: %v", errs)
+			}
+			opCtx := &graphql.OperationContext{Operation: document.Operations[0], OperationName: "attacker-chosen-name"}
+			if got := metrics.operationLabel(opCtx); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
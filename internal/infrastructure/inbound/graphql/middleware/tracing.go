@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/99designs/gqlgen/graphql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "bff-graphql-payment/graphql"
+
+// Tracing es una extensión de gqlgen que crea un span por operación GraphQL
+// y un span hijo por cada field resuelto por un resolver
+type Tracing struct {
+	tracer trace.Tracer
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.ResponseInterceptor
+	graphql.FieldInterceptor
+} = Tracing{}
+
+// NewTracing crea la extensión de tracing usando el TracerProvider global
+func NewTracing() Tracing {
+	return Tracing{tracer: otel.Tracer(tracerName)}
+}
+
+// ExtensionName implementa graphql.HandlerExtension
+func (t Tracing) ExtensionName() string {
+	return "OpenTelemetryTracing"
+}
+
+// Validate implementa graphql.HandlerExtension
+func (t Tracing) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+// InterceptResponse crea el span de la operación (en subscriptions, uno por evento emitido)
+func (t Tracing) InterceptResponse(ctx context.Context, next graphql.ResponseHandler) *graphql.Response {
+	if !graphql.HasOperationContext(ctx) {
+		return next(ctx)
+	}
+
+	opCtx := graphql.GetOperationContext(ctx)
+	operationType := "unknown"
+	if opCtx.Operation != nil {
+		operationType = string(opCtx.Operation.Operation)
+	}
+
+	ctx, span := t.tracer.Start(ctx, fmt.Sprintf("graphql.%s %s", operationType, operationName(opCtx)),
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("graphql.operation.type", operationType),
+			attribute.String("graphql.operation.name", operationName(opCtx)),
+		),
+	)
+	defer span.End()
+
+	response := next(ctx)
+
+	if errs := graphql.GetErrors(ctx); len(errs) > 0 {
+		span.SetStatus(codes.Error, errs.Error())
+	} else if response != nil && len(response.Errors) > 0 {
+		span.SetStatus(codes.Error, response.Errors.Error())
+	}
+
+	return response
+}
+
+// InterceptField crea un span por cada field que ejecuta un resolver
+func (t Tracing) InterceptField(ctx context.Context, next graphql.Resolver) (any, error) {
+	fieldCtx := graphql.GetFieldContext(ctx)
+	if fieldCtx == nil || !fieldCtx.IsResolver {
+		return next(ctx)
+	}
+
+	ctx, span := t.tracer.Start(ctx, fmt.Sprintf("graphql.resolve %s.%s", fieldCtx.Object, fieldCtx.Field.Name),
+		trace.WithAttributes(
+			attribute.String("graphql.field.object", fieldCtx.Object),
+			attribute.String("graphql.field.name", fieldCtx.Field.Name),
+			attribute.String("graphql.field.path", fieldCtx.Path().String()),
+		),
+	)
+	defer span.End()
+
+	result, err := next(ctx)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return result, err
+}
+
+// operationName devuelve el nombre de la operación o "anonymous" si no tiene
+func operationName(opCtx *graphql.OperationContext) string {
+	if opCtx.OperationName != "" {
+		return opCtx.OperationName
+	}
+	if opCtx.Operation != nil && opCtx.Operation.Name != "" {
+		return opCtx.Operation.Name
+	}
+	return "anonymous"
+}
@@ -0,0 +1,176 @@
+package presenter
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"bff-graphql-payment/internal/domain/exception"
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ErrorCode es el código estable expuesto al frontend en extensions.code
+type ErrorCode string
+
+const (
+	CodePaymentRackNotFound     ErrorCode = "PAYMENT_RACK_NOT_FOUND"
+	CodeInvalidPaymentRackID    ErrorCode = "INVALID_PAYMENT_RACK_ID"
+	CodeUpstreamUnavailable     ErrorCode = "UPSTREAM_UNAVAILABLE"
+	CodeUpstreamTimeout         ErrorCode = "UPSTREAM_TIMEOUT"
+	CodeInvalidBookingTimeID    ErrorCode = "INVALID_BOOKING_TIME_ID"
+	CodeNoLockersAvailable      ErrorCode = "NO_LOCKERS_AVAILABLE"
+	CodeInvalidCouponCode       ErrorCode = "INVALID_COUPON_CODE"
+	CodeCouponNotFound          ErrorCode = "COUPON_NOT_FOUND"
+	CodeCouponInvalid           ErrorCode = "COUPON_INVALID"
+	CodeInvalidGroupID          ErrorCode = "INVALID_GROUP_ID"
+	CodeInvalidEmail            ErrorCode = "INVALID_EMAIL"
+	CodeInvalidPhone            ErrorCode = "INVALID_PHONE"
+	CodePurchaseOrderFailed     ErrorCode = "PURCHASE_ORDER_FAILED"
+	CodeInvalidTraceID          ErrorCode = "INVALID_TRACE_ID"
+	CodeInvalidGatewayName      ErrorCode = "INVALID_GATEWAY_NAME"
+	CodeInvalidPurchaseOrder    ErrorCode = "INVALID_PURCHASE_ORDER"
+	CodeBookingGenerationFailed ErrorCode = "BOOKING_GENERATION_FAILED"
+	CodePurchaseOrderNotFound   ErrorCode = "PURCHASE_ORDER_NOT_FOUND"
+	CodeInvalidServiceName      ErrorCode = "INVALID_SERVICE_NAME"
+	CodeInvalidCurrentCode      ErrorCode = "INVALID_CURRENT_CODE"
+	CodeBookingNotFound         ErrorCode = "BOOKING_NOT_FOUND"
+	CodeExecuteOpenFailed       ErrorCode = "EXECUTE_OPEN_FAILED"
+	CodeValidationFailed        ErrorCode = "VALIDATION_FAILED"
+	CodeIdempotencyConflict     ErrorCode = "IDEMPOTENCY_KEY_CONFLICT"
+	CodeIdempotencyInProgress   ErrorCode = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	CodeInvalidIdempotencyKey   ErrorCode = "INVALID_IDEMPOTENCY_KEY"
+	CodeIdempotentResponseLost  ErrorCode = "IDEMPOTENT_RESPONSE_UNAVAILABLE"
+	CodeRateLimited             ErrorCode = "RATE_LIMITED"
+	CodeRequestCancelled        ErrorCode = "REQUEST_CANCELLED"
+	CodeShuttingDown            ErrorCode = "SHUTTING_DOWN"
+	CodeInternal                ErrorCode = "INTERNAL_ERROR"
+)
+
+// maskedMessage es el mensaje que se muestra para errores desconocidos en producción
+const maskedMessage = "internal server error"
+
+// errorDescriptor describe cómo se presenta un error al frontend
+type errorDescriptor struct {
+	Code       ErrorCode
+	HTTPStatus int
+	Retryable  bool
+}
+
+// errorCatalogEntry asocia un error centinela con su descriptor
+type errorCatalogEntry struct {
+	err        error
+	descriptor errorDescriptor
+}
+
+// errorCatalog contiene la traducción de cada error centinela de dominio y aplicación
+var errorCatalog = []errorCatalogEntry{
+	// Errores de dominio
+	{exception.ErrPaymentRackNotFound, errorDescriptor{CodePaymentRackNotFound, http.StatusNotFound, false}},
+	{exception.ErrInvalidPaymentRackID, errorDescriptor{CodeInvalidPaymentRackID, http.StatusBadRequest, false}},
+	{exception.ErrPaymentInfraServiceUnavailable, errorDescriptor{CodeUpstreamUnavailable, http.StatusServiceUnavailable, true}},
+	{exception.ErrPaymentInfraServiceTimeout, errorDescriptor{CodeUpstreamTimeout, http.StatusGatewayTimeout, true}},
+	{exception.ErrInvalidBookingTimeID, errorDescriptor{CodeInvalidBookingTimeID, http.StatusBadRequest, false}},
+	{exception.ErrNoLockersAvailable, errorDescriptor{CodeNoLockersAvailable, http.StatusConflict, false}},
+	{exception.ErrInvalidCouponCode, errorDescriptor{CodeInvalidCouponCode, http.StatusBadRequest, false}},
+	{exception.ErrCouponNotFound, errorDescriptor{CodeCouponNotFound, http.StatusNotFound, false}},
+	{exception.ErrInvalidCoupon, errorDescriptor{CodeCouponInvalid, http.StatusUnprocessableEntity, false}},
+	{exception.ErrInvalidGroupID, errorDescriptor{CodeInvalidGroupID, http.StatusBadRequest, false}},
+	{exception.ErrInvalidEmail, errorDescriptor{CodeInvalidEmail, http.StatusBadRequest, false}},
+	{exception.ErrInvalidPhone, errorDescriptor{CodeInvalidPhone, http.StatusBadRequest, false}},
+	{exception.ErrPurchaseOrderFailed, errorDescriptor{CodePurchaseOrderFailed, http.StatusBadGateway, false}},
+	{exception.ErrInvalidTraceID, errorDescriptor{CodeInvalidTraceID, http.StatusBadRequest, false}},
+	{exception.ErrInvalidGatewayName, errorDescriptor{CodeInvalidGatewayName, http.StatusBadRequest, false}},
+	{exception.ErrInvalidPurchaseOrder, errorDescriptor{CodeInvalidPurchaseOrder, http.StatusBadRequest, false}},
+	{exception.ErrBookingGenerationFailed, errorDescriptor{CodeBookingGenerationFailed, http.StatusBadGateway, false}},
+	{exception.ErrPurchaseOrderNotFound, errorDescriptor{CodePurchaseOrderNotFound, http.StatusNotFound, false}},
+	{exception.ErrInvalidServiceName, errorDescriptor{CodeInvalidServiceName, http.StatusBadRequest, false}},
+	{exception.ErrInvalidCurrentCode, errorDescriptor{CodeInvalidCurrentCode, http.StatusBadRequest, false}},
+	{exception.ErrBookingNotFound, errorDescriptor{CodeBookingNotFound, http.StatusNotFound, false}},
+	{exception.ErrExecuteOpenFailed, errorDescriptor{CodeExecuteOpenFailed, http.StatusBadGateway, false}},
+
+	// Errores de aplicación
+	{appException.ErrValidationFailed, errorDescriptor{CodeValidationFailed, http.StatusBadRequest, false}},
+	{appException.ErrServiceUnavailable, errorDescriptor{CodeUpstreamUnavailable, http.StatusServiceUnavailable, true}},
+	{appException.ErrIdempotencyKeyConflict, errorDescriptor{CodeIdempotencyConflict, http.StatusConflict, false}},
+	{appException.ErrIdempotencyKeyInProgress, errorDescriptor{CodeIdempotencyInProgress, http.StatusConflict, true}},
+	{appException.ErrInvalidIdempotencyKey, errorDescriptor{CodeInvalidIdempotencyKey, http.StatusBadRequest, false}},
+	{appException.ErrIdempotentResponseUnavailable, errorDescriptor{CodeIdempotentResponseLost, http.StatusConflict, false}},
+	{appException.ErrRateLimited, errorDescriptor{CodeRateLimited, http.StatusTooManyRequests, true}},
+	{appException.ErrShuttingDown, errorDescriptor{CodeShuttingDown, http.StatusServiceUnavailable, true}},
+
+	// Errores de contexto
+	{context.DeadlineExceeded, errorDescriptor{CodeUpstreamTimeout, http.StatusGatewayTimeout, true}},
+	{context.Canceled, errorDescriptor{CodeRequestCancelled, 499, false}},
+}
+
+// unknownDescriptor se usa para errores que no están en el catálogo
+var unknownDescriptor = errorDescriptor{CodeInternal, http.StatusInternalServerError, false}
+
+// NewErrorPresenter crea un ErrorPresenter de gqlgen que agrega extensions tipadas a cada error.
+// Si maskUnknown es true, el mensaje de los errores desconocidos se reemplaza por uno genérico
+// y el error original solo queda en el log.
+func NewErrorPresenter(maskUnknown bool, logger *slog.Logger) graphql.ErrorPresenterFunc {
+	return func(ctx context.Context, err error) *gqlerror.Error {
+		gqlErr := graphql.DefaultErrorPresenter(ctx, err)
+		if gqlErr == nil {
+			return nil
+		}
+
+		// Errores propios de gqlgen (parseo, validación) ya traen su código
+		if gqlErr.Err == nil {
+			return gqlErr
+		}
+
+		descriptor, known := describe(gqlErr.Err)
+		if !known && maskUnknown {
+			logger.ErrorContext(ctx, "unknown GraphQL error masked", "path", gqlErr.Path.String(), "error", gqlErr.Err)
+			gqlErr.Message = maskedMessage
+		}
+
+		if gqlErr.Extensions == nil {
+			gqlErr.Extensions = map[string]interface{}{}
+		}
+		gqlErr.Extensions["code"] = descriptor.Code
+		gqlErr.Extensions["httpStatus"] = descriptor.HTTPStatus
+		gqlErr.Extensions["retryable"] = descriptor.Retryable
+
+		// Exponer los campos inválidos reportados por el servicio externo
+		var upstreamErr *exception.UpstreamError
+		if errors.As(gqlErr.Err, &upstreamErr) && len(upstreamErr.FieldViolations) > 0 {
+			violations := make([]map[string]string, 0, len(upstreamErr.FieldViolations))
+			for _, violation := range upstreamErr.FieldViolations {
+				violations = append(violations, map[string]string{
+					"field":       violation.Field,
+					"description": violation.Description,
+				})
+			}
+			gqlErr.Extensions["fieldViolations"] = violations
+		}
+
+		// Indicar cuándo puede reintentar un cliente limitado
+		var rateLimitErr *appException.RateLimitError
+		if errors.As(gqlErr.Err, &rateLimitErr) {
+			gqlErr.Extensions["retryAfterSeconds"] = int(math.Ceil(rateLimitErr.RetryAfter.Seconds()))
+			gqlErr.Extensions["reason"] = "rate_limit"
+			if rateLimitErr.Locked {
+				gqlErr.Extensions["reason"] = "lockout"
+			}
+		}
+
+		return gqlErr
+	}
+}
+
+// describe busca el descriptor del primer error centinela contenido en err
+func describe(err error) (errorDescriptor, bool) {
+	for _, entry := range errorCatalog {
+		if errors.Is(err, entry.err) {
+			return entry.descriptor, true
+		}
+	}
+	return unknownDescriptor, false
+}
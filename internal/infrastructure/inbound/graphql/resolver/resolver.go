@@ -1,8 +1,11 @@
 package resolver
 
 import (
+	domainModel "bff-graphql-payment/internal/domain/model"
 	"bff-graphql-payment/internal/domain/ports"
 	"bff-graphql-payment/internal/infrastructure/inbound/graphql/mapper"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/subscription"
+	"log/slog"
 )
 
 // This file will not be regenerated automatically.
@@ -12,12 +15,35 @@ import (
 type Resolver struct {
 	paymentInfraService ports.PaymentInfraService
 	mapper              *mapper.PaymentInfraGraphQLMapper
+	subscriptions       *subscription.Registry
+	logger              *slog.Logger
 }
 
 // NewResolver crea un nuevo resolver con dependencias
-func NewResolver(paymentInfraService ports.PaymentInfraService) *Resolver {
+func NewResolver(paymentInfraService ports.PaymentInfraService, subscriptions *subscription.Registry, logger *slog.Logger) *Resolver {
 	return &Resolver{
 		paymentInfraService: paymentInfraService,
 		mapper:              mapper.NewPaymentInfraGraphQLMapper(),
+		subscriptions:       subscriptions,
+		logger:              logger.With("component", "graphql"),
+	}
+}
+
+// stringValue devuelve el valor de un argumento opcional o "" si no se envió
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
+
+// interruptedOpenResult es el estado final que se emite cuando el plazo de drenaje corta una apertura
+// antes de SUCCESS o ERROR: no se sabe si la puerta se abrió
+func interruptedOpenResult(transactionID string) *domainModel.ExecuteOpenResult {
+	return &domainModel.ExecuteOpenResult{
+		TransactionID:  transactionID,
+		Message:        domainModel.IncompleteOpenMessage,
+		OpenStatus:     domainModel.OpenStatusError,
+		PhysicalStatus: domainModel.PhysicalStatusUnexpected,
 	}
 }
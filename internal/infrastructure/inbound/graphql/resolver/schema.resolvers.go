@@ -7,49 +7,65 @@ package resolver
 import (
 	"bff-graphql-payment/graph/generated"
 	"bff-graphql-payment/graph/model"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/subscription"
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
 	"context"
 	"fmt"
 	"time"
 )
 
 // GeneratePurchaseOrder is the resolver for the generatePurchaseOrder field.
-func (r *mutationResolver) GeneratePurchaseOrder(ctx context.Context, input model.GeneratePurchaseOrderInput) (*model.GeneratePurchaseOrderResponse, error) {
+func (r *mutationResolver) GeneratePurchaseOrder(ctx context.Context, input model.GeneratePurchaseOrderInput, idempotencyKey *string) (*model.GeneratePurchaseOrderResponse, error) {
 	// Normalizar couponCode: si es un puntero a string vacío, convertir a nil
 	couponCode := input.CouponCode
 	if couponCode != nil && *couponCode == "" {
 		couponCode = nil
 	}
 
-	// Log para debug
-	couponCodeLog := "nil"
+	// Propagar el traceId del input a los servicios upstream
+	ctx = requestmeta.WithTraceID(ctx, input.TraceID)
+
+	// Los datos personales (email, teléfono, cupón) se redactan en el logger
+	couponCodeLog := ""
 	if couponCode != nil {
-		couponCodeLog = fmt.Sprintf("\"%s\"", *couponCode)
+		couponCodeLog = *couponCode
 	}
-	fmt.Printf("🔷 GraphQL Resolver - GeneratePurchaseOrder: rackId=%d, groupId=%d, couponCode=%s, email=%s, phone=%s, traceId=%s, gateway=%s\n",
-		input.RackIDReference, input.GroupID, couponCodeLog, input.UserEmail, input.UserPhone, input.TraceID, input.GatewayName)
+	r.logger.InfoContext(ctx, "generatePurchaseOrder request",
+		"rackId", input.RackIDReference,
+		"groupId", input.GroupID,
+		"couponCode", couponCodeLog,
+		"userEmail", input.UserEmail,
+		"userPhone", input.UserPhone,
+		"gateway", input.GatewayName,
+		"idempotencyKey", stringValue(idempotencyKey),
+	)
 
 	// Llamar al caso de uso
-	order, err := r.paymentInfraService.GeneratePurchaseOrder(ctx, input.RackIDReference, input.GroupID, couponCode, input.UserEmail, input.UserPhone, input.TraceID, input.GatewayName)
+	order, err := r.paymentInfraService.GeneratePurchaseOrder(ctx, input.RackIDReference, input.GroupID, couponCode, input.UserEmail, input.UserPhone, input.TraceID, input.GatewayName, stringValue(idempotencyKey))
 	if err != nil {
-		fmt.Printf("❌ GraphQL Resolver - GeneratePurchaseOrder failed: %v\n", err)
+		r.logger.ErrorContext(ctx, "generatePurchaseOrder failed", "error", err)
 		return nil, fmt.Errorf("failed to generate purchase order: %w", err)
 	}
 
-	fmt.Printf("✅ GraphQL Resolver - GeneratePurchaseOrder succeeded\n")
+	r.logger.InfoContext(ctx, "generatePurchaseOrder succeeded")
 	// Mapear a respuesta GraphQL
 	return r.mapper.ToPurchaseOrderResponse(order), nil
 }
 
 // GenerateBooking is the resolver for the generateBooking field.
-func (r *mutationResolver) GenerateBooking(ctx context.Context, input model.GenerateBookingInput) (*model.GenerateBookingResponse, error) {
+func (r *mutationResolver) GenerateBooking(ctx context.Context, input model.GenerateBookingInput, idempotencyKey *string) (*model.GenerateBookingResponse, error) {
 	// Normalizar couponCode: si es un puntero a string vacío, convertir a nil
 	couponCode := input.CouponCode
 	if couponCode != nil && *couponCode == "" {
 		couponCode = nil
 	}
 
+	// Propagar el traceId del input a los servicios upstream
+	ctx = requestmeta.WithTraceID(ctx, input.TraceID)
+
 	// Llamar al caso de uso
-	booking, err := r.paymentInfraService.GenerateBooking(ctx, input.RackIDReference, input.GroupID, couponCode, input.UserEmail, input.UserPhone, input.TraceID)
+	booking, err := r.paymentInfraService.GenerateBooking(ctx, input.RackIDReference, input.GroupID, couponCode, input.UserEmail, input.UserPhone, input.TraceID, stringValue(idempotencyKey))
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate booking: %w", err)
 	}
@@ -72,6 +88,9 @@ func (r *queryResolver) GetPaymentInfraByQRValue(ctx context.Context, input mode
 
 // GetAvailableLockersByRackIDAndBookingTime is the resolver for the getAvailableLockersByRackIDAndBookingTime field.
 func (r *queryResolver) GetAvailableLockersByRackIDAndBookingTime(ctx context.Context, input model.GetAvailableLockersByRackIDAndBookingTimeInput) (*model.AvailableLockersByRackIDAndBookingTimeResponse, error) {
+	// Propagar el traceId del input a los servicios upstream
+	ctx = requestmeta.WithTraceID(ctx, input.TraceID)
+
 	// Llamar al caso de uso
 	lockers, err := r.paymentInfraService.GetAvailableLockers(ctx, input.PaymentRackID, input.BookingTimeID, input.TraceID)
 	if err != nil {
@@ -84,6 +103,9 @@ func (r *queryResolver) GetAvailableLockersByRackIDAndBookingTime(ctx context.Co
 
 // ValidateDiscountCoupon is the resolver for the validateDiscountCoupon field.
 func (r *queryResolver) ValidateDiscountCoupon(ctx context.Context, input model.ValidateDiscountCouponInput) (*model.ValidateDiscountCouponResponse, error) {
+	// Propagar el traceId del input a los servicios upstream
+	ctx = requestmeta.WithTraceID(ctx, input.TraceID)
+
 	// Llamar al caso de uso
 	validation, err := r.paymentInfraService.ValidateDiscountCoupon(ctx, input.CouponCode, input.RackID, input.TraceID)
 	if err != nil {
@@ -96,6 +118,9 @@ func (r *queryResolver) ValidateDiscountCoupon(ctx context.Context, input model.
 
 // GetPurchaseOrderByPo is the resolver for the getPurchaseOrderByPo field.
 func (r *queryResolver) GetPurchaseOrderByPo(ctx context.Context, input model.GetPurchaseOrderByPoInput) (*model.PurchaseOrderResponse, error) {
+	// Propagar el traceId del input a los servicios upstream
+	ctx = requestmeta.WithTraceID(ctx, input.TraceID)
+
 	// Llamar al caso de uso
 	orderData, err := r.paymentInfraService.GetPurchaseOrderByPo(ctx, input.PurchaseOrder, input.TraceID)
 	if err != nil {
@@ -120,60 +145,113 @@ func (r *queryResolver) CheckBookingStatus(ctx context.Context, input model.Chec
 
 // ExecuteOpen is the resolver for the executeOpen field.
 func (r *subscriptionResolver) ExecuteOpen(ctx context.Context, input model.ExecuteOpenInput) (<-chan *model.ExecuteOpenResponse, error) {
-	// Log de entrada
-	fmt.Printf("🔷 GraphQL Subscription - ExecuteOpen REQUEST: serviceName=%s, currentCode=%s\n",
-		input.ServiceName, input.CurrentCode)
+	// Log de entrada (currentCode se redacta en el logger)
+	r.logger.InfoContext(ctx, "executeOpen subscription request",
+		"serviceName", input.ServiceName,
+		"currentCode", input.CurrentCode,
+	)
+
+	// Registrar la subscription para drenarla al apagar el servidor (durante el apagado se rechaza).
+	// streamCtx se cancela si la apertura no termina dentro del plazo de drenaje.
+	streamCtx, subscriptionDone, err := r.subscriptions.Start(ctx)
+	if err != nil {
+		r.logger.WarnContext(ctx, "executeOpen subscription rejected", "error", err)
+		return nil, fmt.Errorf("failed to execute open: %w", err)
+	}
 
 	// Obtener el canal del servicio que emite los 3 estados progresivamente
-	domainChan, err := r.paymentInfraService.ExecuteOpenStream(ctx, input.ServiceName, input.CurrentCode)
+	domainChan, err := r.paymentInfraService.ExecuteOpenStream(streamCtx, input.ServiceName, input.CurrentCode)
 	if err != nil {
-		fmt.Printf("❌ GraphQL Subscription - ExecuteOpen FAILED to start: %v\n", err)
+		subscriptionDone()
+		r.logger.ErrorContext(ctx, "executeOpen subscription failed to start", "error", err)
 		return nil, fmt.Errorf("failed to execute open: %w", err)
 	}
 
 	// Crear canal de salida para GraphQL con buffer suficiente para los 3 mensajes
 	outputChan := make(chan *model.ExecuteOpenResponse, 5)
 
+	// Contabilizar la subscription activa hasta que se cierre el canal de salida
+	subscriptionFinished := telemetry.ExecuteOpenSubscriptionStarted()
+
 	// Goroutine para transformar y reenviar los mensajes del dominio a GraphQL
 	go func() {
 		defer func() {
-			fmt.Printf("🔒 GraphQL Subscription - Closing output channel\n")
+			r.logger.DebugContext(ctx, "executeOpen subscription closing output channel")
 			close(outputChan)
+			subscriptionFinished()
+			subscriptionDone()
 		}()
 
 		messageCount := 0
 		var lastMessage *model.ExecuteOpenResponse
+		var transactionID string
+		terminalSent := false
+
+		// Si el plazo de drenaje vence antes del estado final, informar al frontend que no se sabe
+		// si la puerta se abrió antes de cerrar la conexión
+		defer func() {
+			if terminalSent || !subscription.DrainTimedOut(streamCtx) {
+				return
+			}
+			interrupted := interruptedOpenResult(transactionID)
+			select {
+			case outputChan <- r.mapper.ToExecuteOpenResponse(interrupted):
+				r.logger.WarnContext(ctx, "executeOpen interrupted by shutdown, terminal status sent", "messages", messageCount)
+				telemetry.ObserveExecuteOpenOutcome(string(interrupted.OpenStatus), string(interrupted.PhysicalStatus))
+			case <-ctx.Done():
+			}
+		}()
 
 		for domainResult := range domainChan {
 			messageCount++
 
 			// Log del estado recibido
-			fmt.Printf("📥 GraphQL Subscription - Message %d: openStatus=%v, message=%s\n",
-				messageCount, domainResult.OpenStatus, domainResult.Message)
+			r.logger.InfoContext(ctx, "executeOpen status received",
+				"message", messageCount,
+				"openStatus", domainResult.OpenStatus,
+				"physicalStatus", domainResult.PhysicalStatus,
+				"detail", domainResult.Message,
+			)
 
 			// Mapear de dominio a GraphQL
 			graphQLResponse := r.mapper.ToExecuteOpenResponse(domainResult)
 			lastMessage = graphQLResponse
+			if domainResult.TransactionID != "" {
+				transactionID = domainResult.TransactionID
+			}
 
 			// Enviar al frontend de forma no bloqueante con timeout
 			select {
 			case outputChan <- graphQLResponse:
-				fmt.Printf("✅ GraphQL Subscription - Sent message %d to frontend: status=%v\n",
-					messageCount, graphQLResponse.OpenStatus)
-			case <-ctx.Done():
-				fmt.Printf("⚠️ GraphQL Subscription - Context cancelled after %d messages\n", messageCount)
+				r.logger.DebugContext(ctx, "executeOpen status sent to frontend",
+					"message", messageCount,
+					"openStatus", graphQLResponse.OpenStatus,
+				)
+			case <-streamCtx.Done():
+				r.logger.WarnContext(ctx, "executeOpen subscription cancelled", "messages", messageCount)
 				return
 			}
 
 			// Log si es un estado terminal pero NO salimos, esperamos a que el canal se vacíe
-			if domainResult.OpenStatus == "OPEN_STATUS_SUCCESS" || domainResult.OpenStatus == "OPEN_STATUS_ERROR" {
-				fmt.Printf("🏁 GraphQL Subscription - Terminal status sent (%v), waiting for channel drain\n", domainResult.OpenStatus)
+			if domainResult.OpenStatus.IsTerminal() {
+				terminalSent = true
+				r.logger.InfoContext(ctx, "executeOpen terminal status sent, waiting for channel drain",
+					"openStatus", domainResult.OpenStatus,
+					"physicalStatus", domainResult.PhysicalStatus,
+				)
+				telemetry.ObserveExecuteOpenOutcome(string(domainResult.OpenStatus), string(domainResult.PhysicalStatus))
 			}
 		}
 
 		// El canal domainChan se cerró (ya se enviaron todos los mensajes)
-		fmt.Printf("✅ GraphQL Subscription - ExecuteOpen completed with %d messages, last status: %v\n",
-			messageCount, lastMessage.OpenStatus)
+		lastStatus := "none"
+		if lastMessage != nil {
+			lastStatus = fmt.Sprintf("%v", lastMessage.OpenStatus)
+		}
+		r.logger.InfoContext(ctx, "executeOpen subscription completed",
+			"messages", messageCount,
+			"lastStatus", lastStatus,
+		)
 
 		// Pequeño delay para asegurar que el último mensaje se procese
 		time.Sleep(100 * time.Millisecond)
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bff-graphql-payment/graph/generated"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/middleware"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/presenter"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/resolver"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/subscription"
+	"bff-graphql-payment/internal/infrastructure/origin"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gorilla/websocket"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// Settings contiene las dependencias del servidor GraphQL además del resolver
+type Settings struct {
+	// Production oculta en las respuestas los errores desconocidos
+	Production bool
+	// OriginPolicy valida el Origin del upgrade WebSocket (la misma política que CORS)
+	OriginPolicy *origin.Policy
+	// Subscriptions registra las conexiones WebSocket para cerrarlas al drenar
+	Subscriptions *subscription.Registry
+	Logger        *slog.Logger
+}
+
+// NewHandler crea el servidor GraphQL con soporte completo para subscriptions vía WebSocket.
+// Lo usan el binario del BFF y los tests de integración, así ambos ejercitan la misma configuración.
+func NewHandler(graphQLResolver *resolver.Resolver, settings Settings) *handler.Server {
+	srv := handler.New(
+		generated.NewExecutableSchema(
+			generated.Config{Resolvers: graphQLResolver},
+		),
+	)
+
+	// Configurar transports (HTTP POST, WebSocket para subscriptions, GET para queries)
+	srv.AddTransport(transport.Options{})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.AddTransport(transport.MultipartForm{})
+
+	// WebSocket transport para subscriptions - CRÍTICO para executeOpen subscription
+	// El origen se valida con la misma política que CORS
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		// Contabilizar conexiones WebSocket abiertas (gauge en /metrics)
+		// y registrarlas para cerrarlas con un close frame al terminar el drenaje de subscriptions
+		InitFunc: func(ctx context.Context, initPayload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+			ctx = settings.Subscriptions.TrackConnection(ctx)
+			return telemetry.WebSocketConnectionInitialized(ctx), nil, nil
+		},
+		CloseFunc: func(ctx context.Context, closeCode int) {
+			settings.Subscriptions.ConnectionClosed(ctx)
+			telemetry.WebSocketConnectionClosed(ctx)
+		},
+		Upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool {
+				if !settings.OriginPolicy.CheckOrigin(r) {
+					settings.Logger.WarnContext(r.Context(), "websocket origin rejected", "origin", r.Header.Get("Origin"))
+					return false
+				}
+				return true
+			},
+		},
+	})
+
+	// Presentar errores con códigos estables (extensions.code) y ocultar errores desconocidos en producción
+	srv.SetErrorPresenter(presenter.NewErrorPresenter(settings.Production, settings.Logger.With("component", "graphql")))
+
+	// Configurar query cache y extensions
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.AutomaticPersistedQuery{
+		Cache: lru.New[string](100),
+	})
+
+	// Spans por operación GraphQL y por resolver
+	srv.Use(middleware.NewTracing())
+
+	// Métricas Prometheus por operación GraphQL y código de error
+	srv.Use(middleware.NewMetrics())
+
+	return srv
+}
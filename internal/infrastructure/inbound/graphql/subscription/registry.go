@@ -0,0 +1,210 @@
+package subscription
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrDrainTimeout se devuelve cuando quedaron subscriptions activas al vencer el plazo de drenaje
+var ErrDrainTimeout = errors.New("subscription drain timed out")
+
+// completeGrace da tiempo a gqlgen para enviar el mensaje complete de cada stream
+// antes de cerrar las conexiones WebSocket
+const completeGrace = 250 * time.Millisecond
+
+// cancelGrace es la espera adicional para que los streams cancelados al vencer el drenaje terminen
+// y para que las conexiones WebSocket envíen su close frame
+const cancelGrace = time.Second
+
+// connectionKey identifica la conexión WebSocket registrada en el contexto
+type connectionKey struct{}
+
+// Registry lleva la cuenta de las subscriptions activas y de las conexiones WebSocket
+// (que http.Server.Shutdown no controla por estar hijacked) para drenarlas al apagar el servidor
+type Registry struct {
+	mu          sync.Mutex
+	draining    bool
+	nextID      uint64
+	streams     map[uint64]context.CancelCauseFunc
+	connections map[uint64]context.CancelFunc
+	// idle se cierra cuando, durante el drenaje, ya no quedan streams activos
+	idle       chan struct{}
+	idleClosed bool
+	// disconnected se cierra cuando, tras el drenaje, ya no quedan conexiones abiertas
+	disconnected       chan struct{}
+	disconnectedClosed bool
+	logger             *slog.Logger
+}
+
+// NewRegistry crea un registro de subscriptions vacío
+func NewRegistry(logger *slog.Logger) *Registry {
+	return &Registry{
+		streams:      make(map[uint64]context.CancelCauseFunc),
+		connections:  make(map[uint64]context.CancelFunc),
+		idle:         make(chan struct{}),
+		disconnected: make(chan struct{}),
+		logger:       logger.With("component", "subscriptions"),
+	}
+}
+
+// TrackConnection registra una conexión WebSocket (se usa en el InitFunc del transport).
+// El contexto devuelto se cancela al terminar el drenaje, y gqlgen cierra la conexión con un close frame.
+func (r *Registry) TrackConnection(ctx context.Context) context.Context {
+	ctx, cancel := context.WithCancel(ctx)
+
+	r.mu.Lock()
+	r.nextID++
+	id := r.nextID
+	r.connections[id] = cancel
+	draining := r.draining
+	r.mu.Unlock()
+
+	// Una conexión que se inicializa durante el apagado se cierra de inmediato
+	if draining {
+		cancel()
+	}
+	return context.WithValue(ctx, connectionKey{}, id)
+}
+
+// ConnectionClosed elimina la conexión del registro (se usa en el CloseFunc del transport)
+func (r *Registry) ConnectionClosed(ctx context.Context) {
+	id, ok := ctx.Value(connectionKey{}).(uint64)
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	cancel, found := r.connections[id]
+	delete(r.connections, id)
+	r.closeDisconnectedIfDrained()
+	r.mu.Unlock()
+
+	if found {
+		cancel()
+	}
+}
+
+// Start registra una subscription. Devuelve ErrShuttingDown si el servidor se está apagando.
+// El contexto devuelto se cancela con causa ErrDrainTimeout si el stream no termina dentro del plazo
+// de drenaje (ver DrainTimedOut); done debe llamarse una sola vez al cerrar el canal de la subscription.
+func (r *Registry) Start(ctx context.Context) (context.Context, func(), error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.draining {
+		return nil, nil, appException.ErrShuttingDown
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	r.nextID++
+	id := r.nextID
+	r.streams[id] = cancel
+
+	done := func() {
+		r.mu.Lock()
+		delete(r.streams, id)
+		r.closeIdleIfDrained()
+		r.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, done, nil
+}
+
+// DrainTimedOut indica si el contexto de una subscription se canceló porque venció el plazo de drenaje.
+// En ese caso el stream debe emitir su estado terminal antes de cerrarse.
+func DrainTimedOut(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrDrainTimeout)
+}
+
+// Active devuelve la cantidad de subscriptions activas
+func (r *Registry) Active() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.streams)
+}
+
+// Draining indica si el servidor dejó de aceptar subscriptions
+func (r *Registry) Draining() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.draining
+}
+
+// Drain rechaza las subscriptions nuevas y espera a que las activas lleguen a un estado terminal.
+// Si ctx vence antes, cancela los streams restantes con causa ErrDrainTimeout (cada uno emite un
+// OPEN_STATUS_ERROR con PHYSICAL_STATUS_UNEXPECTED) y devuelve ErrDrainTimeout.
+// En ambos casos cierra al final las conexiones WebSocket registradas.
+func (r *Registry) Drain(ctx context.Context) error {
+	r.mu.Lock()
+	r.draining = true
+	active := len(r.streams)
+	r.closeIdleIfDrained()
+	r.mu.Unlock()
+
+	if active > 0 {
+		r.logger.Info("draining subscriptions", "active", active)
+	}
+
+	var err error
+	select {
+	case <-r.idle:
+		time.Sleep(completeGrace)
+	case <-ctx.Done():
+		r.mu.Lock()
+		remaining := len(r.streams)
+		for _, cancel := range r.streams {
+			cancel(ErrDrainTimeout)
+		}
+		r.mu.Unlock()
+
+		err = fmt.Errorf("%w: %d subscriptions cancelled", ErrDrainTimeout, remaining)
+		r.logger.Warn("subscription drain timed out, cancelling remaining streams", "remaining", remaining)
+
+		select {
+		case <-r.idle:
+			time.Sleep(completeGrace)
+		case <-time.After(cancelGrace):
+		}
+	}
+
+	// Cerrar las conexiones WebSocket (gqlgen envía el close frame al cancelar su contexto)
+	// y esperar a que terminen antes de que el proceso salga
+	r.mu.Lock()
+	connections := len(r.connections)
+	for _, cancel := range r.connections {
+		cancel()
+	}
+	r.closeDisconnectedIfDrained()
+	r.mu.Unlock()
+
+	select {
+	case <-r.disconnected:
+	case <-time.After(cancelGrace):
+	}
+
+	if err == nil {
+		r.logger.Info("subscriptions drained", "subscriptions", active, "connections", connections)
+	}
+	return err
+}
+
+// closeDisconnectedIfDrained cierra disconnected si se está drenando y no quedan conexiones (requiere r.mu tomado)
+func (r *Registry) closeDisconnectedIfDrained() {
+	if r.draining && len(r.connections) == 0 && !r.disconnectedClosed {
+		close(r.disconnected)
+		r.disconnectedClosed = true
+	}
+}
+
+// closeIdleIfDrained cierra idle si se está drenando y no quedan streams (requiere r.mu tomado)
+func (r *Registry) closeIdleIfDrained() {
+	if r.draining && len(r.streams) == 0 && !r.idleClosed {
+		close(r.idle)
+		r.idleClosed = true
+	}
+}
@@ -0,0 +1,187 @@
+package health
+
+import (
+	"bff-graphql-payment/internal/infrastructure/buildinfo"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"bff-graphql-payment/internal/infrastructure/outbound/routing"
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Estados reportados por readiness y details
+const (
+	StatusReady       = "ready"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// requiredUpstream es el upstream sin el cual el BFF no puede atender solicitudes.
+// Si solo falla Booking Manager el BFF opera degradado.
+const requiredUpstream = "payment"
+
+// Upstreams expone el estado de los managers gRPC (lo implementa el cliente gRPC)
+type Upstreams interface {
+	Connections() []client.ConnectionStatus
+	CircuitBreakers() []client.CircuitBreakerSnapshot
+	CheckHealth(ctx context.Context, timeout time.Duration) []client.HealthCheckResult
+}
+
+// Adapters expone el adaptador vigente de cada upstream ("grpc" o "mock"); lo implementa el enrutador
+type Adapters interface {
+	Adapters() map[string]string
+}
+
+// Settings configura el endpoint de diagnóstico detallado
+type Settings struct {
+	// GRPCCheck ejecuta grpc.health.v1 contra cada manager en /health/details
+	GRPCCheck bool
+	// CheckTimeout es el tiempo máximo de cada consulta grpc.health.v1
+	CheckTimeout time.Duration
+}
+
+// Handler atiende los endpoints de liveness, readiness y diagnóstico detallado
+type Handler struct {
+	upstreams Upstreams
+	adapters  Adapters
+	settings  Settings
+}
+
+// NewHandler crea los handlers de salud sobre los upstreams indicados.
+// Solo se evalúan los upstreams que usan el adaptador gRPC; los simulados siempre están listos.
+func NewHandler(upstreams Upstreams, adapters Adapters, settings Settings) *Handler {
+	return &Handler{
+		upstreams: upstreams,
+		adapters:  adapters,
+		settings:  settings,
+	}
+}
+
+// upstreamDetails agrupa todo lo que se conoce de un manager
+type upstreamDetails struct {
+	Name           string                         `json:"name"`
+	Connection     *client.ConnectionStatus       `json:"connection,omitempty"`
+	CircuitBreaker *client.CircuitBreakerSnapshot `json:"circuitBreaker,omitempty"`
+	HealthCheck    *client.HealthCheckResult      `json:"healthCheck,omitempty"`
+}
+
+// Liveness responde 200 mientras el proceso esté vivo; no depende de los upstreams
+// para que el orquestador no reinicie el BFF por una caída de los managers
+func (h *Handler) Liveness(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "alive"})
+}
+
+// Readiness responde 200 si el BFF puede atender tráfico (upstreams READY o simulados)
+// y 503 si Payment Manager no está disponible
+func (h *Handler) Readiness(w http.ResponseWriter, r *http.Request) {
+	adapters := h.adapters.Adapters()
+	connections := grpcOnly(adapters, h.upstreams.Connections(), func(c client.ConnectionStatus) string { return c.Name })
+	readiness := readinessOf(connections)
+
+	writeJSON(w, httpStatusOf(readiness), map[string]interface{}{
+		"status":    readiness,
+		"mock":      allMock(adapters),
+		"adapters":  adapters,
+		"upstreams": connections,
+	})
+}
+
+// Details devuelve el diagnóstico completo: versión, uptime y, por upstream, conectividad,
+// última llamada exitosa, circuit breaker y opcionalmente la respuesta de grpc.health.v1
+func (h *Handler) Details(w http.ResponseWriter, r *http.Request) {
+	adapters := h.adapters.Adapters()
+	connections := grpcOnly(adapters, h.upstreams.Connections(), func(c client.ConnectionStatus) string { return c.Name })
+	readiness := readinessOf(connections)
+
+	upstreams := make([]*upstreamDetails, 0, 2)
+	byName := make(map[string]*upstreamDetails)
+	upstream := func(name string) *upstreamDetails {
+		if details, ok := byName[name]; ok {
+			return details
+		}
+		details := &upstreamDetails{Name: name}
+		byName[name] = details
+		upstreams = append(upstreams, details)
+		return details
+	}
+
+	for _, connection := range connections {
+		upstream(connection.Name).Connection = &connection
+	}
+	for _, breaker := range grpcOnly(adapters, h.upstreams.CircuitBreakers(), func(b client.CircuitBreakerSnapshot) string { return b.Name }) {
+		upstream(breaker.Name).CircuitBreaker = &breaker
+	}
+	if h.settings.GRPCCheck {
+		checks := grpcOnly(adapters, h.upstreams.CheckHealth(r.Context(), h.settings.CheckTimeout), func(c client.HealthCheckResult) string { return c.Name })
+		for _, result := range checks {
+			upstream(result.Name).HealthCheck = &result
+		}
+	}
+
+	info := buildinfo.Get()
+	writeJSON(w, httpStatusOf(readiness), map[string]interface{}{
+		"status":        readiness,
+		"version":       info.Version,
+		"commit":        info.Commit,
+		"goVersion":     info.GoVersion,
+		"startedAt":     info.StartedAt,
+		"uptimeSeconds": int64(buildinfo.Uptime().Seconds()),
+		"mock":          allMock(adapters),
+		"adapters":      adapters,
+		"upstreams":     upstreams,
+	})
+}
+
+// grpcOnly descarta los elementos de los upstreams que no usan el adaptador gRPC
+// (un upstream que pasó a mock en caliente conserva su conexión, pero ya no atiende tráfico)
+func grpcOnly[T any](adapters map[string]string, items []T, name func(T) string) []T {
+	filtered := make([]T, 0, len(items))
+	for _, item := range items {
+		if adapters[name(item)] == routing.AdapterGRPC {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// allMock indica si todos los upstreams están simulados
+func allMock(adapters map[string]string) bool {
+	for _, adapter := range adapters {
+		if adapter != routing.AdapterMock {
+			return false
+		}
+	}
+	return true
+}
+
+// readinessOf calcula el estado a partir de la conectividad de cada upstream
+func readinessOf(connections []client.ConnectionStatus) string {
+	readiness := StatusReady
+	for _, connection := range connections {
+		if connection.Ready {
+			continue
+		}
+		if connection.Name == requiredUpstream {
+			return StatusUnavailable
+		}
+		readiness = StatusDegraded
+	}
+	return readiness
+}
+
+// httpStatusOf traduce el estado a código HTTP (degradado sigue recibiendo tráfico)
+func httpStatusOf(readiness string) int {
+	if readiness == StatusUnavailable {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
+
+// writeJSON escribe la respuesta JSON con el código indicado
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
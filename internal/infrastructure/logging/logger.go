@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Formatos de salida soportados
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// redactedValue reemplaza el valor de los atributos sensibles
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys son los atributos que se ocultan cuando la redacción está activa (comparación sin mayúsculas)
+var sensitiveKeys = map[string]struct{}{
+	"useremail":   {},
+	"email":       {},
+	"userphone":   {},
+	"phone":       {},
+	"currentcode": {},
+	"couponcode":  {},
+	"coupon":      {},
+}
+
+// Settings define el formato y nivel del logger
+type Settings struct {
+	// Format es "json" o "text"
+	Format string
+	// Level es "debug", "info", "warn" o "error"
+	Level string
+	// LevelVar permite cambiar el nivel en caliente; si es nil el nivel queda fijo en Level
+	LevelVar *slog.LevelVar
+	// RedactPII oculta email, teléfono, códigos de apertura y cupones
+	RedactPII bool
+}
+
+// New crea el logger de la aplicación. Cada línea incluye el request ID y el trace ID
+// de la solicitud cuando se registra con un contexto (p. ej. logger.InfoContext(ctx, ...)).
+func New(settings Settings) *slog.Logger {
+	options := &slog.HandlerOptions{
+		Level: ParseLevel(settings.Level),
+	}
+	if settings.LevelVar != nil {
+		settings.LevelVar.Set(ParseLevel(settings.Level))
+		options.Level = settings.LevelVar
+	}
+	if settings.RedactPII {
+		options.ReplaceAttr = redactAttr
+	}
+
+	var handler slog.Handler
+	if strings.ToLower(settings.Format) == FormatJSON {
+		handler = slog.NewJSONHandler(os.Stdout, options)
+	} else {
+		handler = slog.NewTextHandler(os.Stdout, options)
+	}
+
+	return slog.New(&contextHandler{Handler: handler})
+}
+
+// ParseLevel convierte el nivel configurado; por defecto info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// redactAttr oculta el valor de los atributos sensibles
+func redactAttr(groups []string, attr slog.Attr) slog.Attr {
+	if _, ok := sensitiveKeys[strings.ToLower(attr.Key)]; ok && attr.Value.Kind() != slog.KindGroup {
+		return slog.String(attr.Key, redactedValue)
+	}
+	return attr
+}
+
+// contextHandler agrega request_id y trace_id desde el contexto a cada registro
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implementa slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		metadata, _ := requestmeta.FromContext(ctx)
+		if metadata.RequestID != "" {
+			record.AddAttrs(slog.String("request_id", metadata.RequestID))
+		}
+
+		traceID := metadata.TraceID
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+			if traceID == "" {
+				traceID = spanContext.TraceID().String()
+			}
+			record.AddAttrs(slog.String("span_id", spanContext.SpanID().String()))
+		}
+		if traceID != "" {
+			record.AddAttrs(slog.String("trace_id", traceID))
+		}
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implementa slog.Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implementa slog.Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
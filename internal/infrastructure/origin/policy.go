@@ -0,0 +1,142 @@
+package origin
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ErrInvalidPattern se devuelve cuando un origen permitido no tiene el formato esperado
+var ErrInvalidPattern = errors.New("invalid origin pattern")
+
+// pattern es un origen permitido ya validado
+type pattern struct {
+	scheme string
+	// host es el host exacto o, si wildcard es true, el dominio base (sin "*.")
+	host     string
+	port     string
+	wildcard bool
+}
+
+// Policy decide qué orígenes pueden llamar al BFF. Se comparte entre CORS y el upgrade WebSocket
+// y puede actualizarse en caliente.
+type Policy struct {
+	mu       sync.RWMutex
+	patterns []pattern
+	// strict rechaza las solicitudes sin header Origin (en WebSocket)
+	strict bool
+}
+
+// NewPolicy valida los patrones y crea la política.
+// Un patrón es "scheme://host[:port]"; el host puede empezar con "*." para aceptar cualquier subdominio.
+func NewPolicy(allowedOrigins []string, strict bool) (*Policy, error) {
+	policy := &Policy{}
+	if err := policy.Update(allowedOrigins, strict); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// Update reemplaza los orígenes permitidos. Si algún patrón es inválido la política no cambia.
+func (p *Policy) Update(allowedOrigins []string, strict bool) error {
+	var patterns []pattern
+	var errs []error
+	for _, raw := range allowedOrigins {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		parsed, err := parsePattern(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		patterns = append(patterns, parsed)
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.patterns = patterns
+	p.strict = strict
+	return nil
+}
+
+// Strict indica si se rechazan las solicitudes sin Origin
+func (p *Policy) Strict() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.strict
+}
+
+// Allowed indica si el origen recibido coincide con algún patrón permitido
+func (p *Policy) Allowed(origin string) bool {
+	parsed, err := url.Parse(strings.TrimSpace(origin))
+	if err != nil || parsed.Host == "" || parsed.Path != "" {
+		return false
+	}
+	scheme := strings.ToLower(parsed.Scheme)
+	host := strings.ToLower(parsed.Hostname())
+	port := parsed.Port()
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for _, allowed := range p.patterns {
+		if allowed.scheme != scheme || allowed.port != port {
+			continue
+		}
+		if allowed.wildcard {
+			if strings.HasSuffix(host, "."+allowed.host) {
+				return true
+			}
+			continue
+		}
+		if allowed.host == host {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckOrigin valida el Origin del handshake WebSocket. Sin Origin (Postman, curl) se acepta
+// salvo en modo estricto.
+func (p *Policy) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return !p.Strict()
+	}
+	return p.Allowed(origin)
+}
+
+// parsePattern valida un patrón de origen permitido
+func parsePattern(raw string) (pattern, error) {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return pattern{}, fmt.Errorf("%w %q: %v", ErrInvalidPattern, raw, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return pattern{}, fmt.Errorf("%w %q: scheme must be http or https", ErrInvalidPattern, raw)
+	}
+	if parsed.Host == "" || (parsed.Path != "" && parsed.Path != "/") || parsed.RawQuery != "" || parsed.Fragment != "" || parsed.User != nil {
+		return pattern{}, fmt.Errorf("%w %q: expected scheme://host[:port]", ErrInvalidPattern, raw)
+	}
+
+	host := strings.ToLower(parsed.Hostname())
+	result := pattern{scheme: parsed.Scheme, host: host, port: parsed.Port()}
+	if strings.HasPrefix(host, "*.") {
+		result.wildcard = true
+		result.host = strings.TrimPrefix(host, "*.")
+	}
+	if result.host == "" || strings.Contains(result.host, "*") {
+		return pattern{}, fmt.Errorf("%w %q: wildcard is only allowed as the first label (*.example.com)", ErrInvalidPattern, raw)
+	}
+	if result.wildcard && !strings.Contains(result.host, ".") {
+		return pattern{}, fmt.Errorf("%w %q: wildcard requires a domain with at least two labels", ErrInvalidPattern, raw)
+	}
+	return result, nil
+}
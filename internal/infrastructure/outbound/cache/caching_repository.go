@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"bff-graphql-payment/internal/domain/model"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"golang.org/x/sync/singleflight"
+)
+
+// Settings define el tamaño y la vigencia de cada cache
+type Settings struct {
+	// PaymentInfraTTL es la vigencia de getPaymentInfraByQrValue (rack, instalación, dispositivo, tiempos)
+	PaymentInfraTTL time.Duration
+	// AvailableLockersTTL es la vigencia de getAvailableLockers (cambia con cada reserva, debe ser corta)
+	AvailableLockersTTL time.Duration
+	// MaxEntries es el máximo de entradas por operación; al superarlo se descarta la menos usada
+	MaxEntries int
+}
+
+// CachingRepository decora un PaymentInfraRepository con un cache read-through para las
+// consultas de infraestructura. Las solicitudes idénticas simultáneas comparten una sola llamada upstream.
+type CachingRepository struct {
+	ports.PaymentInfraRepository
+
+	// stores se reemplaza completo al cambiar la configuración
+	stores atomic.Pointer[stores]
+	group  singleflight.Group
+
+	// generation cambia en cada invalidación para descartar resultados obtenidos antes de ella
+	generation atomic.Uint64
+}
+
+// stores contiene un cache LRU por operación
+type stores struct {
+	paymentInfra     *expirable.LRU[string, *model.PaymentInfra]
+	availableLockers *expirable.LRU[string, *model.AvailableLockers]
+}
+
+// NewCachingRepository crea el decorador de cache sobre el repositorio indicado
+func NewCachingRepository(repo ports.PaymentInfraRepository, settings Settings) *CachingRepository {
+	r := &CachingRepository{PaymentInfraRepository: repo}
+	r.stores.Store(newStores(settings))
+	return r
+}
+
+// UpdateSettings aplica nuevas vigencias y tamaños. Las entradas actuales se descartan.
+func (r *CachingRepository) UpdateSettings(settings Settings) {
+	r.generation.Add(1)
+	r.stores.Store(newStores(settings))
+}
+
+// Purge descarta todas las entradas (p. ej. al cambiar el adaptador de Payment Manager)
+func (r *CachingRepository) Purge() {
+	r.generation.Add(1)
+	current := r.stores.Load()
+	current.paymentInfra.Purge()
+	current.availableLockers.Purge()
+}
+
+// newStores crea los caches de cada operación
+func newStores(settings Settings) *stores {
+	if settings.MaxEntries < 1 {
+		settings.MaxEntries = 1
+	}
+	return &stores{
+		paymentInfra:     expirable.NewLRU[string, *model.PaymentInfra](settings.MaxEntries, nil, settings.PaymentInfraTTL),
+		availableLockers: expirable.NewLRU[string, *model.AvailableLockers](settings.MaxEntries, nil, settings.AvailableLockersTTL),
+	}
+}
+
+// GetPaymentInfraByQrValue implementa PaymentInfraRepository.GetPaymentInfraByQrValue con cache
+func (r *CachingRepository) GetPaymentInfraByQrValue(ctx context.Context, qrValue string) (*model.PaymentInfra, error) {
+	current := r.stores.Load()
+	if cached, ok := current.paymentInfra.Get(qrValue); ok {
+		telemetry.ObserveCacheLookup("getPaymentInfraByQrValue", true)
+		return clonePaymentInfra(cached), nil
+	}
+	telemetry.ObserveCacheLookup("getPaymentInfraByQrValue", false)
+
+	result, err := load(ctx, r, "qr:"+qrValue, func(ctx context.Context) (*model.PaymentInfra, error) {
+		return r.PaymentInfraRepository.GetPaymentInfraByQrValue(ctx, qrValue)
+	}, func(value *model.PaymentInfra) {
+		current.paymentInfra.Add(qrValue, clonePaymentInfra(value))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return clonePaymentInfra(result), nil
+}
+
+// GetAvailableLockers implementa PaymentInfraRepository.GetAvailableLockers con cache
+func (r *CachingRepository) GetAvailableLockers(ctx context.Context, paymentRackID int, bookingTimeID int, traceID string) (*model.AvailableLockers, error) {
+	key := lockersKey(paymentRackID, bookingTimeID)
+
+	current := r.stores.Load()
+	if cached, ok := current.availableLockers.Get(key); ok {
+		telemetry.ObserveCacheLookup("getAvailableLockers", true)
+		copied := cloneAvailableLockers(cached)
+		copied.TraceID = traceID
+		return copied, nil
+	}
+	telemetry.ObserveCacheLookup("getAvailableLockers", false)
+
+	result, err := load(ctx, r, "lockers:"+key, func(ctx context.Context) (*model.AvailableLockers, error) {
+		return r.PaymentInfraRepository.GetAvailableLockers(ctx, paymentRackID, bookingTimeID, traceID)
+	}, func(value *model.AvailableLockers) {
+		current.availableLockers.Add(key, cloneAvailableLockers(value))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	copied := cloneAvailableLockers(result)
+	copied.TraceID = traceID
+	return copied, nil
+}
+
+// InvalidateRack elimina las entradas del rack indicado y devuelve cuántas se eliminaron
+func (r *CachingRepository) InvalidateRack(paymentRackID int) int {
+	r.generation.Add(1)
+	current := r.stores.Load()
+
+	removed := 0
+	for _, qrValue := range current.paymentInfra.Keys() {
+		value, ok := current.paymentInfra.Peek(qrValue)
+		if ok && value.PaymentRack != nil && value.PaymentRack.ID == paymentRackID {
+			if current.paymentInfra.Remove(qrValue) {
+				removed++
+			}
+		}
+	}
+
+	prefix := strconv.Itoa(paymentRackID) + ":"
+	for _, key := range current.availableLockers.Keys() {
+		if strings.HasPrefix(key, prefix) && current.availableLockers.Remove(key) {
+			removed++
+		}
+	}
+
+	return removed
+}
+
+// load ejecuta fetch una sola vez por clave entre solicitudes simultáneas y guarda el resultado
+// si no hubo una invalidación mientras se obtenía. Cada solicitante espera con su propio contexto.
+func load[T any](ctx context.Context, r *CachingRepository, key string, fetch func(ctx context.Context) (*T, error), store func(*T)) (*T, error) {
+	resultChan := r.group.DoChan(key, func() (interface{}, error) {
+		generation := r.generation.Load()
+
+		// La llamada compartida no depende de la cancelación del primer solicitante;
+		// el cliente gRPC aplica su propio timeout
+		value, err := fetch(context.WithoutCancel(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if r.generation.Load() == generation {
+			store(value)
+		}
+		return value, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-resultChan:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		value, ok := result.Val.(*T)
+		if !ok || value == nil {
+			return nil, fmt.Errorf("unexpected cached value for %s", key)
+		}
+		return value, nil
+	}
+}
+
+// clonePaymentInfra copia el valor y sus miembros: ni el cache ni los solicitantes que comparten
+// una llamada deben ver las modificaciones de otro solicitante
+func clonePaymentInfra(value *model.PaymentInfra) *model.PaymentInfra {
+	copied := *value
+	if value.PaymentRack != nil {
+		rack := *value.PaymentRack
+		copied.PaymentRack = &rack
+	}
+	if value.Installation != nil {
+		installation := *value.Installation
+		copied.Installation = &installation
+	}
+	if value.Device != nil {
+		device := *value.Device
+		copied.Device = &device
+	}
+	copied.BookingTimes = slices.Clone(value.BookingTimes)
+	return &copied
+}
+
+// cloneAvailableLockers copia el valor y sus grupos
+func cloneAvailableLockers(value *model.AvailableLockers) *model.AvailableLockers {
+	copied := *value
+	copied.AvailableGroups = slices.Clone(value.AvailableGroups)
+	return &copied
+}
+
+// lockersKey arma la clave de cache de lockers disponibles (prefijo por rack para invalidar)
+func lockersKey(paymentRackID int, bookingTimeID int) string {
+	return strconv.Itoa(paymentRackID) + ":" + strconv.Itoa(bookingTimeID)
+}
+
+// Asegurar que CachingRepository implementa PaymentInfraRepository
+var _ ports.PaymentInfraRepository = (*CachingRepository)(nil)
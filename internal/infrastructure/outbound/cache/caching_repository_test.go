@@ -0,0 +1,204 @@
+package cache
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"bff-graphql-payment/internal/domain/model"
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRepository responde las consultas de infraestructura y cuenta las llamadas.
+// Si release no es nil, cada llamada avisa en started y espera a release.
+type fakeRepository struct {
+	ports.PaymentInfraRepository
+
+	calls   atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func (f *fakeRepository) GetPaymentInfraByQrValue(ctx context.Context, qrValue string) (*model.PaymentInfra, error) {
+	f.calls.Add(1)
+	if f.release != nil {
+		f.started <- struct{}{}
+		<-f.release
+	}
+	return &model.PaymentInfra{
+		TransactionID: "tx-" + qrValue,
+		PaymentRack:   &model.PaymentRack{ID: 7, Description: "Rack"},
+		Device:        &model.PaymentDevice{Name: "DEV-1", Online: true},
+		BookingTimes:  []model.PaymentBookingTime{{ID: 1, Name: "1 día"}},
+	}, nil
+}
+
+func (f *fakeRepository) GetAvailableLockers(ctx context.Context, paymentRackID int, bookingTimeID int, traceID string) (*model.AvailableLockers, error) {
+	f.calls.Add(1)
+	return &model.AvailableLockers{
+		TraceID:         traceID,
+		AvailableGroups: []model.AvailablePaymentGroup{{GroupID: 1, Price: 2000}},
+	}, nil
+}
+
+func newTestCache(repo *fakeRepository) *CachingRepository {
+	return NewCachingRepository(repo, Settings{
+		PaymentInfraTTL:     time.Minute,
+		AvailableLockersTTL: time.Minute,
+		MaxEntries:          10,
+	})
+}
+
+func TestCacheHit(t *testing.T) {
+	repo := &fakeRepository{}
+	c := newTestCache(repo)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetPaymentInfraByQrValue(context.Background(), "QR-1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if calls := repo.calls.Load(); calls != 1 {
+		t.Errorf("expected 1 upstream call, got %d", calls)
+	}
+}
+
+func TestCachedValuesAreDeepCopies(t *testing.T) {
+	repo := &fakeRepository{}
+	c := newTestCache(repo)
+	ctx := context.Background()
+
+	first, err := c.GetPaymentInfraByQrValue(ctx, "QR-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first.PaymentRack.Description = "modified"
+	first.Device.Online = false
+	first.BookingTimes[0].Name = "modified"
+
+	second, err := c.GetPaymentInfraByQrValue(ctx, "QR-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.PaymentRack.Description != "Rack" || !second.Device.Online || second.BookingTimes[0].Name != "1 día" {
+		t.Errorf("cached value was modified through a previous result: %+v", second)
+	}
+
+	lockers, err := c.GetAvailableLockers(ctx, 7, 1, "trace-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockers.AvailableGroups[0].Price = 0
+
+	lockers, err = c.GetAvailableLockers(ctx, 7, 1, "trace-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lockers.AvailableGroups[0].Price != 2000 || lockers.TraceID != "trace-2" {
+		t.Errorf("unexpected cached lockers: %+v", lockers)
+	}
+}
+
+func TestInvalidateRack(t *testing.T) {
+	repo := &fakeRepository{}
+	c := newTestCache(repo)
+	ctx := context.Background()
+
+	if _, err := c.GetPaymentInfraByQrValue(ctx, "QR-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetAvailableLockers(ctx, 7, 1, "t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetAvailableLockers(ctx, 8, 1, "t"); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed := c.InvalidateRack(7); removed != 2 {
+		t.Errorf("expected 2 entries removed, got %d", removed)
+	}
+
+	if _, err := c.GetAvailableLockers(ctx, 8, 1, "t"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetPaymentInfraByQrValue(ctx, "QR-1"); err != nil {
+		t.Fatal(err)
+	}
+	// 3 llamadas iniciales + QR-1 de nuevo; el rack 8 sigue en cache
+	if calls := repo.calls.Load(); calls != 4 {
+		t.Errorf("expected 4 upstream calls, got %d", calls)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	repo := &fakeRepository{}
+	c := newTestCache(repo)
+	ctx := context.Background()
+
+	if _, err := c.GetPaymentInfraByQrValue(ctx, "QR-1"); err != nil {
+		t.Fatal(err)
+	}
+	c.Purge()
+	if _, err := c.GetPaymentInfraByQrValue(ctx, "QR-1"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := repo.calls.Load(); calls != 2 {
+		t.Errorf("expected 2 upstream calls, got %d", calls)
+	}
+}
+
+func TestInvalidationDiscardsInFlightLoad(t *testing.T) {
+	repo := &fakeRepository{started: make(chan struct{}), release: make(chan struct{})}
+	c := newTestCache(repo)
+	ctx := context.Background()
+
+	done := make(chan error)
+	go func() {
+		_, err := c.GetPaymentInfraByQrValue(ctx, "QR-1")
+		done <- err
+	}()
+
+	// La invalidación llega mientras la llamada upstream está en curso: el resultado se entrega
+	// al solicitante pero no se guarda, porque puede ser anterior a la invalidación
+	<-repo.started
+	c.InvalidateRack(7)
+	close(repo.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	repo.release = nil
+	if _, err := c.GetPaymentInfraByQrValue(ctx, "QR-1"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := repo.calls.Load(); calls != 2 {
+		t.Errorf("expected the stale result to be discarded (2 upstream calls), got %d", calls)
+	}
+}
+
+func TestConcurrentMissesShareOneCall(t *testing.T) {
+	repo := &fakeRepository{started: make(chan struct{}, 10), release: make(chan struct{})}
+	c := newTestCache(repo)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.GetPaymentInfraByQrValue(context.Background(), "QR-1"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	<-repo.started
+	// Dar tiempo a que el resto de los solicitantes se sumen a la llamada en curso
+	time.Sleep(50 * time.Millisecond)
+	close(repo.release)
+	wg.Wait()
+
+	if calls := repo.calls.Load(); calls != 1 {
+		t.Errorf("expected 1 shared upstream call, got %d", calls)
+	}
+}
@@ -0,0 +1,297 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CircuitState representa el estado de un circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "CLOSED"
+	CircuitOpen     CircuitState = "OPEN"
+	CircuitHalfOpen CircuitState = "HALF_OPEN"
+)
+
+// CircuitBreakerSettings define los umbrales de un circuit breaker
+type CircuitBreakerSettings struct {
+	// Enabled activa o desactiva el circuit breaker
+	Enabled bool
+	// FailureThreshold es el número de fallas consecutivas que abren el circuito
+	FailureThreshold int
+	// OpenTimeout es el tiempo que el circuito permanece abierto antes de pasar a half-open
+	OpenTimeout time.Duration
+	// HalfOpenMaxRequests es el número de llamadas de prueba permitidas en half-open;
+	// si todas resultan exitosas el circuito se cierra
+	HalfOpenMaxRequests int
+}
+
+// DefaultCircuitBreakerSettings devuelve la configuración por defecto del circuit breaker
+func DefaultCircuitBreakerSettings() CircuitBreakerSettings {
+	return CircuitBreakerSettings{
+		Enabled:             true,
+		FailureThreshold:    5,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenMaxRequests: 1,
+	}
+}
+
+// CircuitBreakerSnapshot es una vista de solo lectura del estado de un circuit breaker
+type CircuitBreakerSnapshot struct {
+	Name                string       `json:"name"`
+	Enabled             bool         `json:"enabled"`
+	State               CircuitState `json:"state"`
+	ConsecutiveFailures int          `json:"consecutiveFailures"`
+	TotalFailures       int64        `json:"totalFailures"`
+	TotalRejected       int64        `json:"totalRejected"`
+	LastStateChange     time.Time    `json:"lastStateChange"`
+	OpenUntil           *time.Time   `json:"openUntil,omitempty"`
+}
+
+// ErrCircuitOpen se devuelve cuando el circuito está abierto y la llamada se rechaza sin contactar al upstream
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitBreaker protege una conexión gRPC contra un upstream caído
+type CircuitBreaker struct {
+	name     string
+	settings CircuitBreakerSettings
+	logger   *slog.Logger
+
+	mu                  sync.Mutex
+	state               CircuitState
+	consecutiveFailures int
+	halfOpenInFlight    int
+	halfOpenSuccesses   int
+	totalFailures       int64
+	totalRejected       int64
+	lastStateChange     time.Time
+	openedAt            time.Time
+}
+
+// NewCircuitBreaker crea un nuevo circuit breaker para el upstream indicado
+func NewCircuitBreaker(name string, settings CircuitBreakerSettings, logger *slog.Logger) *CircuitBreaker {
+	if settings.FailureThreshold < 1 {
+		settings.FailureThreshold = 1
+	}
+	if settings.HalfOpenMaxRequests < 1 {
+		settings.HalfOpenMaxRequests = 1
+	}
+	return &CircuitBreaker{
+		name:            name,
+		settings:        settings,
+		logger:          logger.With("upstream", name),
+		state:           CircuitClosed,
+		lastStateChange: time.Now(),
+	}
+}
+
+// allow decide si una llamada puede pasar. Devuelve una función para registrar el resultado.
+func (b *CircuitBreaker) allow() (func(err error), error) {
+	if !b.settings.Enabled {
+		return func(error) {}, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.settings.OpenTimeout {
+		b.setState(CircuitHalfOpen)
+	}
+
+	switch b.state {
+	case CircuitOpen:
+		b.totalRejected++
+		return nil, fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+	case CircuitHalfOpen:
+		if b.halfOpenInFlight >= b.settings.HalfOpenMaxRequests {
+			b.totalRejected++
+			return nil, fmt.Errorf("%s: %w", b.name, ErrCircuitOpen)
+		}
+		b.halfOpenInFlight++
+		return b.record(true), nil
+	default:
+		return b.record(false), nil
+	}
+}
+
+// record devuelve la función que registra el resultado de una llamada autorizada
+func (b *CircuitBreaker) record(probe bool) func(err error) {
+	return func(err error) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if probe {
+			b.halfOpenInFlight--
+		}
+
+		// Una llamada cancelada por el llamador no dice nada del upstream: solo libera el cupo de prueba
+		if isBreakerNeutral(err) {
+			return
+		}
+
+		if isBreakerFailure(err) {
+			b.totalFailures++
+			b.consecutiveFailures++
+			if b.state == CircuitHalfOpen || b.consecutiveFailures >= b.settings.FailureThreshold {
+				b.setState(CircuitOpen)
+			}
+			return
+		}
+
+		b.consecutiveFailures = 0
+		if b.state == CircuitHalfOpen {
+			b.halfOpenSuccesses++
+			if b.halfOpenSuccesses >= b.settings.HalfOpenMaxRequests {
+				b.setState(CircuitClosed)
+			}
+		}
+	}
+}
+
+// setState cambia el estado del circuito (requiere b.mu tomado)
+func (b *CircuitBreaker) setState(state CircuitState) {
+	if b.state == state {
+		return
+	}
+	level := slog.LevelInfo
+	if state == CircuitOpen {
+		level = slog.LevelWarn
+	}
+	b.logger.Log(context.Background(), level, "circuit breaker state changed", "from", b.state, "to", state, "consecutiveFailures", b.consecutiveFailures)
+	b.state = state
+	b.lastStateChange = time.Now()
+	b.halfOpenSuccesses = 0
+	b.halfOpenInFlight = 0
+	if state == CircuitOpen {
+		b.openedAt = b.lastStateChange
+	}
+	if state == CircuitClosed {
+		b.consecutiveFailures = 0
+	}
+}
+
+// Snapshot devuelve el estado actual del circuit breaker
+func (b *CircuitBreaker) Snapshot() CircuitBreakerSnapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snapshot := CircuitBreakerSnapshot{
+		Name:                b.name,
+		Enabled:             b.settings.Enabled,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		TotalFailures:       b.totalFailures,
+		TotalRejected:       b.totalRejected,
+		LastStateChange:     b.lastStateChange,
+	}
+	if b.state == CircuitOpen {
+		openUntil := b.openedAt.Add(b.settings.OpenTimeout)
+		snapshot.OpenUntil = &openUntil
+	}
+	return snapshot
+}
+
+// UnaryClientInterceptor rechaza llamadas unarias mientras el circuito está abierto
+func (b *CircuitBreaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		done, err := b.allow()
+		if err != nil {
+			return err
+		}
+		err = invoker(ctx, method, req, reply, cc, opts...)
+		done(err)
+		return err
+	}
+}
+
+// StreamClientInterceptor rechaza la apertura de streams mientras el circuito está abierto.
+// El resultado se registra con el error final del stream, no al crearlo: un stream que
+// falla con Unavailable a mitad de ExecuteOpen cuenta como falla del upstream. Un stream
+// cancelado es neutral, salvo que la causa de la cancelación sea un plazo vencido.
+func (b *CircuitBreaker) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		done, err := b.allow()
+		if err != nil {
+			return nil, err
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			done(err)
+			return nil, err
+		}
+
+		breakerStream := &breakerClientStream{ClientStream: stream, ctx: ctx, done: done}
+		// Si el llamador abandona el stream sin leer el error final, el resultado se registra al
+		// cancelar su contexto para no dejar ocupado el cupo de prueba de half-open
+		context.AfterFunc(ctx, func() { breakerStream.finish(cancelledStreamResult(ctx)) })
+		return breakerStream, nil
+	}
+}
+
+// breakerClientStream registra en el circuit breaker el resultado final del stream
+type breakerClientStream struct {
+	grpc.ClientStream
+	ctx  context.Context
+	done func(err error)
+	once sync.Once
+}
+
+// RecvMsg implementa grpc.ClientStream
+func (s *breakerClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		switch {
+		case errors.Is(err, io.EOF):
+			s.finish(nil)
+		case status.Code(err) == codes.Canceled && s.ctx.Err() != nil:
+			s.finish(cancelledStreamResult(s.ctx))
+		default:
+			s.finish(err)
+		}
+	}
+	return err
+}
+
+// finish registra el resultado una sola vez
+func (s *breakerClientStream) finish(err error) {
+	s.once.Do(func() { s.done(err) })
+}
+
+// cancelledStreamResult es el resultado de un stream cortado por su contexto. Si la causa envuelve
+// context.DeadlineExceeded (p. ej. el watchdog de ExecuteOpen) el upstream no respondió a tiempo y
+// cuenta como falla; cualquier otra cancelación es neutral.
+func cancelledStreamResult(ctx context.Context) error {
+	if cause := context.Cause(ctx); errors.Is(cause, context.DeadlineExceeded) {
+		return status.Error(codes.DeadlineExceeded, cause.Error())
+	}
+	return status.Error(codes.Canceled, context.Canceled.Error())
+}
+
+// isBreakerNeutral indica si la llamada terminó por una cancelación del llamador
+func isBreakerNeutral(err error) bool {
+	return status.Code(err) == codes.Canceled || errors.Is(err, context.Canceled)
+}
+
+// isBreakerFailure indica si el error refleja un upstream no saludable.
+// Los errores de negocio (NotFound, InvalidArgument, etc.) no cuentan como falla.
+func isBreakerFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
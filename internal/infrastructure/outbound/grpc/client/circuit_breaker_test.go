@@ -0,0 +1,269 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	errUnavailable = status.Error(codes.Unavailable, "down")
+	discardLogger  = slog.New(slog.DiscardHandler)
+)
+
+// call pasa una llamada por el breaker y registra el resultado indicado
+func call(b *CircuitBreaker, result error) error {
+	done, err := b.allow()
+	if err != nil {
+		return err
+	}
+	done(result)
+	return nil
+}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker("payment", CircuitBreakerSettings{Enabled: true, FailureThreshold: 3, OpenTimeout: time.Minute}, discardLogger)
+
+	for i := 0; i < 2; i++ {
+		if err := call(b, errUnavailable); err != nil {
+			t.Fatalf("call %d rejected: %v", i, err)
+		}
+	}
+	if state := b.Snapshot().State; state != CircuitClosed {
+		t.Fatalf("expected CLOSED below the threshold, got %s", state)
+	}
+
+	if err := call(b, errUnavailable); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := b.Snapshot()
+	if snapshot.State != CircuitOpen || snapshot.OpenUntil == nil {
+		t.Fatalf("expected OPEN with openUntil, got %+v", snapshot)
+	}
+
+	if err := call(b, nil); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("expected ErrCircuitOpen, got %v", err)
+	}
+	if rejected := b.Snapshot().TotalRejected; rejected != 1 {
+		t.Errorf("expected 1 rejected call, got %d", rejected)
+	}
+}
+
+func TestCircuitBreakerIgnoresBusinessErrors(t *testing.T) {
+	b := NewCircuitBreaker("payment", CircuitBreakerSettings{Enabled: true, FailureThreshold: 2, OpenTimeout: time.Minute}, discardLogger)
+
+	for _, err := range []error{
+		errUnavailable,
+		status.Error(codes.NotFound, "no rack"),
+		errUnavailable,
+		status.Error(codes.InvalidArgument, "bad"),
+	} {
+		if rejected := call(b, err); rejected != nil {
+			t.Fatal(rejected)
+		}
+	}
+	if state := b.Snapshot().State; state != CircuitClosed {
+		t.Errorf("business errors reset the failure count; expected CLOSED, got %s", state)
+	}
+}
+
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	tests := []struct {
+		name      string
+		probe     error
+		wantState CircuitState
+	}{
+		{name: "successful probe closes the circuit", probe: nil, wantState: CircuitClosed},
+		{name: "failed probe reopens the circuit", probe: errUnavailable, wantState: CircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("booking", CircuitBreakerSettings{
+				Enabled:             true,
+				FailureThreshold:    1,
+				OpenTimeout:         20 * time.Millisecond,
+				HalfOpenMaxRequests: 1,
+			}, discardLogger)
+			if err := call(b, errUnavailable); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(30 * time.Millisecond)
+
+			done, err := b.allow()
+			if err != nil {
+				t.Fatalf("probe rejected after the open timeout: %v", err)
+			}
+			if state := b.Snapshot().State; state != CircuitHalfOpen {
+				t.Fatalf("expected HALF_OPEN, got %s", state)
+			}
+
+			// Solo se permite una llamada de prueba a la vez
+			if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+				t.Errorf("expected a second probe to be rejected, got %v", err)
+			}
+
+			done(tt.probe)
+			if state := b.Snapshot().State; state != tt.wantState {
+				t.Errorf("expected %s, got %s", tt.wantState, state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	b := NewCircuitBreaker("payment", CircuitBreakerSettings{Enabled: false, FailureThreshold: 1}, discardLogger)
+	for i := 0; i < 5; i++ {
+		if err := call(b, errUnavailable); err != nil {
+			t.Fatalf("disabled breaker rejected call %d: %v", i, err)
+		}
+	}
+}
+
+// fakeClientStream termina con err en la primera lectura
+type fakeClientStream struct {
+	grpc.ClientStream
+	err error
+}
+
+func (s *fakeClientStream) RecvMsg(m interface{}) error {
+	return s.err
+}
+
+func TestCircuitBreakerStreamRecordsFinalError(t *testing.T) {
+	tests := []struct {
+		name      string
+		recvErr   error
+		wantState CircuitState
+	}{
+		{name: "stream fails after being established", recvErr: errUnavailable, wantState: CircuitOpen},
+		{name: "stream ends normally", recvErr: io.EOF, wantState: CircuitClosed},
+		{name: "business error", recvErr: status.Error(codes.NotFound, "no booking"), wantState: CircuitClosed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("booking", CircuitBreakerSettings{Enabled: true, FailureThreshold: 1, OpenTimeout: time.Minute}, discardLogger)
+			interceptor := b.StreamClientInterceptor()
+			streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{err: tt.recvErr}, nil
+			}
+
+			stream, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, "/booking/ExecuteOpen", streamer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if state := b.Snapshot().State; state != CircuitClosed {
+				t.Fatalf("result recorded before the stream finished: %s", state)
+			}
+
+			_ = stream.RecvMsg(nil)
+			if state := b.Snapshot().State; state != tt.wantState {
+				t.Errorf("expected %s, got %s", tt.wantState, state)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerCancelledStream(t *testing.T) {
+	errPhaseTimeout := fmt.Errorf("open phase timed out: %w", context.DeadlineExceeded)
+
+	tests := []struct {
+		name  string
+		cause error
+		// recv lee el error final del stream en vez de abandonarlo
+		recv      bool
+		wantState CircuitState
+	}{
+		{name: "abandoned by the caller", wantState: CircuitHalfOpen},
+		{name: "cancelled by the caller while reading", recv: true, wantState: CircuitHalfOpen},
+		{name: "abandoned after a deadline cut", cause: errPhaseTimeout, wantState: CircuitOpen},
+		{name: "deadline cut while reading", cause: errPhaseTimeout, recv: true, wantState: CircuitOpen},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewCircuitBreaker("booking", CircuitBreakerSettings{
+				Enabled:             true,
+				FailureThreshold:    1,
+				OpenTimeout:         10 * time.Millisecond,
+				HalfOpenMaxRequests: 1,
+			}, discardLogger)
+			if err := call(b, errUnavailable); err != nil {
+				t.Fatal(err)
+			}
+			time.Sleep(20 * time.Millisecond)
+
+			ctx, cancel := context.WithCancelCause(context.Background())
+			streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return &fakeClientStream{err: status.Error(codes.Canceled, "context canceled")}, nil
+			}
+			stream, err := b.StreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/booking/ExecuteOpen", streamer)
+			if err != nil {
+				t.Fatal(err)
+			}
+			failures := b.Snapshot().TotalFailures
+
+			cancel(tt.cause)
+			if tt.recv {
+				_ = stream.RecvMsg(nil)
+			}
+
+			// El resultado se registra una sola vez, por la lectura o por el AfterFunc del contexto
+			deadline := time.Now().Add(time.Second)
+			for {
+				b.mu.Lock()
+				released := b.halfOpenInFlight == 0
+				b.mu.Unlock()
+				if released || b.Snapshot().State != CircuitHalfOpen {
+					break
+				}
+				if time.Now().After(deadline) {
+					t.Fatal("probe never released")
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			snapshot := b.Snapshot()
+			if snapshot.State != tt.wantState {
+				t.Errorf("expected %s, got %s", tt.wantState, snapshot.State)
+			}
+			if tt.wantState == CircuitHalfOpen {
+				if snapshot.TotalFailures != failures {
+					t.Errorf("a cancelled stream must not count as a failure, got %d failures", snapshot.TotalFailures)
+				}
+				// El cupo de prueba quedó libre para la siguiente llamada
+				if err := call(b, nil); err != nil {
+					t.Errorf("expected the next probe to be allowed, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerIgnoresCancelledCalls(t *testing.T) {
+	b := NewCircuitBreaker("payment", CircuitBreakerSettings{Enabled: true, FailureThreshold: 2, OpenTimeout: time.Minute}, discardLogger)
+
+	for _, err := range []error{
+		errUnavailable,
+		status.Error(codes.Canceled, "context canceled"),
+		context.Canceled,
+		errUnavailable,
+	} {
+		if rejected := call(b, err); rejected != nil {
+			t.Fatal(rejected)
+		}
+	}
+	// Las cancelaciones no reinician el conteo de fallas consecutivas
+	if state := b.Snapshot().State; state != CircuitOpen {
+		t.Errorf("expected OPEN, got %s", state)
+	}
+}
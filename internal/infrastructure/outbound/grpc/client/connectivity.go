@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// ConnectionStatus es una vista de solo lectura del estado de conectividad de un upstream
+type ConnectionStatus struct {
+	Name            string    `json:"name"`
+	Address         string    `json:"address"`
+	State           string    `json:"state"`
+	Ready           bool      `json:"ready"`
+	LastStateChange time.Time `json:"lastStateChange"`
+	// LastSuccessfulCall es la última llamada respondida sin error (nil si aún no hubo ninguna)
+	LastSuccessfulCall *time.Time `json:"lastSuccessfulCall,omitempty"`
+}
+
+// connectionMonitor observa el estado de conectividad de una conexión gRPC y registra cada cambio
+type connectionMonitor struct {
+	name    string
+	address string
+	conn    *grpc.ClientConn
+	logger  *slog.Logger
+
+	calls *callTracker
+
+	mu              sync.RWMutex
+	state           connectivity.State
+	lastStateChange time.Time
+}
+
+// newConnectionMonitor crea un monitor y comienza a observar la conexión en segundo plano
+func newConnectionMonitor(name string, address string, conn *grpc.ClientConn, calls *callTracker, logger *slog.Logger) *connectionMonitor {
+	monitor := &connectionMonitor{
+		name:            name,
+		address:         address,
+		conn:            conn,
+		logger:          logger.With("upstream", name, "address", address),
+		calls:           calls,
+		state:           conn.GetState(),
+		lastStateChange: time.Now(),
+	}
+	go monitor.watch()
+	return monitor
+}
+
+// watch registra los cambios de estado hasta que la conexión se cierra
+func (m *connectionMonitor) watch() {
+	state := m.conn.GetState()
+	for {
+		m.record(state)
+
+		switch state {
+		case connectivity.Shutdown:
+			return
+		case connectivity.Idle:
+			// Mantener la conexión caliente: salir de IDLE sin esperar a la próxima llamada
+			m.conn.Connect()
+		}
+
+		if !m.conn.WaitForStateChange(context.Background(), state) {
+			return
+		}
+		state = m.conn.GetState()
+	}
+}
+
+// record guarda el nuevo estado y lo registra en el log si cambió
+func (m *connectionMonitor) record(state connectivity.State) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state == state {
+		return
+	}
+
+	level := slog.LevelInfo
+	if state == connectivity.TransientFailure {
+		level = slog.LevelWarn
+	}
+	m.logger.Log(context.Background(), level, "gRPC connection state changed", "from", m.state.String(), "to", state.String())
+
+	m.state = state
+	m.lastStateChange = time.Now()
+}
+
+// Status devuelve el estado actual de la conexión
+func (m *connectionMonitor) Status() ConnectionStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return ConnectionStatus{
+		Name:               m.name,
+		Address:            m.address,
+		State:              m.state.String(),
+		Ready:              m.state == connectivity.Ready,
+		LastStateChange:    m.lastStateChange,
+		LastSuccessfulCall: m.calls.LastSuccess(),
+	}
+}
+
+// callTracker registra la última llamada exitosa a un upstream
+type callTracker struct {
+	lastSuccess atomic.Int64 // UnixNano; 0 si aún no hubo ninguna
+}
+
+// record marca una llamada exitosa
+func (t *callTracker) record() {
+	t.lastSuccess.Store(time.Now().UnixNano())
+}
+
+// LastSuccess devuelve la hora de la última llamada exitosa o nil
+func (t *callTracker) LastSuccess() *time.Time {
+	nanos := t.lastSuccess.Load()
+	if nanos == 0 {
+		return nil
+	}
+	last := time.Unix(0, nanos)
+	return &last
+}
+
+// UnaryClientInterceptor registra las llamadas unarias exitosas
+func (t *callTracker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if err == nil {
+			t.record()
+		}
+		return err
+	}
+}
+
+// StreamClientInterceptor registra los streams que recibieron al menos un mensaje
+func (t *callTracker) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &trackedClientStream{ClientStream: stream, calls: t}, nil
+	}
+}
+
+// trackedClientStream marca la llamada como exitosa al recibir un mensaje
+type trackedClientStream struct {
+	grpc.ClientStream
+	calls *callTracker
+}
+
+// RecvMsg implementa grpc.ClientStream
+func (s *trackedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err == nil {
+		s.calls.record()
+	}
+	return err
+}
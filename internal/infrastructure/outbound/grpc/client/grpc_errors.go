@@ -0,0 +1,163 @@
+package client
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"bff-graphql-payment/internal/domain/exception"
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcOperation identifica la operación gRPC que originó un error
+type grpcOperation string
+
+const (
+	opGetPaymentInfraByQrValue grpcOperation = "GetPaymentInfraByQrValue"
+	opGetAvailableLockers      grpcOperation = "GetAvailableLockers"
+	opValidateDiscountCoupon   grpcOperation = "ValidateDiscountCoupon"
+	opGeneratePurchaseOrder    grpcOperation = "GeneratePurchaseOrder"
+	opGenerateBooking          grpcOperation = "GenerateBooking"
+	opGetPurchaseOrderByPo     grpcOperation = "GetPurchaseOrderByPo"
+	opCheckBookingStatus       grpcOperation = "CheckBookingStatus"
+	opExecuteOpen              grpcOperation = "ExecuteOpen"
+)
+
+// grpcErrorMapping define los errores de dominio de una operación para cada código gRPC relevante
+type grpcErrorMapping struct {
+	notFound           error
+	invalidArgument    error
+	failedPrecondition error
+}
+
+// grpcErrorMappings contiene la traducción de errores por operación
+var grpcErrorMappings = map[grpcOperation]grpcErrorMapping{
+	opGetPaymentInfraByQrValue: {
+		notFound:           exception.ErrPaymentRackNotFound,
+		invalidArgument:    exception.ErrInvalidPaymentRackID,
+		failedPrecondition: exception.ErrPaymentRackNotFound,
+	},
+	opGetAvailableLockers: {
+		notFound:           exception.ErrPaymentRackNotFound,
+		invalidArgument:    exception.ErrInvalidBookingTimeID,
+		failedPrecondition: exception.ErrNoLockersAvailable,
+	},
+	opValidateDiscountCoupon: {
+		notFound:           exception.ErrCouponNotFound,
+		invalidArgument:    exception.ErrInvalidCouponCode,
+		failedPrecondition: exception.ErrInvalidCoupon,
+	},
+	opGeneratePurchaseOrder: {
+		notFound:           exception.ErrPaymentRackNotFound,
+		invalidArgument:    appException.ErrValidationFailed,
+		failedPrecondition: exception.ErrPurchaseOrderFailed,
+	},
+	opGenerateBooking: {
+		notFound:           exception.ErrPaymentRackNotFound,
+		invalidArgument:    appException.ErrValidationFailed,
+		failedPrecondition: exception.ErrBookingGenerationFailed,
+	},
+	opGetPurchaseOrderByPo: {
+		notFound:           exception.ErrPurchaseOrderNotFound,
+		invalidArgument:    exception.ErrInvalidPurchaseOrder,
+		failedPrecondition: exception.ErrPurchaseOrderNotFound,
+	},
+	opCheckBookingStatus: {
+		notFound:           exception.ErrBookingNotFound,
+		invalidArgument:    exception.ErrInvalidCurrentCode,
+		failedPrecondition: exception.ErrBookingNotFound,
+	},
+	opExecuteOpen: {
+		notFound:           exception.ErrBookingNotFound,
+		invalidArgument:    exception.ErrInvalidCurrentCode,
+		failedPrecondition: exception.ErrExecuteOpenFailed,
+	},
+}
+
+// translateGRPCError traduce un error gRPC al error de dominio correspondiente a la operación.
+// Conserva el mensaje y los detalles originales solo cuando el código es atribuible a la solicitud;
+// el texto de las fallas del servicio se registra en el log del cliente pero no llega al frontend.
+func translateGRPCError(op grpcOperation, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	// El circuit breaker rechazó la llamada sin contactar al upstream
+	if errors.Is(err, ErrCircuitOpen) {
+		return &exception.UpstreamError{
+			Operation: string(op),
+			Code:      codes.Unavailable.String(),
+			Err:       exception.ErrPaymentInfraServiceUnavailable,
+		}
+	}
+
+	statusErr, ok := status.FromError(err)
+	if !ok {
+		// Errores de contexto que no vienen como estado gRPC (p. ej. deadline agotado entre reintentos)
+		statusErr = status.FromContextError(err)
+	}
+
+	upstreamErr := &exception.UpstreamError{
+		Operation: string(op),
+		Code:      statusErr.Code().String(),
+		Err:       domainErrorForCode(op, statusErr.Code()),
+	}
+	if !isClientCode(statusErr.Code()) {
+		return upstreamErr
+	}
+	upstreamErr.Message = statusErr.Message()
+
+	// Conservar los detalles del estado (BadRequest y demás)
+	for _, detail := range statusErr.Details() {
+		switch d := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, violation := range d.GetFieldViolations() {
+				upstreamErr.FieldViolations = append(upstreamErr.FieldViolations, exception.FieldViolation{
+					Field:       violation.GetField(),
+					Description: violation.GetDescription(),
+				})
+			}
+		case error:
+			upstreamErr.Details = append(upstreamErr.Details, d.Error())
+		default:
+			upstreamErr.Details = append(upstreamErr.Details, fmt.Sprintf("%v", d))
+		}
+	}
+
+	return upstreamErr
+}
+
+// domainErrorForCode selecciona el error de dominio para un código gRPC dentro de una operación
+func domainErrorForCode(op grpcOperation, code codes.Code) error {
+	mapping, ok := grpcErrorMappings[op]
+	if !ok {
+		return exception.ErrPaymentInfraServiceUnavailable
+	}
+
+	switch code {
+	case codes.NotFound:
+		return mapping.notFound
+	case codes.InvalidArgument, codes.OutOfRange:
+		return mapping.invalidArgument
+	case codes.FailedPrecondition, codes.AlreadyExists:
+		return mapping.failedPrecondition
+	case codes.DeadlineExceeded:
+		return exception.ErrPaymentInfraServiceTimeout
+	default:
+		// Unavailable, Internal, etc.
+		return exception.ErrPaymentInfraServiceUnavailable
+	}
+}
+
+// isClientCode indica si el código gRPC describe un problema de la solicitud y no del servicio,
+// es decir, si su mensaje puede mostrarse al frontend
+func isClientCode(code codes.Code) bool {
+	switch code {
+	case codes.NotFound, codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition, codes.AlreadyExists:
+		return true
+	default:
+		return false
+	}
+}
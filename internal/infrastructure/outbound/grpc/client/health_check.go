@@ -0,0 +1,39 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// HealthCheckResult es la respuesta del servicio grpc.health.v1 de un upstream
+type HealthCheckResult struct {
+	Name string `json:"name"`
+	// Status es SERVING, NOT_SERVING, UNKNOWN o el código gRPC si la consulta falló (p. ej. Unimplemented)
+	Status  string `json:"status"`
+	Serving bool   `json:"serving"`
+	Error   string `json:"error,omitempty"`
+}
+
+// checkHealth ejecuta grpc.health.v1.Health/Check sobre la conexión (servicio vacío = servidor completo)
+func checkHealth(ctx context.Context, name string, conn *grpc.ClientConn, timeout time.Duration) HealthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		return HealthCheckResult{
+			Name:   name,
+			Status: status.Code(err).String(),
+			Error:  err.Error(),
+		}
+	}
+	return HealthCheckResult{
+		Name:    name,
+		Status:  response.GetStatus().String(),
+		Serving: response.GetStatus() == healthpb.HealthCheckResponse_SERVING,
+	}
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"context"
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Claves de metadata gRPC enviadas a Payment Manager y Booking Manager
+const (
+	metadataTraceID   = "x-trace-id"
+	metadataRequestID = "x-request-id"
+	metadataClientIP  = "x-client-ip"
+	metadataUserAgent = "x-client-user-agent"
+	metadataOrigin    = "x-frontend-origin"
+)
+
+// maxMetadataValueLength acota cada valor propagado (el User-Agent y el traceId los define el cliente)
+const maxMetadataValueLength = 256
+
+// outgoingContext agrega la metadata de la solicitud GraphQL como headers gRPC.
+// Si el contexto no trae trace ID o request ID se generan para poder correlacionar los logs
+// (withRetry los genera antes del primer intento para que los reintentos compartan los mismos).
+// Los valores se limpian con metadataValue: gRPC rechaza los que no son ASCII imprimible.
+func outgoingContext(ctx context.Context) context.Context {
+	requestMetadata, _ := requestmeta.FromContext(requestmeta.EnsureIDs(ctx))
+
+	pairs := []string{
+		metadataTraceID, metadataValue(requestMetadata.TraceID),
+		metadataRequestID, metadataValue(requestMetadata.RequestID),
+	}
+	if requestMetadata.ClientIP != "" {
+		pairs = append(pairs, metadataClientIP, metadataValue(requestMetadata.ClientIP))
+	}
+	if requestMetadata.UserAgent != "" {
+		pairs = append(pairs, metadataUserAgent, metadataValue(requestMetadata.UserAgent))
+	}
+	if requestMetadata.Origin != "" {
+		pairs = append(pairs, metadataOrigin, metadataValue(requestMetadata.Origin))
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// metadataValue codifica con %XX los bytes fuera de ASCII imprimible (y el propio "%") y corta el
+// resultado en maxMetadataValueLength sin partir una secuencia codificada
+func metadataValue(value string) string {
+	var encoded strings.Builder
+	for i := 0; i < len(value); i++ {
+		b := value[i]
+		escape := b < 0x20 || b > 0x7e || b == '%'
+		size := 1
+		if escape {
+			size = 3
+		}
+		if encoded.Len()+size > maxMetadataValueLength {
+			break
+		}
+		if escape {
+			fmt.Fprintf(&encoded, "%%%02X", b)
+		} else {
+			encoded.WriteByte(b)
+		}
+	}
+	return encoded.String()
+}
+
+// metadataUnaryInterceptor propaga la metadata de la solicitud en llamadas unarias
+func metadataUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingContext(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// metadataStreamInterceptor propaga la metadata de la solicitud en streams (ExecuteOpen)
+func metadataStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingContext(ctx), desc, cc, method, opts...)
+	}
+}
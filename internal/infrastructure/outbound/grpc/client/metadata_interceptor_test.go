@@ -0,0 +1,83 @@
+package client
+
+import (
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"context"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestMetadataValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "printable ASCII", value: "Mozilla/5.0 (X11; Linux)", want: "Mozilla/5.0 (X11; Linux)"},
+		{name: "non-ASCII", value: "traza-ñ", want: "traza-%C3%B1"},
+		{name: "control characters", value: "a\r\nb\x00", want: "a%0D%0Ab%00"},
+		{name: "percent sign", value: "100%", want: "100%25"},
+		{name: "length cap", value: strings.Repeat("a", 300), want: strings.Repeat("a", maxMetadataValueLength)},
+		{name: "cap does not split an escape", value: strings.Repeat("a", maxMetadataValueLength-2) + "ñ", want: strings.Repeat("a", maxMetadataValueLength-2)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := metadataValue(tt.value); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestOutgoingContextSanitizesValues(t *testing.T) {
+	ctx := requestmeta.WithMetadata(context.Background(), requestmeta.Metadata{
+		TraceID:   "traza-ñ",
+		RequestID: "req-1",
+		ClientIP:  "203.0.113.7",
+		UserAgent: "Navegador/1.0 (España)",
+		Origin:    "https://tienda.example",
+	})
+
+	outgoing, _ := metadata.FromOutgoingContext(outgoingContext(ctx))
+	for key, values := range outgoing {
+		for _, value := range values {
+			for i := 0; i < len(value); i++ {
+				if value[i] < 0x20 || value[i] > 0x7e {
+					t.Errorf("%s: non printable byte in %q", key, value)
+					break
+				}
+			}
+		}
+	}
+	if got := outgoing.Get(metadataTraceID); len(got) != 1 || got[0] != "traza-%C3%B1" {
+		t.Errorf("unexpected trace id %v", got)
+	}
+	if got := outgoing.Get(metadataUserAgent); len(got) != 1 || got[0] != "Navegador/1.0 (Espa%C3%B1a)" {
+		t.Errorf("unexpected user agent %v", got)
+	}
+}
+
+func TestWithRetrySharesGeneratedTraceID(t *testing.T) {
+	c := newRetryClient(RetryPolicy{MaxAttempts: 3, RetryableCodes: []codes.Code{codes.Unavailable}})
+
+	var traceIDs []string
+	_ = c.withRetry(context.Background(), opGetPaymentInfraByQrValue, func(ctx context.Context) error {
+		outgoing, _ := metadata.FromOutgoingContext(outgoingContext(ctx))
+		traceIDs = append(traceIDs, outgoing.Get(metadataTraceID)...)
+		return status.Error(codes.Unavailable, "down")
+	})
+
+	if len(traceIDs) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(traceIDs))
+	}
+	for _, traceID := range traceIDs {
+		if traceID == "" || traceID != traceIDs[0] {
+			t.Fatalf("expected every attempt to share one generated trace id, got %v", traceIDs)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// metricsUnaryInterceptor registra método, código de estado y latencia de cada llamada unaria
+func metricsUnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		telemetry.ObserveGRPCCall(method, status.Code(err).String(), time.Since(start))
+		return err
+	}
+}
+
+// metricsStreamInterceptor registra método, código de estado final y duración de cada stream
+func metricsStreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			telemetry.ObserveGRPCCall(method, status.Code(err).String(), time.Since(start))
+			return nil, err
+		}
+		return &metricsClientStream{ClientStream: stream, method: method, start: start}, nil
+	}
+}
+
+// metricsClientStream registra el resultado del stream cuando RecvMsg devuelve el error final
+type metricsClientStream struct {
+	grpc.ClientStream
+	method string
+	start  time.Time
+	once   sync.Once
+}
+
+// RecvMsg implementa grpc.ClientStream
+func (s *metricsClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		code := codes.OK
+		if !errors.Is(err, io.EOF) {
+			code = status.Code(err)
+		}
+		s.once.Do(func() {
+			telemetry.ObserveGRPCCall(s.method, code.String(), time.Since(s.start))
+		})
+	}
+	return err
+}
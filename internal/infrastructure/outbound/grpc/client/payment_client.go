@@ -9,77 +9,141 @@ import (
 	"bff-graphql-payment/internal/infrastructure/outbound/grpc/dto"
 	"bff-graphql-payment/internal/infrastructure/outbound/grpc/mapper"
 	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
-	"google.golang.org/grpc/status"
+)
+
+// Upstreams a los que se conecta el cliente (se usan en los logs, métricas y diagnósticos)
+const (
+	UpstreamPayment = "payment"
+	UpstreamBooking = "booking"
 )
 
 // PaymentServiceGRPCClient implementa PaymentInfraRepository usando gRPC
 type PaymentServiceGRPCClient struct {
-	conn          *grpc.ClientConn
-	bookingConn   *grpc.ClientConn
-	grpcClient    paymentpb.PaymentServiceClient
-	bookingClient bookingpb.BookingServiceClient
-	mapper        *mapper.PaymentInfraGRPCMapper
-	timeout       time.Duration
-	useMock       bool // Flag para determinar si usar mocks o cliente real
+	conn           *grpc.ClientConn
+	bookingConn    *grpc.ClientConn
+	grpcClient     paymentpb.PaymentServiceClient
+	bookingClient  bookingpb.BookingServiceClient
+	mapper         *mapper.PaymentInfraGRPCMapper
+	timeout        time.Duration // Timeout de las llamadas a Payment Manager
+	bookingTimeout time.Duration // Timeout de las llamadas unarias a Booking Manager
+	retryPolicy    RetryPolicy   // Reintentos para operaciones de solo lectura
+	logger         *slog.Logger
+
+	// Circuit breakers por upstream (payment y booking usan conexiones separadas)
+	paymentBreaker *CircuitBreaker
+	bookingBreaker *CircuitBreaker
+
+	// Última llamada exitosa por upstream
+	paymentCalls callTracker
+	bookingCalls callTracker
+
+	// Monitores de conectividad por upstream (nil mientras no se conecte)
+	paymentMonitor *connectionMonitor
+	bookingMonitor *connectionMonitor
+
+	// Datos para crear las conexiones cuando un upstream pasa a usar este adaptador
+	paymentAddress string
+	bookingAddress string
+	tlsSettings    TLSSettings
+	dialOptions    []grpc.DialOption
+	connectMu      sync.Mutex
+}
+
+// NewPaymentServiceGRPCClient crea un nuevo cliente gRPC para el servicio de pagos.
+// No abre conexiones: cada upstream se conecta con Connect solo si usa este adaptador.
+func NewPaymentServiceGRPCClient(paymentAddress string, bookingAddress string, timeout time.Duration, bookingTimeout time.Duration, retryPolicy RetryPolicy, breakerSettings CircuitBreakerSettings, tlsSettings TLSSettings, logger *slog.Logger) *PaymentServiceGRPCClient {
+	logger = logger.With("component", "grpc-client")
+	client := &PaymentServiceGRPCClient{
+		mapper:         mapper.NewPaymentInfraGRPCMapper(),
+		timeout:        timeout,
+		bookingTimeout: bookingTimeout,
+		retryPolicy:    retryPolicy,
+		logger:         logger,
+
+		paymentBreaker: NewCircuitBreaker(UpstreamPayment, breakerSettings, logger),
+		bookingBreaker: NewCircuitBreaker(UpstreamBooking, breakerSettings, logger),
+
+		paymentAddress: paymentAddress,
+		bookingAddress: bookingAddress,
+		tlsSettings:    tlsSettings,
+	}
+	return client
 }
 
-// NewPaymentServiceGRPCClient crea un nuevo cliente gRPC para el servicio de pagos
-func NewPaymentServiceGRPCClient(paymentAddress string, bookingAddress string, timeout time.Duration, useMock bool) (*PaymentServiceGRPCClient, error) {
-	var conn *grpc.ClientConn
-	var bookingConn *grpc.ClientConn
-	var grpcClient paymentpb.PaymentServiceClient
-	var bookingClient bookingpb.BookingServiceClient
-	var err error
-
-	// Solo intentar conectar si NO estamos usando mocks
-	if !useMock {
-		log.Printf("🔌 Connecting to Payment Service at %s (Real API)", paymentAddress)
-		conn, err = grpc.Dial(
-			paymentAddress,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithBlock(),
-			grpc.WithTimeout(timeout),
-		)
+// SetDialOptions agrega opciones a las conexiones que se creen después (p. ej. el dialer en memoria
+// de los tests de integración). No afecta a los upstreams ya conectados.
+func (c *PaymentServiceGRPCClient) SetDialOptions(options ...grpc.DialOption) {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+	c.dialOptions = append(c.dialOptions, options...)
+}
+
+// Connect crea la conexión al upstream indicado (UpstreamPayment o UpstreamBooking) si todavía no existe.
+// La conexión se inicia sin bloquear: el BFF arranca aunque el upstream no esté disponible
+// y su estado se monitorea en segundo plano.
+func (c *PaymentServiceGRPCClient) Connect(upstream string) error {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	switch upstream {
+	case UpstreamPayment:
+		if c.conn != nil {
+			return nil
+		}
+		conn, err := c.dial(UpstreamPayment, c.paymentAddress, c.tlsSettings.PaymentServerName, c.paymentBreaker, &c.paymentCalls)
 		if err != nil {
-			return nil, fmt.Errorf("failed to connect to payment service: %w", err)
+			return err
 		}
-		grpcClient = paymentpb.NewPaymentServiceClient(conn)
-		log.Printf("✅ Connected to Payment Service successfully")
-
-		log.Printf("🔌 Connecting to Booking Service at %s (Real API)", bookingAddress)
-		bookingConn, err = grpc.Dial(
-			bookingAddress,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithBlock(),
-			grpc.WithTimeout(timeout),
-		)
+		c.conn = conn
+		c.grpcClient = paymentpb.NewPaymentServiceClient(conn)
+		c.paymentMonitor = newConnectionMonitor(UpstreamPayment, c.paymentAddress, conn, &c.paymentCalls, c.logger)
+		conn.Connect()
+	case UpstreamBooking:
+		if c.bookingConn != nil {
+			return nil
+		}
+		conn, err := c.dial(UpstreamBooking, c.bookingAddress, c.tlsSettings.BookingServerName, c.bookingBreaker, &c.bookingCalls)
 		if err != nil {
-			conn.Close()
-			return nil, fmt.Errorf("failed to connect to booking service: %w", err)
+			return err
 		}
-		bookingClient = bookingpb.NewBookingServiceClient(bookingConn)
-		log.Printf("✅ Connected to Booking Service successfully")
-	} else {
-		log.Printf("🧪 Using MOCK mode for Payment and Booking Services (no real connection)")
-	}
-
-	return &PaymentServiceGRPCClient{
-		conn:          conn,
-		bookingConn:   bookingConn,
-		grpcClient:    grpcClient,
-		bookingClient: bookingClient,
-		mapper:        mapper.NewPaymentInfraGRPCMapper(),
-		timeout:       timeout,
-		useMock:       useMock,
-	}, nil
+		c.bookingConn = conn
+		c.bookingClient = bookingpb.NewBookingServiceClient(conn)
+		c.bookingMonitor = newConnectionMonitor(UpstreamBooking, c.bookingAddress, conn, &c.bookingCalls, c.logger)
+		conn.Connect()
+	default:
+		return fmt.Errorf("unknown upstream %q", upstream)
+	}
+	return nil
+}
+
+// dial crea el cliente gRPC de un upstream con TLS, tracing, circuit breaker y métricas
+func (c *PaymentServiceGRPCClient) dial(upstream string, address string, serverName string, breaker *CircuitBreaker, calls *callTracker) (*grpc.ClientConn, error) {
+	creds, err := transportCredentials(c.tlsSettings, address, serverName, c.logger.With("upstream", upstream))
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure %s service TLS: %w", upstream, err)
+	}
+
+	c.logger.Info("creating "+upstream+" service client", "address", address, "tls", !c.tlsSettings.Insecure)
+	options := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
+		grpc.WithChainUnaryInterceptor(metadataUnaryInterceptor(), breaker.UnaryClientInterceptor(), metricsUnaryInterceptor(), calls.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(metadataStreamInterceptor(), breaker.StreamClientInterceptor(), metricsStreamInterceptor(), calls.StreamClientInterceptor()),
+	}, c.dialOptions...)
+	conn, err := grpc.NewClient(address, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s service client: %w", upstream, err)
+	}
+	return conn, nil
 }
 
 // GetPaymentInfraByQrValue implementa PaymentInfraRepository.GetPaymentInfraByQrValue
@@ -91,27 +155,26 @@ func (c *PaymentServiceGRPCClient) GetPaymentInfraByQrValue(ctx context.Context,
 	// Crear request
 	request := c.mapper.ToGetPaymentInfraByQrValueRequest(qrValue)
 
-	var response *dto.GetPaymentInfraByQrValueResponse
-
-	// Usar mock o llamada real según configuración
-	if c.useMock {
-		response = c.mockGetPaymentInfraByQrValue(request)
-	} else {
-		// Llamada real al servicio gRPC
-		grpcRequest := &paymentpb.GetPaymentInfraByQrValueRequest{
-			QrValue: request.QrValue,
-		}
-
-		grpcResponse, err := c.grpcClient.GetPaymentInfraByQrValue(ctx, grpcRequest)
-		if err != nil {
-			log.Printf("❌ gRPC call failed: %v", err)
-			return nil, c.mapGRPCError(err)
-		}
+	// Llamada real al servicio gRPC
+	grpcRequest := &paymentpb.GetPaymentInfraByQrValueRequest{
+		QrValue: request.QrValue,
+	}
 
-		// Mapear respuesta de gRPC a DTO
-		response = c.mapper.FromGRPCGetPaymentInfraResponse(grpcResponse)
+	// Operación idempotente: se reintenta ante fallas transitorias
+	var grpcResponse *paymentpb.GetPaymentInfraByQrValueResponse
+	err := c.withRetry(ctx, opGetPaymentInfraByQrValue, func(attemptCtx context.Context) error {
+		var callErr error
+		grpcResponse, callErr = c.grpcClient.GetPaymentInfraByQrValue(attemptCtx, grpcRequest)
+		return callErr
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opGetPaymentInfraByQrValue, "error", err)
+		return nil, translateGRPCError(opGetPaymentInfraByQrValue, err)
 	}
 
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCGetPaymentInfraResponse(grpcResponse)
+
 	// Manejar errores
 	if response == nil {
 		return nil, exception.ErrPaymentInfraServiceUnavailable
@@ -132,29 +195,28 @@ func (c *PaymentServiceGRPCClient) GetAvailableLockers(ctx context.Context, paym
 
 	request := c.mapper.ToGetAvailableLockersRequest(paymentRackID, bookingTimeID, traceID)
 
-	var response *dto.GetAvailableLockersResponse
-
-	// Usar mock o llamada real según configuración
-	if c.useMock {
-		response = c.mockGetAvailableLockers(request)
-	} else {
-		// Llamada real al servicio gRPC con el método correcto del proto
-		grpcRequest := &paymentpb.GetAvailableLockersByRackIDAndBookingTimeRequest{
-			PaymentRackId: request.PaymentRackId,
-			BookingTimeId: request.BookingTimeId,
-			TraceId:       request.TraceId,
-		}
-
-		grpcResponse, err := c.grpcClient.GetAvailableLockersByRackIDAndBookingTime(ctx, grpcRequest)
-		if err != nil {
-			log.Printf("❌ gRPC call failed: %v", err)
-			return nil, c.mapGRPCError(err)
-		}
+	// Llamada real al servicio gRPC con el método correcto del proto
+	grpcRequest := &paymentpb.GetAvailableLockersByRackIDAndBookingTimeRequest{
+		PaymentRackId: request.PaymentRackId,
+		BookingTimeId: request.BookingTimeId,
+		TraceId:       request.TraceId,
+	}
 
-		// Mapear respuesta de gRPC a DTO
-		response = c.mapper.FromGRPCGetAvailableLockersByRackIDAndBookingTimeResponse(grpcResponse)
+	// Operación idempotente: se reintenta ante fallas transitorias
+	var grpcResponse *paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse
+	err := c.withRetry(ctx, opGetAvailableLockers, func(attemptCtx context.Context) error {
+		var callErr error
+		grpcResponse, callErr = c.grpcClient.GetAvailableLockersByRackIDAndBookingTime(attemptCtx, grpcRequest)
+		return callErr
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opGetAvailableLockers, "error", err)
+		return nil, translateGRPCError(opGetAvailableLockers, err)
 	}
 
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCGetAvailableLockersByRackIDAndBookingTimeResponse(grpcResponse)
+
 	if response == nil {
 		return nil, exception.ErrPaymentInfraServiceUnavailable
 	}
@@ -173,29 +235,28 @@ func (c *PaymentServiceGRPCClient) ValidateDiscountCoupon(ctx context.Context, c
 
 	request := c.mapper.ToValidateCouponRequest(couponCode, rackID, traceID)
 
-	var response *dto.ValidateDiscountCouponResponse
-
-	// Usar mock o llamada real según configuración
-	if c.useMock {
-		response = c.mockValidateCoupon(request)
-	} else {
-		// Llamada real al servicio gRPC
-		grpcRequest := &paymentpb.ValidateDiscountCouponRequest{
-			CouponCode: request.CouponCode,
-			RackId:     request.RackId,
-			TraceId:    request.TraceId,
-		}
-
-		grpcResponse, err := c.grpcClient.ValidateDiscountCoupon(ctx, grpcRequest)
-		if err != nil {
-			log.Printf("❌ ValidateDiscountCoupon gRPC call failed: %v", err)
-			return nil, c.mapGRPCError(err)
-		}
+	// Llamada real al servicio gRPC
+	grpcRequest := &paymentpb.ValidateDiscountCouponRequest{
+		CouponCode: request.CouponCode,
+		RackId:     request.RackId,
+		TraceId:    request.TraceId,
+	}
 
-		// Mapear respuesta de gRPC a DTO
-		response = c.mapper.FromGRPCValidateDiscountCouponResponse(grpcResponse)
+	// Operación idempotente: se reintenta ante fallas transitorias
+	var grpcResponse *paymentpb.ValidateDiscountCouponResponse
+	err := c.withRetry(ctx, opValidateDiscountCoupon, func(attemptCtx context.Context) error {
+		var callErr error
+		grpcResponse, callErr = c.grpcClient.ValidateDiscountCoupon(attemptCtx, grpcRequest)
+		return callErr
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opValidateDiscountCoupon, "error", err)
+		return nil, translateGRPCError(opValidateDiscountCoupon, err)
 	}
 
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCValidateDiscountCouponResponse(grpcResponse)
+
 	if response == nil {
 		return nil, exception.ErrPaymentInfraServiceUnavailable
 	}
@@ -214,56 +275,51 @@ func (c *PaymentServiceGRPCClient) GeneratePurchaseOrder(ctx context.Context, ra
 
 	request := c.mapper.ToGeneratePurchaseOrderRequest(rackIdReference, groupID, couponCode, userEmail, userPhone, traceID, gatewayName)
 
-	// Log detallado del request
-	couponCodeValue := "nil"
+	// Log detallado del request (email, teléfono y cupón se redactan en el logger)
+	couponCodeValue := ""
 	if request.CouponCode != nil {
-		couponCodeValue = fmt.Sprintf("\"%s\"", *request.CouponCode)
-	}
-	log.Printf("🔵 GeneratePurchaseOrder - Request: rackId=%d, groupId=%d, couponCode=%s, email=%s, phone=%s, traceId=%s, gateway=%s",
-		request.RackIdReference, request.GroupId, couponCodeValue, request.UserEmail, request.UserPhone, request.TraceId, request.GatewayName)
-
-	var response *dto.GeneratePurchaseOrderResponse
-
-	// Usar mock o llamada real según configuración
-	if c.useMock {
-		log.Printf("🟡 Using MOCK mode for GeneratePurchaseOrder")
-		response = c.mockGeneratePurchaseOrder(request)
-	} else {
-		// Llamada real al servicio gRPC
-		grpcRequest := &paymentpb.GeneratePurchaseOrderRequest{
-			RackIdReference: request.RackIdReference,
-			GroupId:         request.GroupId,
-			CouponCode:      request.CouponCode, // Se asigna directamente, nil si no se proporciona
-			UserEmail:       request.UserEmail,
-			UserPhone:       request.UserPhone,
-			TraceId:         request.TraceId,
-			GatewayName:     request.GatewayName,
-		}
-
-		log.Printf("🟢 Calling real gRPC service for GeneratePurchaseOrder")
-		grpcResponse, err := c.grpcClient.GeneratePurchaseOrder(ctx, grpcRequest)
-		if err != nil {
-			log.Printf("❌ GeneratePurchaseOrder gRPC call failed: %v", err)
-			log.Printf("❌ Error details - Type: %T, Message: %s", err, err.Error())
-			return nil, c.mapGRPCError(err)
-		}
-
-		log.Printf("✅ GeneratePurchaseOrder gRPC call succeeded")
-		// Mapear respuesta de gRPC a DTO
-		response = c.mapper.FromGRPCGeneratePurchaseOrderResponse(grpcResponse)
+		couponCodeValue = *request.CouponCode
+	}
+	c.logger.InfoContext(ctx, "GeneratePurchaseOrder request",
+		"rackId", request.RackIdReference,
+		"groupId", request.GroupId,
+		"couponCode", couponCodeValue,
+		"userEmail", request.UserEmail,
+		"userPhone", request.UserPhone,
+		"gateway", request.GatewayName,
+	)
+
+	// Llamada real al servicio gRPC
+	grpcRequest := &paymentpb.GeneratePurchaseOrderRequest{
+		RackIdReference: request.RackIdReference,
+		GroupId:         request.GroupId,
+		CouponCode:      request.CouponCode, // Se asigna directamente, nil si no se proporciona
+		UserEmail:       request.UserEmail,
+		UserPhone:       request.UserPhone,
+		TraceId:         request.TraceId,
+		GatewayName:     request.GatewayName,
+	}
+
+	grpcResponse, err := c.grpcClient.GeneratePurchaseOrder(ctx, grpcRequest)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opGeneratePurchaseOrder, "error", err, "errorType", fmt.Sprintf("%T", err))
+		return nil, translateGRPCError(opGeneratePurchaseOrder, err)
 	}
 
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCGeneratePurchaseOrderResponse(grpcResponse)
+
 	if response == nil {
-		log.Printf("❌ GeneratePurchaseOrder - Response is nil")
+		c.logger.ErrorContext(ctx, "GeneratePurchaseOrder response is nil")
 		return nil, exception.ErrPaymentInfraServiceUnavailable
 	}
 
 	if response.Response != nil && response.Response.Status == dto.PaymentManagerResponseStatus_RESPONSE_STATUS_ERROR {
-		log.Printf("❌ GeneratePurchaseOrder - Response status is ERROR: %s", response.Response.Message)
+		c.logger.ErrorContext(ctx, "GeneratePurchaseOrder response status is ERROR", "detail", response.Response.Message)
 		return nil, exception.ErrPurchaseOrderFailed
 	}
 
-	log.Printf("✅ GeneratePurchaseOrder - Success: transactionId=%s, url=%s", response.Response.TransactionId, response.Url)
+	c.logger.InfoContext(ctx, "GeneratePurchaseOrder succeeded", "transactionId", response.Response.TransactionId)
 
 	return c.mapper.ToPurchaseOrderDomain(response), nil
 }
@@ -275,32 +331,25 @@ func (c *PaymentServiceGRPCClient) GenerateBooking(ctx context.Context, rackIdRe
 
 	request := c.mapper.ToGenerateBookingRequest(rackIdReference, groupID, couponCode, userEmail, userPhone, traceID)
 
-	var response *dto.GenerateBookingResponse
-
-	// Usar mock o llamada real según configuración
-	if c.useMock {
-		response = c.mockGenerateBooking(request)
-	} else {
-		// Llamada real al servicio gRPC
-		grpcRequest := &paymentpb.GenerateBookingRequest{
-			RackIdReference: request.RackIdReference,
-			GroupId:         request.GroupId,
-			CouponCode:      request.CouponCode, // Se asigna directamente, nil si no se proporciona
-			UserEmail:       request.UserEmail,
-			UserPhone:       request.UserPhone,
-			TraceId:         request.TraceId,
-		}
-
-		grpcResponse, err := c.grpcClient.GenerateBooking(ctx, grpcRequest)
-		if err != nil {
-			log.Printf("❌ GenerateBooking gRPC call failed: %v", err)
-			return nil, c.mapGRPCError(err)
-		}
+	// Llamada real al servicio gRPC
+	grpcRequest := &paymentpb.GenerateBookingRequest{
+		RackIdReference: request.RackIdReference,
+		GroupId:         request.GroupId,
+		CouponCode:      request.CouponCode, // Se asigna directamente, nil si no se proporciona
+		UserEmail:       request.UserEmail,
+		UserPhone:       request.UserPhone,
+		TraceId:         request.TraceId,
+	}
 
-		// Mapear respuesta de gRPC a DTO
-		response = c.mapper.FromGRPCGenerateBookingResponse(grpcResponse)
+	grpcResponse, err := c.grpcClient.GenerateBooking(ctx, grpcRequest)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opGenerateBooking, "error", err)
+		return nil, translateGRPCError(opGenerateBooking, err)
 	}
 
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCGenerateBookingResponse(grpcResponse)
+
 	if response == nil {
 		return nil, exception.ErrPaymentInfraServiceUnavailable
 	}
@@ -319,8 +368,26 @@ func (c *PaymentServiceGRPCClient) GetPurchaseOrderByPo(ctx context.Context, pur
 
 	request := c.mapper.ToGetPurchaseOrderByPoRequest(purchaseOrder, traceID)
 
-	// Mock por ahora
-	response := c.mockGetPurchaseOrderByPo(request)
+	// Llamada real al servicio gRPC
+	grpcRequest := &paymentpb.GetPurchaseOrderByPoRequest{
+		PurchaseOrder: request.PurchaseOrder,
+		TraceId:       request.TraceId,
+	}
+
+	// Operación idempotente: se reintenta ante fallas transitorias
+	var grpcResponse *paymentpb.GetPurchaseOrderByPoResponse
+	err := c.withRetry(ctx, opGetPurchaseOrderByPo, func(attemptCtx context.Context) error {
+		var callErr error
+		grpcResponse, callErr = c.grpcClient.GetPurchaseOrderByPo(attemptCtx, grpcRequest)
+		return callErr
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opGetPurchaseOrderByPo, "error", err)
+		return nil, translateGRPCError(opGetPurchaseOrderByPo, err)
+	}
+
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCGetPurchaseOrderByPoResponse(grpcResponse)
 
 	if response == nil {
 		return nil, exception.ErrPaymentInfraServiceUnavailable
@@ -330,37 +397,42 @@ func (c *PaymentServiceGRPCClient) GetPurchaseOrderByPo(ctx context.Context, pur
 		return nil, exception.ErrPurchaseOrderNotFound
 	}
 
+	// Una respuesta OK sin registro de orden de compra equivale a no encontrada
+	if response.PurchaseOrder == nil {
+		return nil, exception.ErrPurchaseOrderNotFound
+	}
+
 	return c.mapper.ToPurchaseOrderDataDomain(response), nil
 }
 
 // CheckBookingStatus implementa PaymentInfraRepository.CheckBookingStatus
 func (c *PaymentServiceGRPCClient) CheckBookingStatus(ctx context.Context, serviceName string, currentCode string) (*model.BookingStatusCheck, error) {
-	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	ctx, cancel := context.WithTimeout(ctx, c.bookingTimeout)
 	defer cancel()
 
 	request := c.mapper.ToCheckBookingStatusRequest(serviceName, currentCode)
 
-	var response *dto.CheckBookingStatusResponse
-
-	if c.useMock {
-		response = c.mockCheckBookingStatus(request)
-	} else {
-		// Llamada real al servicio gRPC de Booking
-		grpcRequest := &bookingpb.CheckBookingStatusRequest{
-			ServiceName: request.ServiceName,
-			CurrentCode: request.CurrentCode,
-		}
-
-		grpcResponse, err := c.bookingClient.CheckBookingStatus(ctx, grpcRequest)
-		if err != nil {
-			log.Printf("❌ Booking gRPC call failed: %v", err)
-			return nil, c.mapGRPCError(err)
-		}
+	// Llamada real al servicio gRPC de Booking
+	grpcRequest := &bookingpb.CheckBookingStatusRequest{
+		ServiceName: request.ServiceName,
+		CurrentCode: request.CurrentCode,
+	}
 
-		// Mapear respuesta de gRPC a DTO
-		response = c.mapper.FromGRPCCheckBookingStatusResponse(grpcResponse)
+	// Operación idempotente: se reintenta ante fallas transitorias
+	var grpcResponse *bookingpb.CheckBookingStatusResponse
+	err := c.withRetry(ctx, opCheckBookingStatus, func(attemptCtx context.Context) error {
+		var callErr error
+		grpcResponse, callErr = c.bookingClient.CheckBookingStatus(attemptCtx, grpcRequest)
+		return callErr
+	})
+	if err != nil {
+		c.logger.ErrorContext(ctx, "gRPC call failed", "operation", opCheckBookingStatus, "error", err)
+		return nil, translateGRPCError(opCheckBookingStatus, err)
 	}
 
+	// Mapear respuesta de gRPC a DTO
+	response := c.mapper.FromGRPCCheckBookingStatusResponse(grpcResponse)
+
 	if response == nil {
 		return nil, exception.ErrPaymentInfraServiceUnavailable
 	}
@@ -372,57 +444,26 @@ func (c *PaymentServiceGRPCClient) CheckBookingStatus(ctx context.Context, servi
 	return c.mapper.ToBookingStatusDomain(response), nil
 }
 
+// openConnectionLostMessage es el mensaje del ERROR emitido cuando el stream de Booking Manager falla;
+// el detalle del error solo queda en el log
+const openConnectionLostMessage = "Se perdió la conexión con el servicio de apertura"
+
 // ExecuteOpenStream implementa PaymentInfraRepository.ExecuteOpenStream con soporte de streaming
-// Retorna un canal que emite todos los estados progresivamente: RECEIVED -> REQUESTED -> SUCCESS/ERROR
+// Retorna un canal que emite todos los estados progresivamente: RECEIVED -> REQUESTED -> SUCCESS/ERROR.
+// Los plazos de cada fase los vigila el repositorio que enruta los upstreams; al cancelar ctx se corta el stream.
 func (c *PaymentServiceGRPCClient) ExecuteOpenStream(ctx context.Context, serviceName string, currentCode string) (<-chan *model.ExecuteOpenResult, error) {
 	request := c.mapper.ToExecuteOpenRequest(serviceName, currentCode)
 
-	log.Printf("🔷 ExecuteOpenStream - Starting: serviceName=%s, currentCode=%s", serviceName, currentCode)
+	c.logger.InfoContext(ctx, "ExecuteOpenStream starting", "serviceName", serviceName, "currentCode", currentCode)
 
 	// Crear canal para emitir resultados progresivos
 	resultChan := make(chan *model.ExecuteOpenResult, 10)
 
-	if c.useMock {
-		// Mock mode: emitir los 3 estados simulados
-		go func() {
-			defer close(resultChan)
-
-			// Estado 1: RECEIVED
-			resultChan <- &model.ExecuteOpenResult{
-				TransactionID:  "mock-tx-id",
-				Message:        "Solicitud recibida",
-				OpenStatus:     model.OpenStatusReceived,
-				PhysicalStatus: model.PhysicalStatusWaiting,
-			}
-			time.Sleep(500 * time.Millisecond)
-
-			// Estado 2: REQUESTED
-			resultChan <- &model.ExecuteOpenResult{
-				TransactionID:  "mock-tx-id",
-				Message:        "Solicitud enviada al dispositivo",
-				OpenStatus:     model.OpenStatusRequested,
-				PhysicalStatus: model.PhysicalStatusWaiting,
-			}
-			time.Sleep(2 * time.Second)
-
-			// Estado 3: SUCCESS
-			resultChan <- &model.ExecuteOpenResult{
-				TransactionID:  "mock-tx-id",
-				Message:        "Apertura ejecutada correctamente",
-				OpenStatus:     model.OpenStatusSuccess,
-				PhysicalStatus: model.PhysicalStatusSuccess,
-			}
-		}()
-
-		return resultChan, nil
-	}
-
-	// Modo real: usar gRPC streaming
 	stream, err := c.bookingClient.ExecuteOpen(ctx)
 	if err != nil {
 		close(resultChan)
-		log.Printf("❌ ExecuteOpenStream failed to create stream: %v", err)
-		return nil, c.mapGRPCError(err)
+		c.logger.ErrorContext(ctx, "ExecuteOpenStream failed to create stream", "error", err)
+		return nil, translateGRPCError(opExecuteOpen, err)
 	}
 
 	// Enviar request al stream
@@ -433,15 +474,15 @@ func (c *PaymentServiceGRPCClient) ExecuteOpenStream(ctx context.Context, servic
 
 	if err := stream.Send(grpcRequest); err != nil {
 		close(resultChan)
-		log.Printf("❌ ExecuteOpenStream failed to send request: %v", err)
-		return nil, c.mapGRPCError(err)
+		c.logger.ErrorContext(ctx, "ExecuteOpenStream failed to send request", "error", err)
+		return nil, translateGRPCError(opExecuteOpen, err)
 	}
 
 	// Cerrar el envío
 	if err := stream.CloseSend(); err != nil {
 		close(resultChan)
-		log.Printf("❌ ExecuteOpenStream failed to close send: %v", err)
-		return nil, c.mapGRPCError(err)
+		c.logger.ErrorContext(ctx, "ExecuteOpenStream failed to close send", "error", err)
+		return nil, translateGRPCError(opExecuteOpen, err)
 	}
 
 	// Goroutine para recibir todos los mensajes del stream y emitirlos al canal
@@ -453,17 +494,22 @@ func (c *PaymentServiceGRPCClient) ExecuteOpenStream(ctx context.Context, servic
 			resp, err := stream.Recv()
 			if err != nil {
 				if err == io.EOF {
-					log.Printf("✅ ExecuteOpenStream - Stream ended normally after %d messages", messageCount)
+					c.logger.InfoContext(ctx, "ExecuteOpenStream ended normally", "messages", messageCount)
+					break
+				}
+				if ctx.Err() != nil {
+					// Cancelado por el watchdog, el drenaje o el cliente: el estado final lo emite el watchdog
+					c.logger.InfoContext(ctx, "ExecuteOpenStream cancelled", "messages", messageCount)
 					break
 				}
-				log.Printf("❌ ExecuteOpenStream recv error: %v", err)
+				c.logger.ErrorContext(ctx, "ExecuteOpenStream recv error", "messages", messageCount, "error", err)
 
-				// Emitir error al canal
+				// Emitir error al canal; no se sabe si la puerta se abrió
 				resultChan <- &model.ExecuteOpenResult{
 					TransactionID:  "",
-					Message:        fmt.Sprintf("Error de conexión: %v", err),
+					Message:        openConnectionLostMessage,
 					OpenStatus:     model.OpenStatusError,
-					PhysicalStatus: model.PhysicalStatusUnspecified,
+					PhysicalStatus: model.PhysicalStatusUnexpected,
 				}
 				break
 			}
@@ -496,22 +542,26 @@ func (c *PaymentServiceGRPCClient) ExecuteOpenStream(ctx context.Context, servic
 			// Convertir a modelo de dominio
 			domainResult := c.mapper.ToExecuteOpenDomain(dtoResponse)
 
-			log.Printf("📥 ExecuteOpenStream - Message %d: status=%v, message=%s",
-				messageCount, domainResult.OpenStatus, domainResult.Message)
+			c.logger.InfoContext(ctx, "ExecuteOpenStream message received",
+				"message", messageCount,
+				"openStatus", domainResult.OpenStatus,
+				"physicalStatus", domainResult.PhysicalStatus,
+				"detail", domainResult.Message,
+			)
 
 			// Emitir al canal
 			select {
 			case resultChan <- domainResult:
 				// Emitido exitosamente
 			case <-ctx.Done():
-				log.Printf("⚠️ ExecuteOpenStream - Context cancelled, stopping stream")
+				c.logger.WarnContext(ctx, "ExecuteOpenStream context cancelled, stopping stream")
 				return
 			}
 
 			// Si recibimos un estado terminal, continuamos leyendo hasta EOF
 			if resp.Status == bookingpb.OpenStatus_OPEN_STATUS_SUCCESS ||
 				resp.Status == bookingpb.OpenStatus_OPEN_STATUS_ERROR {
-				log.Printf("🏁 ExecuteOpenStream - Terminal status received: %v", resp.Status)
+				c.logger.InfoContext(ctx, "ExecuteOpenStream terminal status received", "openStatus", resp.Status.String())
 			}
 		}
 	}()
@@ -519,43 +569,62 @@ func (c *PaymentServiceGRPCClient) ExecuteOpenStream(ctx context.Context, servic
 	return resultChan, nil
 }
 
-// Close cierra las conexiones gRPC
-func (c *PaymentServiceGRPCClient) Close() error {
-	var err error
-	if c.conn != nil {
-		if closeErr := c.conn.Close(); closeErr != nil {
-			err = closeErr
-		}
+// CircuitBreakers devuelve el estado actual de los circuit breakers de cada upstream
+func (c *PaymentServiceGRPCClient) CircuitBreakers() []CircuitBreakerSnapshot {
+	return []CircuitBreakerSnapshot{
+		c.paymentBreaker.Snapshot(),
+		c.bookingBreaker.Snapshot(),
 	}
-	if c.bookingConn != nil {
-		if closeErr := c.bookingConn.Close(); closeErr != nil {
-			err = closeErr
-		}
-	}
-	return err
 }
 
-// mapGRPCError mapea errores gRPC a errores de dominio
-func (c *PaymentServiceGRPCClient) mapGRPCError(err error) error {
-	if err == nil {
-		return nil
+// Connections devuelve el estado de conectividad de cada upstream conectado
+func (c *PaymentServiceGRPCClient) Connections() []ConnectionStatus {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	connections := []ConnectionStatus{}
+	if c.paymentMonitor != nil {
+		connections = append(connections, c.paymentMonitor.Status())
+	}
+	if c.bookingMonitor != nil {
+		connections = append(connections, c.bookingMonitor.Status())
 	}
+	return connections
+}
 
-	statusErr, ok := status.FromError(err)
-	if !ok {
-		return exception.ErrPaymentInfraServiceUnavailable
+// CheckHealth consulta el servicio estándar grpc.health.v1 de cada upstream conectado
+func (c *PaymentServiceGRPCClient) CheckHealth(ctx context.Context, timeout time.Duration) []HealthCheckResult {
+	c.connectMu.Lock()
+	conn, bookingConn := c.conn, c.bookingConn
+	c.connectMu.Unlock()
+
+	results := []HealthCheckResult{}
+	if conn != nil {
+		results = append(results, checkHealth(ctx, UpstreamPayment, conn, timeout))
+	}
+	if bookingConn != nil {
+		results = append(results, checkHealth(ctx, UpstreamBooking, bookingConn, timeout))
 	}
+	return results
+}
 
-	switch statusErr.Code() {
-	case codes.NotFound:
-		return exception.ErrPaymentRackNotFound
-	case codes.InvalidArgument:
-		return exception.ErrInvalidPaymentRackID
-	case codes.Unavailable:
-		return exception.ErrPaymentInfraServiceUnavailable
-	default:
-		return exception.ErrPaymentInfraServiceUnavailable
+// Close cierra las conexiones gRPC
+func (c *PaymentServiceGRPCClient) Close() error {
+	c.connectMu.Lock()
+	defer c.connectMu.Unlock()
+
+	var errs []error
+	if c.conn != nil {
+		if err := c.conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", UpstreamPayment, err))
+		}
+	}
+	if c.bookingConn != nil {
+		if err := c.bookingConn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", UpstreamBooking, err))
+		}
 	}
+	return errors.Join(errs...)
 }
 
 // Asegurar que PaymentServiceGRPCClient implementa PaymentInfraRepository
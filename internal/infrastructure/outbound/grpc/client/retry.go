@@ -0,0 +1,137 @@
+package client
+
+import (
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryPolicy define los reintentos para las operaciones gRPC idempotentes (solo lecturas)
+type RetryPolicy struct {
+	// MaxAttempts es el número total de intentos, incluyendo el primero (1 = sin reintentos)
+	MaxAttempts int
+	// InitialBackoff es la espera base antes del primer reintento
+	InitialBackoff time.Duration
+	// MaxBackoff es la espera máxima entre reintentos
+	MaxBackoff time.Duration
+	// Multiplier es el factor de crecimiento exponencial del backoff
+	Multiplier float64
+	// PerAttemptTimeout es el deadline de cada intento; siempre queda acotado por el timeout total
+	PerAttemptTimeout time.Duration
+	// RetryableCodes son los códigos gRPC que se consideran transitorios
+	RetryableCodes []codes.Code
+}
+
+// DefaultRetryPolicy devuelve la política de reintentos por defecto
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:       3,
+		InitialBackoff:    100 * time.Millisecond,
+		MaxBackoff:        1 * time.Second,
+		Multiplier:        2.0,
+		PerAttemptTimeout: 3 * time.Second,
+		RetryableCodes:    []codes.Code{codes.Unavailable, codes.DeadlineExceeded, codes.Aborted},
+	}
+}
+
+// ParseRetryableCodes convierte nombres de códigos gRPC (p. ej. "UNAVAILABLE") a codes.Code
+func ParseRetryableCodes(names []string) ([]codes.Code, error) {
+	result := make([]codes.Code, 0, len(names))
+	for _, name := range names {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		var code codes.Code
+		if err := code.UnmarshalJSON([]byte(strconv.Quote(name))); err != nil {
+			return nil, fmt.Errorf("invalid gRPC code %q: %w", name, err)
+		}
+		result = append(result, code)
+	}
+	return result, nil
+}
+
+// isRetryable indica si el error gRPC corresponde a un código reintentable
+func (p RetryPolicy) isRetryable(err error) bool {
+	code := status.Code(err)
+	for _, retryable := range p.RetryableCodes {
+		if code == retryable {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff calcula la espera antes del reintento n (1-based) usando backoff exponencial con full jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	ceiling := float64(p.InitialBackoff)
+	for i := 1; i < retry; i++ {
+		ceiling *= p.Multiplier
+		if ceiling >= float64(p.MaxBackoff) {
+			ceiling = float64(p.MaxBackoff)
+			break
+		}
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(ceiling)) + 1)
+}
+
+// withRetry ejecuta call aplicando la política de reintentos. ctx debe traer el deadline total
+// de la operación: cada intento recibe un deadline propio que nunca lo excede.
+func (c *PaymentServiceGRPCClient) withRetry(ctx context.Context, op grpcOperation, call func(ctx context.Context) error) error {
+	policy := c.retryPolicy
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+
+	// Los reintentos son la misma operación: comparten trace ID y request ID
+	ctx = requestmeta.EnsureIDs(ctx)
+
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if policy.PerAttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, policy.PerAttemptTimeout)
+		}
+		err = call(attemptCtx)
+		cancel()
+
+		if err == nil || attempt == maxAttempts || !policy.isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		// No reintentar si la espera consumiría el tiempo restante de la operación
+		wait := policy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= wait {
+			return err
+		}
+
+		c.logger.WarnContext(ctx, "gRPC attempt failed, retrying",
+			"operation", op,
+			"attempt", attempt,
+			"maxAttempts", maxAttempts,
+			"code", status.Code(err).String(),
+			"wait", wait,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,160 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// newRetryClient crea un cliente sin conexiones que solo sirve para probar withRetry
+func newRetryClient(policy RetryPolicy) *PaymentServiceGRPCClient {
+	return &PaymentServiceGRPCClient{
+		retryPolicy: policy,
+		logger:      discardLogger,
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: 10 * time.Millisecond,
+		MaxBackoff:     50 * time.Millisecond,
+		Multiplier:     2,
+	}
+
+	tests := []struct {
+		retry   int
+		ceiling time.Duration
+	}{
+		{retry: 1, ceiling: 10 * time.Millisecond},
+		{retry: 2, ceiling: 20 * time.Millisecond},
+		{retry: 3, ceiling: 40 * time.Millisecond},
+		{retry: 4, ceiling: 50 * time.Millisecond},
+		{retry: 10, ceiling: 50 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for i := 0; i < 200; i++ {
+			wait := policy.backoff(tt.retry)
+			if wait <= 0 || wait > tt.ceiling {
+				t.Fatalf("retry %d: backoff %v outside (0, %v]", tt.retry, wait, tt.ceiling)
+			}
+		}
+	}
+}
+
+func TestBackoffWithoutInitialBackoff(t *testing.T) {
+	policy := RetryPolicy{MaxBackoff: time.Second, Multiplier: 2}
+	if wait := policy.backoff(3); wait != 0 {
+		t.Errorf("expected no wait, got %v", wait)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "down")
+	notFound := status.Error(codes.NotFound, "missing")
+
+	tests := []struct {
+		name      string
+		failures  []error
+		wantCalls int
+		wantCode  codes.Code
+	}{
+		{name: "success on first attempt", wantCalls: 1, wantCode: codes.OK},
+		{name: "transient failure then success", failures: []error{unavailable}, wantCalls: 2, wantCode: codes.OK},
+		{name: "gives up after max attempts", failures: []error{unavailable, unavailable, unavailable, unavailable}, wantCalls: 3, wantCode: codes.Unavailable},
+		{name: "non retryable code", failures: []error{notFound}, wantCalls: 1, wantCode: codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newRetryClient(RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+				MaxBackoff:     time.Millisecond,
+				Multiplier:     2,
+				RetryableCodes: []codes.Code{codes.Unavailable},
+			})
+
+			calls := 0
+			err := c.withRetry(context.Background(), opGetPaymentInfraByQrValue, func(ctx context.Context) error {
+				calls++
+				if calls <= len(tt.failures) {
+					return tt.failures[calls-1]
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if got := status.Code(err); got != tt.wantCode {
+				t.Errorf("expected code %v, got %v", tt.wantCode, got)
+			}
+		})
+	}
+}
+
+func TestWithRetryStopsBeforeDeadline(t *testing.T) {
+	c := newRetryClient(RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     time.Second,
+		Multiplier:     1,
+		RetryableCodes: []codes.Code{codes.Unavailable},
+	})
+
+	// El loop nunca espera más allá del deadline de la operación y devuelve el error del último
+	// intento, no el del contexto
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := c.withRetry(ctx, opGetPaymentInfraByQrValue, func(ctx context.Context) error {
+		return status.Error(codes.Unavailable, "down")
+	})
+
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Errorf("retry loop outlived the operation deadline: %v", elapsed)
+	}
+}
+
+func TestWithRetryPerAttemptTimeout(t *testing.T) {
+	c := newRetryClient(RetryPolicy{
+		MaxAttempts:       1,
+		PerAttemptTimeout: 20 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := c.withRetry(ctx, opGetPaymentInfraByQrValue, func(attemptCtx context.Context) error {
+		deadline, ok := attemptCtx.Deadline()
+		if !ok || time.Until(deadline) > 20*time.Millisecond {
+			return errors.New("attempt deadline not applied")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseRetryableCodes(t *testing.T) {
+	got, err := ParseRetryableCodes([]string{"unavailable", " DEADLINE_EXCEEDED ", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != codes.Unavailable || got[1] != codes.DeadlineExceeded {
+		t.Errorf("unexpected codes: %v", got)
+	}
+
+	if _, err := ParseRetryableCodes([]string{"NOPE"}); err == nil {
+		t.Error("expected error for unknown code")
+	}
+}
@@ -0,0 +1,229 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// TLSSettings define la seguridad de transporte de las conexiones gRPC salientes
+type TLSSettings struct {
+	// Insecure desactiva TLS (solo para desarrollo local)
+	Insecure bool
+	// CAFile es el bundle de CAs para validar el certificado del servidor; vacío usa las CAs del sistema
+	CAFile string
+	// CertFile y KeyFile son el certificado y la llave del cliente para mTLS (opcionales)
+	CertFile string
+	KeyFile  string
+	// PaymentServerName y BookingServerName sobrescriben el nombre esperado en el certificado de cada upstream
+	PaymentServerName string
+	BookingServerName string
+}
+
+// transportCredentials construye las credenciales de transporte para un upstream.
+// Los certificados se vuelven a leer desde disco cuando cambian, sin reiniciar el proceso.
+func transportCredentials(settings TLSSettings, address string, serverName string, logger *slog.Logger) (credentials.TransportCredentials, error) {
+	if settings.Insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	serverName = expectedServerName(address, serverName)
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: serverName,
+	}
+
+	// Certificado de cliente (mTLS)
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, errors.New("both TLS client cert and key files are required for mTLS")
+		}
+		certReloader := &certificateReloader{certFile: settings.CertFile, keyFile: settings.KeyFile, logger: logger}
+		if _, err := certReloader.load(); err != nil {
+			return nil, err
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return certReloader.load()
+		}
+	}
+
+	// Bundle de CAs propio: la verificación se hace manualmente para poder recargarlo en caliente
+	if settings.CAFile != "" {
+		caReloader := &caBundleReloader{caFile: settings.CAFile, logger: logger}
+		if _, err := caReloader.load(); err != nil {
+			return nil, err
+		}
+		tlsConfig.InsecureSkipVerify = true // la verificación la realiza VerifyConnection
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			pool, err := caReloader.load()
+			if err != nil {
+				return err
+			}
+			return verifyServerCertificate(state, pool, serverName)
+		}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+// expectedServerName devuelve el nombre que debe figurar en el certificado del upstream:
+// el configurado o, si no hay, el host de la dirección (nombre DNS o IP)
+func expectedServerName(address string, serverName string) string {
+	if serverName != "" {
+		return serverName
+	}
+	address = address[strings.LastIndex(address, "/")+1:]
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		return host
+	}
+	return address
+}
+
+// verifyServerCertificate valida la cadena del servidor contra el pool de CAs indicado
+// y que el certificado corresponda a serverName
+func verifyServerCertificate(state tls.ConnectionState, pool *x509.CertPool, serverName string) error {
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("server did not present a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         pool,
+		Intermediates: intermediates,
+	})
+	return err
+}
+
+// fileModTimes devuelve la fecha de modificación de cada archivo
+func fileModTimes(paths ...string) ([]time.Time, error) {
+	modTimes := make([]time.Time, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// sameModTimes compara dos listas de fechas de modificación
+func sameModTimes(a, b []time.Time) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Equal(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// certificateReloader mantiene el certificado de cliente y lo recarga cuando cambian los archivos
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	cert     *tls.Certificate
+	modTimes []time.Time
+}
+
+// load devuelve el certificado vigente, recargándolo si los archivos cambiaron
+func (r *certificateReloader) load() (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes, err := fileModTimes(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			r.logger.Warn("TLS client certificate not readable, keeping previous one", "certFile", r.certFile, "error", err)
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to read TLS client certificate: %w", err)
+	}
+	if r.cert != nil && sameModTimes(modTimes, r.modTimes) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		if r.cert != nil {
+			r.logger.Warn("TLS client certificate reload failed, keeping previous one", "certFile", r.certFile, "error", err)
+			return r.cert, nil
+		}
+		return nil, fmt.Errorf("failed to load TLS client certificate: %w", err)
+	}
+
+	if r.cert != nil {
+		r.logger.Info("TLS client certificate reloaded", "certFile", r.certFile)
+	}
+	r.cert = &cert
+	r.modTimes = modTimes
+	return r.cert, nil
+}
+
+// caBundleReloader mantiene el pool de CAs y lo recarga cuando cambia el archivo
+type caBundleReloader struct {
+	caFile string
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	pool     *x509.CertPool
+	modTimes []time.Time
+}
+
+// load devuelve el pool de CAs vigente, recargándolo si el archivo cambió
+func (r *caBundleReloader) load() (*x509.CertPool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	modTimes, err := fileModTimes(r.caFile)
+	if err != nil {
+		if r.pool != nil {
+			r.logger.Warn("TLS CA bundle not readable, keeping previous one", "caFile", r.caFile, "error", err)
+			return r.pool, nil
+		}
+		return nil, fmt.Errorf("failed to read TLS CA bundle: %w", err)
+	}
+	if r.pool != nil && sameModTimes(modTimes, r.modTimes) {
+		return r.pool, nil
+	}
+
+	pemData, err := os.ReadFile(r.caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS CA bundle: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pemData) {
+		if r.pool != nil {
+			r.logger.Warn("TLS CA bundle reload failed: no valid certificates, keeping previous one", "caFile", r.caFile)
+			return r.pool, nil
+		}
+		return nil, fmt.Errorf("no valid certificates found in TLS CA bundle %s", r.caFile)
+	}
+
+	if r.pool != nil {
+		r.logger.Info("TLS CA bundle reloaded", "caFile", r.caFile)
+	}
+	r.pool = pool
+	r.modTimes = modTimes
+	return r.pool, nil
+}
@@ -0,0 +1,113 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestExpectedServerName(t *testing.T) {
+	tests := []struct {
+		address    string
+		serverName string
+		want       string
+	}{
+		{address: "payment-manager:50051", want: "payment-manager"},
+		{address: "10.0.3.7:50051", want: "10.0.3.7"},
+		{address: "[::1]:50051", want: "::1"},
+		{address: "dns:///booking-manager:50052", want: "booking-manager"},
+		{address: "10.0.3.7:50051", serverName: "payment.internal", want: "payment.internal"},
+	}
+	for _, tt := range tests {
+		if got := expectedServerName(tt.address, tt.serverName); got != tt.want {
+			t.Errorf("expectedServerName(%q, %q) = %q, want %q", tt.address, tt.serverName, got, tt.want)
+		}
+	}
+}
+
+// newTestCertificate emite un certificado de servidor firmado por una CA propia
+func newTestCertificate(t *testing.T) (*x509.Certificate, *x509.CertPool) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serverKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "payment-manager"},
+		DNSNames:     []string{"payment.internal"},
+		IPAddresses:  []net.IP{net.ParseIP("10.0.3.7")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, ca, &serverKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := x509.ParseCertificate(serverDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	return server, pool
+}
+
+func TestVerifyServerCertificate(t *testing.T) {
+	server, pool := newTestCertificate(t)
+	// Con un destino IP el ServerName del handshake llega vacío: la verificación no debe depender de él
+	state := tls.ConnectionState{PeerCertificates: []*x509.Certificate{server}}
+
+	tests := []struct {
+		serverName string
+		wantErr    bool
+	}{
+		{serverName: "10.0.3.7"},
+		{serverName: "payment.internal"},
+		{serverName: "10.0.3.8", wantErr: true},
+		{serverName: "booking.internal", wantErr: true},
+	}
+	for _, tt := range tests {
+		err := verifyServerCertificate(state, pool, tt.serverName)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("serverName %q: expected error=%v, got %v", tt.serverName, tt.wantErr, err)
+		}
+	}
+
+	if err := verifyServerCertificate(state, x509.NewCertPool(), "10.0.3.7"); err == nil {
+		t.Error("expected error for an unknown CA")
+	}
+}
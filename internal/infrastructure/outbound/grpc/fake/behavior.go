@@ -0,0 +1,165 @@
+package fake
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultKey es la clave de la respuesta que se usa cuando ninguna otra coincide
+const DefaultKey = "*"
+
+// injectedError es un error programado para un método
+type injectedError struct {
+	err error
+	// remaining es la cantidad de llamadas que todavía fallan (-1 = todas)
+	remaining int
+}
+
+// behavior contiene la parte programable común a los servidores falsos:
+// errores inyectados, latencia por método y registro de las solicitudes recibidas.
+// Los métodos se identifican por su nombre completo gRPC (p. ej. paymentpb.PaymentService_GenerateBooking_FullMethodName).
+type behavior struct {
+	mu       sync.Mutex
+	errors   map[string]*injectedError
+	delays   map[string]time.Duration
+	requests map[string][]proto.Message
+}
+
+// InjectError hace fallar con err las próximas times llamadas al método (times <= 0: todas hasta Reset).
+// err debería ser un error de status (status.Error) para que el cliente reciba el código esperado.
+func (b *behavior) InjectError(method string, err error, times int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.errors == nil {
+		b.errors = make(map[string]*injectedError)
+	}
+	if times <= 0 {
+		times = -1
+	}
+	b.errors[method] = &injectedError{err: err, remaining: times}
+}
+
+// SetDelay hace esperar delay al método antes de responder (respeta la cancelación del cliente)
+func (b *behavior) SetDelay(method string, delay time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.delays == nil {
+		b.delays = make(map[string]time.Duration)
+	}
+	b.delays[method] = delay
+}
+
+// Calls devuelve la cantidad de llamadas recibidas por el método (incluidas las que fallaron)
+func (b *behavior) Calls(method string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.requests[method])
+}
+
+// Requests devuelve las solicitudes recibidas por el método, en orden de llegada
+func (b *behavior) Requests(method string) []proto.Message {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]proto.Message(nil), b.requests[method]...)
+}
+
+// reset descarta los errores, las latencias y las solicitudes registradas
+func (b *behavior) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.errors = nil
+	b.delays = nil
+	b.requests = nil
+}
+
+// begin registra la solicitud, aplica la latencia del método y devuelve el error inyectado si corresponde
+func (b *behavior) begin(ctx context.Context, method string, request proto.Message) error {
+	b.mu.Lock()
+	if b.requests == nil {
+		b.requests = make(map[string][]proto.Message)
+	}
+	b.requests[method] = append(b.requests[method], proto.Clone(request))
+	delay := b.delays[method]
+	var injectedErr error
+	if injected := b.errors[method]; injected != nil {
+		injectedErr = injected.err
+		if injected.remaining > 0 {
+			injected.remaining--
+			if injected.remaining == 0 {
+				delete(b.errors, method)
+			}
+		}
+	}
+	b.mu.Unlock()
+
+	if err := wait(ctx, delay); err != nil {
+		return err
+	}
+	return injectedErr
+}
+
+// wait espera delay o hasta que el cliente cancele la llamada
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// responses es una tabla de respuestas programadas indexada por el valor que recibe el método
+type responses[T any] struct {
+	mu      sync.Mutex
+	entries map[string]T
+}
+
+// set programa la respuesta de la clave (DefaultKey responde a las claves sin respuesta propia)
+func (r *responses[T]) set(key string, response T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.entries == nil {
+		r.entries = make(map[string]T)
+	}
+	r.entries[key] = response
+}
+
+// get devuelve la respuesta de la clave o la respuesta por defecto; sin ninguna responde NotFound
+func (r *responses[T]) get(method string, key string) (T, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if response, ok := r.entries[key]; ok {
+		return response, nil
+	}
+	if response, ok := r.entries[DefaultKey]; ok {
+		return response, nil
+	}
+	var zero T
+	return zero, status.Errorf(codes.NotFound, "fake: no response programmed for %s %q", method, key)
+}
+
+// reset descarta las respuestas programadas
+func (r *responses[T]) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries = nil
+}
+
+// respond devuelve una copia de la respuesta programada para que el llamador no comparta el mensaje
+func respond[T proto.Message](table *responses[T], method string, key string) (T, error) {
+	response, err := table.get(method, key)
+	if err != nil {
+		return response, err
+	}
+	return proto.Clone(response).(T), nil
+}
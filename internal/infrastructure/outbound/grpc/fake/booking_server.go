@@ -0,0 +1,95 @@
+package fake
+
+import (
+	bookingpb "bff-graphql-payment/gen/go/proto/booking/v1"
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// OpenStep es un paso del guion de ExecuteOpen: espera Delay y luego envía Response
+// o, si Err no es nil, termina el stream con ese error
+type OpenStep struct {
+	Delay    time.Duration
+	Response *bookingpb.ExecuteOpenResponse
+	Err      error
+}
+
+// BookingServer es un Booking Manager falso con respuestas programables.
+// CheckBookingStatus y ExecuteOpen se indexan por código de apertura; DefaultKey responde al resto.
+// Al terminar el guion de ExecuteOpen el servidor cierra el stream (el cliente recibe EOF).
+type BookingServer struct {
+	bookingpb.UnimplementedBookingServiceServer
+	behavior
+
+	bookingStatuses responses[*bookingpb.CheckBookingStatusResponse]
+	openScripts     responses[[]OpenStep]
+}
+
+// NewBookingServer crea un Booking Manager falso sin respuestas programadas
+func NewBookingServer() *BookingServer {
+	return &BookingServer{}
+}
+
+// SetBookingStatus programa la respuesta de CheckBookingStatus para el código de apertura
+func (s *BookingServer) SetBookingStatus(currentCode string, response *bookingpb.CheckBookingStatusResponse) {
+	s.bookingStatuses.set(currentCode, response)
+}
+
+// SetOpenScript programa los estados que emite ExecuteOpen para el código de apertura
+func (s *BookingServer) SetOpenScript(currentCode string, steps ...OpenStep) {
+	s.openScripts.set(currentCode, append([]OpenStep(nil), steps...))
+}
+
+// Reset descarta las respuestas, los guiones, los errores inyectados, las latencias y las solicitudes registradas
+func (s *BookingServer) Reset() {
+	s.behavior.reset()
+	s.bookingStatuses.reset()
+	s.openScripts.reset()
+}
+
+// CheckBookingStatus implementa BookingServiceServer.CheckBookingStatus
+func (s *BookingServer) CheckBookingStatus(ctx context.Context, request *bookingpb.CheckBookingStatusRequest) (*bookingpb.CheckBookingStatusResponse, error) {
+	method := bookingpb.BookingService_CheckBookingStatus_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.bookingStatuses, method, request.GetCurrentCode())
+}
+
+// ExecuteOpen implementa BookingServiceServer.ExecuteOpen: lee la solicitud y reproduce el guion del código
+func (s *BookingServer) ExecuteOpen(stream grpc.BidiStreamingServer[bookingpb.ExecuteOpenRequest, bookingpb.ExecuteOpenResponse]) error {
+	method := bookingpb.BookingService_ExecuteOpen_FullMethodName
+	ctx := stream.Context()
+
+	request, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	if err := s.begin(ctx, method, request); err != nil {
+		return err
+	}
+
+	steps, err := s.openScripts.get(method, request.GetCurrentCode())
+	if err != nil {
+		return err
+	}
+	for _, step := range steps {
+		if err := wait(ctx, step.Delay); err != nil {
+			return err
+		}
+		if step.Err != nil {
+			return step.Err
+		}
+		if step.Response != nil {
+			if err := stream.Send(step.Response); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Asegurar que BookingServer implementa BookingServiceServer
+var _ bookingpb.BookingServiceServer = (*BookingServer)(nil)
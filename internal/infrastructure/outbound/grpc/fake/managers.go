@@ -0,0 +1,72 @@
+package fake
+
+import (
+	bookingpb "bff-graphql-payment/gen/go/proto/booking/v1"
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// bufferSize es el tamaño del buffer de la conexión en memoria
+const bufferSize = 1024 * 1024
+
+// Managers levanta Payment y Booking Manager falsos en un servidor gRPC en memoria (bufconn),
+// junto con el servicio estándar grpc.health.v1. Sirve para probar el cliente gRPC real sin red.
+type Managers struct {
+	Payment *PaymentServer
+	Booking *BookingServer
+	Health  *health.Server
+
+	server   *grpc.Server
+	listener *bufconn.Listener
+	done     chan struct{}
+}
+
+// NewManagers crea los servidores falsos y empieza a atender conexiones en memoria
+func NewManagers() *Managers {
+	managers := &Managers{
+		Payment:  NewPaymentServer(),
+		Booking:  NewBookingServer(),
+		Health:   health.NewServer(),
+		server:   grpc.NewServer(),
+		listener: bufconn.Listen(bufferSize),
+		done:     make(chan struct{}),
+	}
+	paymentpb.RegisterPaymentServiceServer(managers.server, managers.Payment)
+	bookingpb.RegisterBookingServiceServer(managers.server, managers.Booking)
+	healthpb.RegisterHealthServer(managers.server, managers.Health)
+
+	go func() {
+		defer close(managers.done)
+		_ = managers.server.Serve(managers.listener)
+	}()
+	return managers
+}
+
+// DialOptions devuelve las opciones que conectan un cliente gRPC a los servidores en memoria.
+// Cualquier dirección sirve; conviene usar el esquema passthrough (p. ej. "passthrough:///payment")
+// para que el cliente no intente resolverla por DNS.
+func (m *Managers) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return m.listener.DialContext(ctx)
+		}),
+	}
+}
+
+// Reset descarta lo programado en ambos servidores
+func (m *Managers) Reset() {
+	m.Payment.Reset()
+	m.Booking.Reset()
+}
+
+// Close detiene el servidor cortando las llamadas y streams en curso
+func (m *Managers) Close() {
+	m.server.Stop()
+	<-m.done
+}
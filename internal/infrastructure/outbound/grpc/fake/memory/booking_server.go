@@ -0,0 +1,148 @@
+package memory
+
+import (
+	bookingpb "bff-graphql-payment/gen/go/proto/booking/v1"
+	"context"
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// BookingServer es un Booking Manager respaldado por el almacén en memoria
+type BookingServer struct {
+	bookingpb.UnimplementedBookingServiceServer
+
+	store *Store
+	// stepDelay es la espera entre los estados que emite ExecuteOpen, simulando al dispositivo
+	stepDelay time.Duration
+	logger    *slog.Logger
+}
+
+// NewBookingServer crea el Booking Manager sobre el almacén indicado
+func NewBookingServer(store *Store, stepDelay time.Duration, logger *slog.Logger) *BookingServer {
+	return &BookingServer{
+		store:     store,
+		stepDelay: stepDelay,
+		logger:    logger.With("component", "fake-booking-manager"),
+	}
+}
+
+// CheckBookingStatus implementa BookingServiceServer.CheckBookingStatus
+func (s *BookingServer) CheckBookingStatus(ctx context.Context, request *bookingpb.CheckBookingStatusRequest) (*bookingpb.CheckBookingStatusResponse, error) {
+	booking, err := s.store.BookingByCode(request.GetCurrentCode())
+	if err != nil {
+		return nil, err
+	}
+	rack, err := s.store.Rack(booking.RackID)
+	if err != nil {
+		return nil, err
+	}
+	return &bookingpb.CheckBookingStatusResponse{
+		Response: &bookingpb.BookingManagerGenericResponse{
+			TransactionId: transactionID(),
+			Message:       "OK",
+			Status:        bookingpb.ResponseStatus_RESPONSE_STATUS_OK,
+		},
+		Booking: &bookingpb.BookingRecord{
+			Id:                     booking.ID,
+			ConfigurationBookingId: booking.GroupID,
+			InitBooking:            booking.InitBooking.Format(time.RFC3339),
+			FinishBooking:          booking.FinishBooking.Format(time.RFC3339),
+			InstallationName:       rack.Installation.GetName(),
+			NumberLocker:           booking.LockerPosition,
+			DeviceId:               rack.Device.GetName(),
+			CurrentCode:            booking.Code,
+			Openings:               booking.Openings,
+			ServiceName:            request.GetServiceName(),
+			EmailRecipient:         booking.Email,
+			CreatedAt:              booking.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:              booking.UpdatedAt.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// ExecuteOpen implementa BookingServiceServer.ExecuteOpen. Emite RECEIVED y REQUESTED y termina con SUCCESS,
+// o con ERROR si la reserva no está vigente o el dispositivo del rack está desconectado.
+func (s *BookingServer) ExecuteOpen(stream grpc.BidiStreamingServer[bookingpb.ExecuteOpenRequest, bookingpb.ExecuteOpenResponse]) error {
+	ctx := stream.Context()
+
+	request, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	booking, err := s.store.BookingByCode(request.GetCurrentCode())
+	if err != nil {
+		return err
+	}
+	rack, err := s.store.Rack(booking.RackID)
+	if err != nil {
+		return err
+	}
+
+	transactionID := transactionID()
+	send := func(openStatus bookingpb.OpenStatus, physicalStatus bookingpb.PhysicalStatus, message string) error {
+		return stream.Send(&bookingpb.ExecuteOpenResponse{
+			TransactionId:  transactionID,
+			Message:        message,
+			Status:         openStatus,
+			PhysicalStatus: physicalStatus,
+		})
+	}
+
+	if err := send(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING, "solicitud recibida"); err != nil {
+		return err
+	}
+	if err := s.wait(ctx); err != nil {
+		return err
+	}
+	if !booking.Active(time.Now()) {
+		return send(bookingpb.OpenStatus_OPEN_STATUS_ERROR, bookingpb.PhysicalStatus_PHYSICAL_STATUS_FAILED, "reserva no vigente")
+	}
+
+	if err := send(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING, "apertura solicitada al dispositivo"); err != nil {
+		return err
+	}
+	if err := s.wait(ctx); err != nil {
+		return err
+	}
+	if !rack.Device.GetOnline() {
+		return send(bookingpb.OpenStatus_OPEN_STATUS_ERROR, bookingpb.PhysicalStatus_PHYSICAL_STATUS_FAILED, "dispositivo desconectado")
+	}
+
+	if err := send(bookingpb.OpenStatus_OPEN_STATUS_EXECUTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING, "apertura ejecutada por el dispositivo"); err != nil {
+		return err
+	}
+	if err := s.wait(ctx); err != nil {
+		return err
+	}
+
+	booking, err = s.store.RegisterOpening(booking.Code)
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "locker opened",
+		"currentCode", booking.Code,
+		"rackId", booking.RackID,
+		"lockerPosition", booking.LockerPosition,
+		"openings", booking.Openings,
+	)
+	return send(bookingpb.OpenStatus_OPEN_STATUS_SUCCESS, bookingpb.PhysicalStatus_PHYSICAL_STATUS_SUCCESS, "locker abierto")
+}
+
+// wait espera stepDelay o hasta que el cliente cancele el stream
+func (s *BookingServer) wait(ctx context.Context) error {
+	timer := time.NewTimer(s.stepDelay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Asegurar que BookingServer implementa BookingServiceServer
+var _ bookingpb.BookingServiceServer = (*BookingServer)(nil)
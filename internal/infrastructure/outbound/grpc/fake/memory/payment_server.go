@@ -0,0 +1,178 @@
+package memory
+
+import (
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// PaymentServer es un Payment Manager respaldado por el almacén en memoria
+type PaymentServer struct {
+	paymentpb.UnimplementedPaymentServiceServer
+
+	store *Store
+	// paymentURL es la URL base de la pasarela simulada que se devuelve en las órdenes de compra
+	paymentURL string
+	logger     *slog.Logger
+}
+
+// NewPaymentServer crea el Payment Manager sobre el almacén indicado
+func NewPaymentServer(store *Store, paymentURL string, logger *slog.Logger) *PaymentServer {
+	return &PaymentServer{
+		store:      store,
+		paymentURL: paymentURL,
+		logger:     logger.With("component", "fake-payment-manager"),
+	}
+}
+
+// GetPaymentInfraByQrValue implementa PaymentServiceServer.GetPaymentInfraByQrValue
+func (s *PaymentServer) GetPaymentInfraByQrValue(ctx context.Context, request *paymentpb.GetPaymentInfraByQrValueRequest) (*paymentpb.GetPaymentInfraByQrValueResponse, error) {
+	rack, err := s.store.RackByQR(request.GetQrValue())
+	if err != nil {
+		return nil, err
+	}
+	return &paymentpb.GetPaymentInfraByQrValueResponse{
+		Response: okResponse(""),
+		PaymentRack: &paymentpb.RackRecord{
+			Id:          rack.ID,
+			Description: rack.Description,
+			Address:     rack.Address,
+		},
+		Installation: rack.Installation,
+		Device:       rack.Device,
+		BookingTimes: rack.BookingTimes,
+	}, nil
+}
+
+// GetAvailableLockersByRackIDAndBookingTime implementa PaymentServiceServer.GetAvailableLockersByRackIDAndBookingTime.
+// Solo se listan los grupos con al menos un locker libre.
+func (s *PaymentServer) GetAvailableLockersByRackIDAndBookingTime(ctx context.Context, request *paymentpb.GetAvailableLockersByRackIDAndBookingTimeRequest) (*paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse, error) {
+	groups, err := s.store.AvailableGroups(request.GetPaymentRackId())
+	if err != nil {
+		return nil, err
+	}
+	response := &paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse{Response: okResponse(request.GetTraceId())}
+	for _, group := range groups {
+		response.AvailableGroup = append(response.AvailableGroup, &paymentpb.AvailablePaymentGroupRecord{
+			GroupId:     group.ID,
+			Name:        group.Name,
+			Price:       group.Price,
+			Description: group.Description,
+			ImageUrl:    group.ImageURL,
+		})
+	}
+	return response, nil
+}
+
+// ValidateDiscountCoupon implementa PaymentServiceServer.ValidateDiscountCoupon
+func (s *PaymentServer) ValidateDiscountCoupon(ctx context.Context, request *paymentpb.ValidateDiscountCouponRequest) (*paymentpb.ValidateDiscountCouponResponse, error) {
+	coupon, err := s.store.ValidateCoupon(request.GetCouponCode(), request.GetRackId())
+	if err != nil {
+		return nil, err
+	}
+	return &paymentpb.ValidateDiscountCouponResponse{
+		Response:           okResponse(request.GetTraceId()),
+		DiscountPercentage: coupon.DiscountPercentage,
+	}, nil
+}
+
+// GeneratePurchaseOrder implementa PaymentServiceServer.GeneratePurchaseOrder. El pago se aprueba al instante
+// y la reserva resultante ocupa un locker; el código de apertura se informa en el log (en producción llega por email).
+func (s *PaymentServer) GeneratePurchaseOrder(ctx context.Context, request *paymentpb.GeneratePurchaseOrderRequest) (*paymentpb.GeneratePurchaseOrderResponse, error) {
+	order, booking, err := s.store.CreatePurchaseOrder(
+		request.GetRackIdReference(),
+		request.GetGroupId(),
+		request.GetCouponCode(),
+		request.GetUserEmail(),
+		request.GetUserPhone(),
+		request.GetGatewayName(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "purchase order paid",
+		"purchaseOrder", order.Code,
+		"rackId", order.RackID,
+		"lockerPosition", order.LockerPosition,
+		"finalPrice", order.FinalProductPrice,
+		"currentCode", booking.Code,
+	)
+	return &paymentpb.GeneratePurchaseOrderResponse{
+		Response: okResponse(request.GetTraceId()),
+		Url:      fmt.Sprintf("%s/%s", s.paymentURL, order.Code),
+	}, nil
+}
+
+// GenerateBooking implementa PaymentServiceServer.GenerateBooking
+func (s *PaymentServer) GenerateBooking(ctx context.Context, request *paymentpb.GenerateBookingRequest) (*paymentpb.GenerateBookingResponse, error) {
+	booking, err := s.store.CreateBooking(
+		request.GetRackIdReference(),
+		request.GetGroupId(),
+		request.GetCouponCode(),
+		request.GetUserEmail(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.InfoContext(ctx, "booking created",
+		"bookingId", booking.ID,
+		"rackId", booking.RackID,
+		"lockerPosition", booking.LockerPosition,
+		"currentCode", booking.Code,
+	)
+	return &paymentpb.GenerateBookingResponse{
+		Response: okResponse(request.GetTraceId()),
+		Code:     booking.Code,
+	}, nil
+}
+
+// GetPurchaseOrderByPo implementa PaymentServiceServer.GetPurchaseOrderByPo
+func (s *PaymentServer) GetPurchaseOrderByPo(ctx context.Context, request *paymentpb.GetPurchaseOrderByPoRequest) (*paymentpb.GetPurchaseOrderByPoResponse, error) {
+	order, err := s.store.PurchaseOrder(request.GetPurchaseOrder())
+	if err != nil {
+		return nil, err
+	}
+	rack, err := s.store.Rack(order.RackID)
+	if err != nil {
+		return nil, err
+	}
+	return &paymentpb.GetPurchaseOrderByPoResponse{
+		Response: okResponse(request.GetTraceId()),
+		PurchaseOrder: &paymentpb.PurchaseOrderRecord{
+			CouponId:           order.CouponID,
+			BookingReference:   order.BookingID,
+			Oc:                 order.Code,
+			Email:              order.Email,
+			Phone:              order.Phone,
+			Discount:           order.Discount,
+			ProductPrice:       order.ProductPrice,
+			FinalProductPrice:  order.FinalProductPrice,
+			ProductName:        order.ProductName,
+			ProductDescription: order.ProductDescription,
+			LockerPosition:     order.LockerPosition,
+			InstallationName:   rack.Installation.GetName(),
+			DeviceSerieNum:     rack.Device.GetName(),
+			Status:             order.Status,
+		},
+	}, nil
+}
+
+// okResponse crea la respuesta genérica exitosa con un ID de transacción nuevo
+func okResponse(traceID string) *paymentpb.PaymentManagerGenericResponse {
+	return &paymentpb.PaymentManagerGenericResponse{
+		TransactionId: transactionID(),
+		Message:       "OK",
+		Status:        paymentpb.ResponseStatus_RESPONSE_STATUS_OK,
+		TraceId:       traceID,
+	}
+}
+
+// transactionID genera un ID de transacción a partir de la hora actual
+func transactionID() string {
+	return "fake-" + time.Now().Format("20060102150405.000000")
+}
+
+// Asegurar que PaymentServer implementa PaymentServiceServer
+var _ paymentpb.PaymentServiceServer = (*PaymentServer)(nil)
@@ -0,0 +1,126 @@
+package memory
+
+import (
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"time"
+)
+
+// DefaultSeed devuelve el estado inicial de desarrollo. Las vigencias se calculan respecto de now.
+//
+//   - QR-DEV-001: rack 1 con lockers libres en tres tamaños
+//   - QR-DEV-002: rack 2 con el dispositivo desconectado (las aperturas fallan)
+//   - QR-DEV-003: rack 3 sin lockers libres
+//   - Códigos de apertura: 123456 (vigente), 654321 (vigente, rack 2), 111111 (ocupa el rack 3) y 000000 (vencido)
+//   - Cupones: DESCUENTO10, DESCUENTO20, GRATIS, VENCIDO (vencido) y SOLORACK1 (solo rack 1)
+func DefaultSeed(now time.Time) Seed {
+	bookingTimes := []*paymentpb.BookingTimeRecord{
+		{Id: 1, Name: "Express (1 día)", UnitMeasurement: paymentpb.UnitMeasurement_UNIT_MEASUREMENT_DAY, Amount: 1},
+		{Id: 2, Name: "Normal (3 días)", UnitMeasurement: paymentpb.UnitMeasurement_UNIT_MEASUREMENT_DAY, Amount: 3},
+	}
+
+	return Seed{
+		Racks: []Rack{
+			{
+				ID:          1,
+				QRValue:     "QR-DEV-001",
+				Description: "Rack Principal Chicureo",
+				Address:     "Chicureo",
+				Installation: &paymentpb.InstallationRecord{
+					Id: 1, Name: "DEV PAGO", Region: "Metropolitana", City: "Colina", Address: "Chicureo",
+					ImageUrl: "https://www.image.cl/image.jpg",
+				},
+				Device:       &paymentpb.DeviceRecord{Name: "DEV-001", Online: true, Brand: "Odihnx", Model: "L-24"},
+				BookingTimes: bookingTimes,
+				Groups: []LockerGroup{
+					{
+						ID: 1, Name: "Locker Pequeño", Price: 2000,
+						Description: "Locker de 30x30x40 cm - Ideal para paquetes pequeños",
+						ImageURL:    "https://www.image.cl/locker-small.jpg",
+						Positions:   []int32{1, 2, 3, 4},
+					},
+					{
+						ID: 2, Name: "Locker Mediano", Price: 3000,
+						Description: "Locker de 45x45x60 cm - Para paquetes medianos",
+						ImageURL:    "https://www.image.cl/locker-medium.jpg",
+						Positions:   []int32{5, 6, 7},
+					},
+					{
+						ID: 3, Name: "Locker Grande", Price: 4000,
+						Description: "Locker de 60x60x80 cm - Máxima capacidad",
+						ImageURL:    "https://www.image.cl/locker-large.jpg",
+						Positions:   []int32{8},
+					},
+				},
+			},
+			{
+				ID:          2,
+				QRValue:     "QR-DEV-002",
+				Description: "Rack Estacionamiento",
+				Address:     "Chicureo",
+				Installation: &paymentpb.InstallationRecord{
+					Id: 1, Name: "DEV PAGO", Region: "Metropolitana", City: "Colina", Address: "Chicureo",
+					ImageUrl: "https://www.image.cl/image.jpg",
+				},
+				Device:       &paymentpb.DeviceRecord{Name: "DEV-002", Online: false, Brand: "Odihnx", Model: "L-12"},
+				BookingTimes: bookingTimes,
+				Groups: []LockerGroup{
+					{
+						ID: 1, Name: "Locker Pequeño", Price: 2000,
+						Description: "Locker de 30x30x40 cm - Ideal para paquetes pequeños",
+						ImageURL:    "https://www.image.cl/locker-small.jpg",
+						Positions:   []int32{1, 2},
+					},
+				},
+			},
+			{
+				ID:          3,
+				QRValue:     "QR-DEV-003",
+				Description: "Rack Lleno",
+				Address:     "Providencia",
+				Installation: &paymentpb.InstallationRecord{
+					Id: 2, Name: "DEV PROVIDENCIA", Region: "Metropolitana", City: "Providencia", Address: "Providencia",
+					ImageUrl: "https://www.image.cl/image.jpg",
+				},
+				Device:       &paymentpb.DeviceRecord{Name: "DEV-003", Online: true, Brand: "Odihnx", Model: "L-12"},
+				BookingTimes: bookingTimes,
+				Groups: []LockerGroup{
+					{
+						ID: 1, Name: "Locker Pequeño", Price: 2000,
+						Description: "Locker de 30x30x40 cm - Ideal para paquetes pequeños",
+						ImageURL:    "https://www.image.cl/locker-small.jpg",
+						Positions:   []int32{1},
+					},
+				},
+			},
+		},
+		Coupons: []Coupon{
+			{ID: 1, Code: "DESCUENTO10", DiscountPercentage: 10},
+			{ID: 2, Code: "DESCUENTO20", DiscountPercentage: 20},
+			{ID: 3, Code: "GRATIS", DiscountPercentage: 100},
+			{ID: 4, Code: "VENCIDO", DiscountPercentage: 30, ExpiresAt: now.Add(-24 * time.Hour)},
+			{ID: 5, Code: "SOLORACK1", DiscountPercentage: 15, RackIDs: []int32{1}},
+		},
+		Bookings: []Booking{
+			{
+				ID: 1, RackID: 1, GroupID: 1, LockerPosition: 1, Code: "123456", Email: "usuario@odihnx.com", Openings: 2,
+				InitBooking: now.Add(-time.Hour), FinishBooking: now.Add(23 * time.Hour),
+				CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour),
+			},
+			{
+				ID: 2, RackID: 2, GroupID: 1, LockerPosition: 1, Code: "654321", Email: "usuario@odihnx.com",
+				InitBooking: now.Add(-time.Hour), FinishBooking: now.Add(23 * time.Hour),
+				CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour),
+			},
+			{
+				ID: 3, RackID: 3, GroupID: 1, LockerPosition: 1, Code: "111111", Email: "usuario@odihnx.com",
+				InitBooking: now.Add(-time.Hour), FinishBooking: now.Add(71 * time.Hour),
+				CreatedAt: now.Add(-time.Hour), UpdatedAt: now.Add(-time.Hour),
+			},
+			{
+				ID: 4, RackID: 1, GroupID: 2, LockerPosition: 5, Code: "000000", Email: "usuario@odihnx.com", Openings: 5,
+				InitBooking: now.Add(-72 * time.Hour), FinishBooking: now.Add(-48 * time.Hour),
+				CreatedAt: now.Add(-72 * time.Hour), UpdatedAt: now.Add(-48 * time.Hour),
+			},
+		},
+	}
+}
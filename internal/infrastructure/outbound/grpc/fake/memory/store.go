@@ -0,0 +1,392 @@
+package memory
+
+import (
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Estados de una orden de compra
+const (
+	PurchaseOrderPaid = "PAID"
+)
+
+// Rack es un rack de pagos con su instalación, su dispositivo y sus grupos de lockers
+type Rack struct {
+	ID           int32
+	QRValue      string
+	Description  string
+	Address      string
+	Installation *paymentpb.InstallationRecord
+	// Device.Online en false hace fallar las aperturas del rack
+	Device       *paymentpb.DeviceRecord
+	BookingTimes []*paymentpb.BookingTimeRecord
+	Groups       []LockerGroup
+}
+
+// LockerGroup es un grupo de lockers del mismo tamaño y precio
+type LockerGroup struct {
+	ID          int32
+	Name        string
+	Description string
+	ImageURL    string
+	Price       float32
+	// Positions son los números de los lockers del grupo
+	Positions []int32
+}
+
+// Coupon es un cupón de descuento
+type Coupon struct {
+	ID                 int32
+	Code               string
+	DiscountPercentage float64
+	// ExpiresAt vacío significa que el cupón no vence
+	ExpiresAt time.Time
+	// RackIDs limita el cupón a esos racks (vacío: todos)
+	RackIDs []int32
+}
+
+// PurchaseOrder es una orden de compra generada
+type PurchaseOrder struct {
+	Code               string
+	RackID             int32
+	GroupID            int32
+	CouponID           int32
+	Email              string
+	Phone              string
+	GatewayName        string
+	Discount           int32
+	ProductPrice       int32
+	FinalProductPrice  int64
+	ProductName        string
+	ProductDescription string
+	BookingID          int32
+	LockerPosition     int32
+	Status             string
+}
+
+// Booking es una reserva que ocupa un locker hasta FinishBooking
+type Booking struct {
+	ID             int32
+	RackID         int32
+	GroupID        int32
+	LockerPosition int32
+	Code           string
+	Email          string
+	Openings       int32
+	InitBooking    time.Time
+	FinishBooking  time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// Active indica si la reserva está vigente en el instante indicado
+func (b *Booking) Active(now time.Time) bool {
+	return !now.Before(b.InitBooking) && now.Before(b.FinishBooking)
+}
+
+// Seed es el estado inicial del almacén
+type Seed struct {
+	Racks   []Rack
+	Coupons []Coupon
+	// Bookings son reservas existentes; ocupan su locker mientras estén vigentes
+	Bookings []Booking
+}
+
+// Store guarda en memoria racks, lockers, cupones, órdenes de compra y reservas.
+// Las reservas ocupan un locker mientras están vigentes y cada apertura incrementa su contador.
+// Los errores son errores de status gRPC para que los servidores los devuelvan tal cual.
+type Store struct {
+	mu              sync.Mutex
+	racks           map[int32]*Rack
+	racksByQR       map[string]*Rack
+	coupons         map[string]*Coupon
+	purchaseOrders  map[string]*PurchaseOrder
+	bookings        map[int32]*Booking
+	bookingsByCode  map[string]*Booking
+	bookingDuration time.Duration
+	nextOrder       int
+	nextBooking     int32
+	now             func() time.Time
+}
+
+// NewStore crea el almacén con el estado inicial indicado.
+// bookingDuration es la vigencia de las reservas nuevas.
+func NewStore(seed Seed, bookingDuration time.Duration) *Store {
+	store := &Store{
+		racks:           make(map[int32]*Rack),
+		racksByQR:       make(map[string]*Rack),
+		coupons:         make(map[string]*Coupon),
+		purchaseOrders:  make(map[string]*PurchaseOrder),
+		bookings:        make(map[int32]*Booking),
+		bookingsByCode:  make(map[string]*Booking),
+		bookingDuration: bookingDuration,
+		now:             time.Now,
+	}
+	for i := range seed.Racks {
+		rack := seed.Racks[i]
+		store.racks[rack.ID] = &rack
+		store.racksByQR[rack.QRValue] = &rack
+	}
+	for i := range seed.Coupons {
+		coupon := seed.Coupons[i]
+		store.coupons[strings.ToUpper(coupon.Code)] = &coupon
+	}
+	for i := range seed.Bookings {
+		booking := seed.Bookings[i]
+		store.bookings[booking.ID] = &booking
+		store.bookingsByCode[booking.Code] = &booking
+		if booking.ID > store.nextBooking {
+			store.nextBooking = booking.ID
+		}
+	}
+	return store
+}
+
+// RackByQR devuelve una copia del rack asociado al QR
+func (s *Store) RackByQR(qrValue string) (Rack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rack, ok := s.racksByQR[qrValue]
+	if !ok {
+		return Rack{}, status.Errorf(codes.NotFound, "no rack for QR %q", qrValue)
+	}
+	return *rack, nil
+}
+
+// AvailableGroups devuelve los grupos del rack que tienen al menos un locker libre
+func (s *Store) AvailableGroups(rackID int32) ([]LockerGroup, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rack, ok := s.racks[rackID]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "rack %d not found", rackID)
+	}
+	occupied := s.occupiedLockers(rackID)
+	var groups []LockerGroup
+	for _, group := range rack.Groups {
+		if _, free := freeLocker(group, occupied); free {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// ValidateCoupon devuelve el cupón si existe, está vigente y aplica al rack
+func (s *Store) ValidateCoupon(couponCode string, rackID int32) (Coupon, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	coupon, err := s.validCoupon(couponCode, rackID)
+	if err != nil {
+		return Coupon{}, err
+	}
+	return *coupon, nil
+}
+
+// CreatePurchaseOrder genera una orden de compra. La pasarela se simula aprobando el pago al instante,
+// así que la orden queda pagada y reserva un locker del grupo.
+func (s *Store) CreatePurchaseOrder(rackID int32, groupID int32, couponCode string, email string, phone string, gatewayName string) (PurchaseOrder, Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var coupon *Coupon
+	if couponCode != "" {
+		var err error
+		if coupon, err = s.validCoupon(couponCode, rackID); err != nil {
+			return PurchaseOrder{}, Booking{}, err
+		}
+	}
+	booking, group, err := s.book(rackID, groupID, email)
+	if err != nil {
+		return PurchaseOrder{}, Booking{}, err
+	}
+
+	s.nextOrder++
+	order := &PurchaseOrder{
+		Code:               fmt.Sprintf("OC-%06d", s.nextOrder),
+		RackID:             rackID,
+		GroupID:            groupID,
+		Email:              email,
+		Phone:              phone,
+		GatewayName:        gatewayName,
+		ProductPrice:       int32(group.Price),
+		FinalProductPrice:  int64(group.Price),
+		ProductName:        group.Name,
+		ProductDescription: group.Description,
+		BookingID:          booking.ID,
+		LockerPosition:     booking.LockerPosition,
+		Status:             PurchaseOrderPaid,
+	}
+	if coupon != nil {
+		order.CouponID = coupon.ID
+		order.Discount = int32(coupon.DiscountPercentage)
+		order.FinalProductPrice = int64(float64(group.Price) * (100 - coupon.DiscountPercentage) / 100)
+	}
+	s.purchaseOrders[order.Code] = order
+	return *order, *booking, nil
+}
+
+// CreateBooking reserva un locker libre del grupo sin pasar por la pasarela de pago
+func (s *Store) CreateBooking(rackID int32, groupID int32, couponCode string, email string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if couponCode != "" {
+		if _, err := s.validCoupon(couponCode, rackID); err != nil {
+			return Booking{}, err
+		}
+	}
+	booking, _, err := s.book(rackID, groupID, email)
+	if err != nil {
+		return Booking{}, err
+	}
+	return *booking, nil
+}
+
+// PurchaseOrder devuelve la orden de compra por su código
+func (s *Store) PurchaseOrder(code string) (PurchaseOrder, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	order, ok := s.purchaseOrders[code]
+	if !ok {
+		return PurchaseOrder{}, status.Errorf(codes.NotFound, "purchase order %q not found", code)
+	}
+	return *order, nil
+}
+
+// BookingByCode devuelve la reserva asociada al código de apertura
+func (s *Store) BookingByCode(currentCode string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	booking, ok := s.bookingsByCode[currentCode]
+	if !ok {
+		return Booking{}, status.Errorf(codes.NotFound, "no booking for code %q", currentCode)
+	}
+	return *booking, nil
+}
+
+// RegisterOpening incrementa el contador de aperturas de la reserva
+func (s *Store) RegisterOpening(currentCode string) (Booking, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	booking, ok := s.bookingsByCode[currentCode]
+	if !ok {
+		return Booking{}, status.Errorf(codes.NotFound, "no booking for code %q", currentCode)
+	}
+	booking.Openings++
+	booking.UpdatedAt = s.now()
+	return *booking, nil
+}
+
+// Rack devuelve una copia del rack
+func (s *Store) Rack(rackID int32) (Rack, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rack, ok := s.racks[rackID]
+	if !ok {
+		return Rack{}, status.Errorf(codes.NotFound, "rack %d not found", rackID)
+	}
+	return *rack, nil
+}
+
+// validCoupon busca el cupón y verifica su vigencia y su rack (requiere s.mu tomado)
+func (s *Store) validCoupon(couponCode string, rackID int32) (*Coupon, error) {
+	coupon, ok := s.coupons[strings.ToUpper(strings.TrimSpace(couponCode))]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "coupon %q not found", couponCode)
+	}
+	if !coupon.ExpiresAt.IsZero() && !s.now().Before(coupon.ExpiresAt) {
+		return nil, status.Errorf(codes.FailedPrecondition, "coupon %q expired", couponCode)
+	}
+	if len(coupon.RackIDs) > 0 && !slices.Contains(coupon.RackIDs, rackID) {
+		return nil, status.Errorf(codes.FailedPrecondition, "coupon %q does not apply to rack %d", couponCode, rackID)
+	}
+	return coupon, nil
+}
+
+// book ocupa el primer locker libre del grupo con una reserva nueva (requiere s.mu tomado)
+func (s *Store) book(rackID int32, groupID int32, email string) (*Booking, LockerGroup, error) {
+	rack, ok := s.racks[rackID]
+	if !ok {
+		return nil, LockerGroup{}, status.Errorf(codes.NotFound, "rack %d not found", rackID)
+	}
+	var group *LockerGroup
+	for i := range rack.Groups {
+		if rack.Groups[i].ID == groupID {
+			group = &rack.Groups[i]
+		}
+	}
+	if group == nil {
+		return nil, LockerGroup{}, status.Errorf(codes.InvalidArgument, "group %d does not exist in rack %d", groupID, rackID)
+	}
+	position, free := freeLocker(*group, s.occupiedLockers(rackID))
+	if !free {
+		return nil, LockerGroup{}, status.Errorf(codes.FailedPrecondition, "no lockers available in group %d of rack %d", groupID, rackID)
+	}
+
+	now := s.now()
+	s.nextBooking++
+	booking := &Booking{
+		ID:             s.nextBooking,
+		RackID:         rackID,
+		GroupID:        groupID,
+		LockerPosition: position,
+		Code:           s.newCode(),
+		Email:          email,
+		InitBooking:    now,
+		FinishBooking:  now.Add(s.bookingDuration),
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	s.bookings[booking.ID] = booking
+	s.bookingsByCode[booking.Code] = booking
+	return booking, *group, nil
+}
+
+// occupiedLockers devuelve los lockers del rack con una reserva vigente (requiere s.mu tomado)
+func (s *Store) occupiedLockers(rackID int32) map[int32]bool {
+	now := s.now()
+	occupied := make(map[int32]bool)
+	for _, booking := range s.bookings {
+		if booking.RackID == rackID && booking.Active(now) {
+			occupied[booking.LockerPosition] = true
+		}
+	}
+	return occupied
+}
+
+// newCode genera un código de apertura de 6 dígitos que no esté en uso (requiere s.mu tomado)
+func (s *Store) newCode() string {
+	for {
+		code := fmt.Sprintf("%06d", rand.IntN(1000000))
+		if _, used := s.bookingsByCode[code]; !used {
+			return code
+		}
+	}
+}
+
+// freeLocker devuelve el primer locker del grupo que no está ocupado
+func freeLocker(group LockerGroup, occupied map[int32]bool) (int32, bool) {
+	positions := slices.Clone(group.Positions)
+	slices.Sort(positions)
+	for _, position := range positions {
+		if !occupied[position] {
+			return position, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,257 @@
+package memory
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const testBookingDuration = 24 * time.Hour
+
+// testClock es un reloj que el test controla
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time { return c.now }
+
+// newTestStore crea un almacén con dos racks y el reloj del test
+func newTestStore(t *testing.T, seed Seed) (*Store, *testClock) {
+	t.Helper()
+	if seed.Racks == nil {
+		seed.Racks = []Rack{
+			{
+				ID:      1,
+				QRValue: "QR-1",
+				Groups: []LockerGroup{
+					{ID: 10, Name: "Pequeño", Price: 2000, Positions: []int32{2, 1}},
+					{ID: 20, Name: "Grande", Price: 4000, Positions: []int32{3}},
+				},
+			},
+			{
+				ID:      2,
+				QRValue: "QR-2",
+				Groups:  []LockerGroup{{ID: 10, Name: "Pequeño", Price: 2000, Positions: []int32{1}}},
+			},
+		}
+	}
+	clock := &testClock{now: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewStore(seed, testBookingDuration)
+	store.now = clock.Now
+	return store, clock
+}
+
+// groupIDs devuelve los IDs de los grupos en orden
+func groupIDs(groups []LockerGroup) []int32 {
+	ids := make([]int32, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.ID)
+	}
+	return ids
+}
+
+func TestStoreLockerExhaustion(t *testing.T) {
+	store, clock := newTestStore(t, Seed{})
+
+	// Los lockers se asignan en orden de posición aunque el grupo los liste desordenados
+	for _, want := range []int32{1, 2} {
+		booking, err := store.CreateBooking(1, 10, "", "user@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if booking.LockerPosition != want {
+			t.Errorf("expected locker %d, got %d", want, booking.LockerPosition)
+		}
+	}
+
+	if _, err := store.CreateBooking(1, 10, "", "user@example.com"); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition once the group is full, got %v", err)
+	}
+	groups, err := store.AvailableGroups(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := groupIDs(groups); !slices.Equal(ids, []int32{20}) {
+		t.Errorf("expected only group 20 to be available, got %v", ids)
+	}
+
+	// Las reservas de un rack no ocupan lockers de otro
+	if groups, _ := store.AvailableGroups(2); !slices.Equal(groupIDs(groups), []int32{10}) {
+		t.Errorf("expected rack 2 to keep its lockers, got %v", groupIDs(groups))
+	}
+
+	// Al vencer las reservas los lockers vuelven a estar libres
+	clock.now = clock.now.Add(testBookingDuration)
+	if groups, _ := store.AvailableGroups(1); !slices.Equal(groupIDs(groups), []int32{10, 20}) {
+		t.Errorf("expected every group after the bookings expired, got %v", groupIDs(groups))
+	}
+	booking, err := store.CreateBooking(1, 10, "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.LockerPosition != 1 {
+		t.Errorf("expected the first locker to be reused, got %d", booking.LockerPosition)
+	}
+}
+
+func TestStoreSeedBookingsOccupyLockers(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	store, _ := newTestStore(t, Seed{
+		Racks: []Rack{{ID: 1, Groups: []LockerGroup{
+			{ID: 10, Positions: []int32{1}},
+			{ID: 20, Positions: []int32{2}},
+		}}},
+		Bookings: []Booking{
+			{ID: 7, RackID: 1, GroupID: 10, LockerPosition: 1, Code: "111111", InitBooking: now.Add(-time.Hour), FinishBooking: now.Add(time.Hour)},
+			{ID: 8, RackID: 1, GroupID: 20, LockerPosition: 2, Code: "222222", InitBooking: now.Add(-2 * time.Hour), FinishBooking: now.Add(-time.Hour)},
+		},
+	})
+
+	groups, err := store.AvailableGroups(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := groupIDs(groups); !slices.Equal(ids, []int32{20}) {
+		t.Errorf("expected the active seed booking to occupy group 10, got %v", ids)
+	}
+
+	// Las reservas nuevas continúan la numeración de las existentes
+	booking, err := store.CreateBooking(1, 20, "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.ID != 9 {
+		t.Errorf("expected booking id 9, got %d", booking.ID)
+	}
+}
+
+func TestStoreBookingErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		rackID   int32
+		groupID  int32
+		wantCode codes.Code
+	}{
+		{name: "unknown rack", rackID: 99, groupID: 10, wantCode: codes.NotFound},
+		{name: "group from another rack", rackID: 2, groupID: 20, wantCode: codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestStore(t, Seed{})
+			if _, err := store.CreateBooking(tt.rackID, tt.groupID, "", "user@example.com"); status.Code(err) != tt.wantCode {
+				t.Errorf("expected %s, got %v", tt.wantCode, err)
+			}
+		})
+	}
+
+	store, _ := newTestStore(t, Seed{})
+	if _, err := store.AvailableGroups(99); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown rack, got %v", err)
+	}
+}
+
+func TestStoreValidateCoupon(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	seed := Seed{Coupons: []Coupon{
+		{ID: 1, Code: "TODOS10", DiscountPercentage: 10},
+		{ID: 2, Code: "RACK1", DiscountPercentage: 20, RackIDs: []int32{1}},
+		{ID: 3, Code: "VENCIDO", DiscountPercentage: 30, ExpiresAt: now},
+		{ID: 4, Code: "VIGENTE", DiscountPercentage: 40, ExpiresAt: now.Add(time.Minute)},
+	}}
+
+	tests := []struct {
+		name     string
+		code     string
+		rackID   int32
+		wantID   int32
+		wantCode codes.Code
+	}{
+		{name: "unrestricted coupon", code: "TODOS10", rackID: 2, wantID: 1},
+		{name: "case and spaces are ignored", code: " todos10 ", rackID: 1, wantID: 1},
+		{name: "coupon for its rack", code: "RACK1", rackID: 1, wantID: 2},
+		{name: "coupon for another rack", code: "RACK1", rackID: 2, wantCode: codes.FailedPrecondition},
+		{name: "expired coupon", code: "VENCIDO", rackID: 1, wantCode: codes.FailedPrecondition},
+		{name: "coupon before its expiry", code: "VIGENTE", rackID: 1, wantID: 4},
+		{name: "unknown coupon", code: "NOEXISTE", rackID: 1, wantCode: codes.NotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, _ := newTestStore(t, seed)
+			coupon, err := store.ValidateCoupon(tt.code, tt.rackID)
+			if tt.wantCode != codes.OK {
+				if status.Code(err) != tt.wantCode {
+					t.Fatalf("expected %s, got %v", tt.wantCode, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if coupon.ID != tt.wantID {
+				t.Errorf("expected coupon %d, got %d", tt.wantID, coupon.ID)
+			}
+		})
+	}
+}
+
+func TestStorePurchaseOrderCoupon(t *testing.T) {
+	store, _ := newTestStore(t, Seed{Coupons: []Coupon{{ID: 2, Code: "RACK1", DiscountPercentage: 25, RackIDs: []int32{1}}}})
+
+	order, booking, err := store.CreatePurchaseOrder(1, 20, "RACK1", "user@example.com", "+56912345678", "webpay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.CouponID != 2 || order.Discount != 25 || order.ProductPrice != 4000 || order.FinalProductPrice != 3000 {
+		t.Errorf("unexpected discount in %+v", order)
+	}
+	if order.Status != PurchaseOrderPaid || order.BookingID != booking.ID || order.LockerPosition != 3 {
+		t.Errorf("expected a paid order for the booked locker, got %+v", order)
+	}
+
+	// Un cupón de otro rack rechaza la orden sin ocupar el locker
+	if _, _, err := store.CreatePurchaseOrder(2, 10, "RACK1", "user@example.com", "+56912345678", "webpay"); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition, got %v", err)
+	}
+	if groups, _ := store.AvailableGroups(2); !slices.Equal(groupIDs(groups), []int32{10}) {
+		t.Errorf("a rejected order must not occupy a locker, got %v", groupIDs(groups))
+	}
+}
+
+func TestStoreRegisterOpening(t *testing.T) {
+	store, clock := newTestStore(t, Seed{})
+	booking, err := store.CreateBooking(1, 10, "", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if booking.Openings != 0 {
+		t.Fatalf("expected a new booking without openings, got %d", booking.Openings)
+	}
+
+	for want := int32(1); want <= 2; want++ {
+		clock.now = clock.now.Add(time.Minute)
+		opened, err := store.RegisterOpening(booking.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened.Openings != want || !opened.UpdatedAt.Equal(clock.now) {
+			t.Errorf("expected %d openings updated at %s, got %d at %s", want, clock.now, opened.Openings, opened.UpdatedAt)
+		}
+	}
+
+	stored, err := store.BookingByCode(booking.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Openings != 2 {
+		t.Errorf("expected the counter to be stored, got %d", stored.Openings)
+	}
+
+	if _, err := store.RegisterOpening("000000-unknown"); status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an unknown code, got %v", err)
+	}
+}
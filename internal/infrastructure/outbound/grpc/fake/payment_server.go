@@ -0,0 +1,125 @@
+package fake
+
+import (
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"context"
+	"strconv"
+)
+
+// PaymentServer es un Payment Manager falso con respuestas programables.
+// Las respuestas se indexan por el valor que identifica la solicitud (QR, rack, cupón u orden de compra);
+// DefaultKey responde al resto y, sin respuesta programada, el método devuelve NotFound.
+type PaymentServer struct {
+	paymentpb.UnimplementedPaymentServiceServer
+	behavior
+
+	paymentInfra         responses[*paymentpb.GetPaymentInfraByQrValueResponse]
+	lockers              responses[*paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse]
+	coupons              responses[*paymentpb.ValidateDiscountCouponResponse]
+	purchaseOrders       responses[*paymentpb.GeneratePurchaseOrderResponse]
+	bookings             responses[*paymentpb.GenerateBookingResponse]
+	purchaseOrderLookups responses[*paymentpb.GetPurchaseOrderByPoResponse]
+}
+
+// NewPaymentServer crea un Payment Manager falso sin respuestas programadas
+func NewPaymentServer() *PaymentServer {
+	return &PaymentServer{}
+}
+
+// SetPaymentInfra programa la respuesta de GetPaymentInfraByQrValue para el QR
+func (s *PaymentServer) SetPaymentInfra(qrValue string, response *paymentpb.GetPaymentInfraByQrValueResponse) {
+	s.paymentInfra.set(qrValue, response)
+}
+
+// SetAvailableLockers programa la respuesta de GetAvailableLockersByRackIDAndBookingTime para el rack
+func (s *PaymentServer) SetAvailableLockers(paymentRackID string, response *paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse) {
+	s.lockers.set(paymentRackID, response)
+}
+
+// SetCoupon programa la respuesta de ValidateDiscountCoupon para el código de cupón
+func (s *PaymentServer) SetCoupon(couponCode string, response *paymentpb.ValidateDiscountCouponResponse) {
+	s.coupons.set(couponCode, response)
+}
+
+// SetPurchaseOrder programa la respuesta de GeneratePurchaseOrder para el rack
+func (s *PaymentServer) SetPurchaseOrder(rackIDReference string, response *paymentpb.GeneratePurchaseOrderResponse) {
+	s.purchaseOrders.set(rackIDReference, response)
+}
+
+// SetBooking programa la respuesta de GenerateBooking para el rack
+func (s *PaymentServer) SetBooking(rackIDReference string, response *paymentpb.GenerateBookingResponse) {
+	s.bookings.set(rackIDReference, response)
+}
+
+// SetPurchaseOrderLookup programa la respuesta de GetPurchaseOrderByPo para la orden de compra
+func (s *PaymentServer) SetPurchaseOrderLookup(purchaseOrder string, response *paymentpb.GetPurchaseOrderByPoResponse) {
+	s.purchaseOrderLookups.set(purchaseOrder, response)
+}
+
+// Reset descarta las respuestas, los errores inyectados, las latencias y las solicitudes registradas
+func (s *PaymentServer) Reset() {
+	s.behavior.reset()
+	s.paymentInfra.reset()
+	s.lockers.reset()
+	s.coupons.reset()
+	s.purchaseOrders.reset()
+	s.bookings.reset()
+	s.purchaseOrderLookups.reset()
+}
+
+// GetPaymentInfraByQrValue implementa PaymentServiceServer.GetPaymentInfraByQrValue
+func (s *PaymentServer) GetPaymentInfraByQrValue(ctx context.Context, request *paymentpb.GetPaymentInfraByQrValueRequest) (*paymentpb.GetPaymentInfraByQrValueResponse, error) {
+	method := paymentpb.PaymentService_GetPaymentInfraByQrValue_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.paymentInfra, method, request.GetQrValue())
+}
+
+// GetAvailableLockersByRackIDAndBookingTime implementa PaymentServiceServer.GetAvailableLockersByRackIDAndBookingTime
+func (s *PaymentServer) GetAvailableLockersByRackIDAndBookingTime(ctx context.Context, request *paymentpb.GetAvailableLockersByRackIDAndBookingTimeRequest) (*paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse, error) {
+	method := paymentpb.PaymentService_GetAvailableLockersByRackIDAndBookingTime_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.lockers, method, strconv.Itoa(int(request.GetPaymentRackId())))
+}
+
+// ValidateDiscountCoupon implementa PaymentServiceServer.ValidateDiscountCoupon
+func (s *PaymentServer) ValidateDiscountCoupon(ctx context.Context, request *paymentpb.ValidateDiscountCouponRequest) (*paymentpb.ValidateDiscountCouponResponse, error) {
+	method := paymentpb.PaymentService_ValidateDiscountCoupon_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.coupons, method, request.GetCouponCode())
+}
+
+// GeneratePurchaseOrder implementa PaymentServiceServer.GeneratePurchaseOrder
+func (s *PaymentServer) GeneratePurchaseOrder(ctx context.Context, request *paymentpb.GeneratePurchaseOrderRequest) (*paymentpb.GeneratePurchaseOrderResponse, error) {
+	method := paymentpb.PaymentService_GeneratePurchaseOrder_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.purchaseOrders, method, strconv.Itoa(int(request.GetRackIdReference())))
+}
+
+// GenerateBooking implementa PaymentServiceServer.GenerateBooking
+func (s *PaymentServer) GenerateBooking(ctx context.Context, request *paymentpb.GenerateBookingRequest) (*paymentpb.GenerateBookingResponse, error) {
+	method := paymentpb.PaymentService_GenerateBooking_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.bookings, method, strconv.Itoa(int(request.GetRackIdReference())))
+}
+
+// GetPurchaseOrderByPo implementa PaymentServiceServer.GetPurchaseOrderByPo
+func (s *PaymentServer) GetPurchaseOrderByPo(ctx context.Context, request *paymentpb.GetPurchaseOrderByPoRequest) (*paymentpb.GetPurchaseOrderByPoResponse, error) {
+	method := paymentpb.PaymentService_GetPurchaseOrderByPo_FullMethodName
+	if err := s.begin(ctx, method, request); err != nil {
+		return nil, err
+	}
+	return respond(&s.purchaseOrderLookups, method, request.GetPurchaseOrder())
+}
+
+// Asegurar que PaymentServer implementa PaymentServiceServer
+var _ paymentpb.PaymentServiceServer = (*PaymentServer)(nil)
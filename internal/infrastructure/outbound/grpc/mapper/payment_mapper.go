@@ -607,3 +607,51 @@ func (m *PaymentInfraGRPCMapper) FromGRPCCheckBookingStatusResponse(protoResp *b
 
 	return response
 }
+
+// FromGRPCGetPurchaseOrderByPoResponse mapea la respuesta proto de gRPC al DTO interno
+func (m *PaymentInfraGRPCMapper) FromGRPCGetPurchaseOrderByPoResponse(protoResp *paymentpb.GetPurchaseOrderByPoResponse) *dto.GetPurchaseOrderByPoResponse {
+	if protoResp == nil {
+		return nil
+	}
+
+	response := &dto.GetPurchaseOrderByPoResponse{}
+
+	// Mapear response metadata
+	if protoResp.Response != nil {
+		response.Response = &dto.PaymentManagerGenericResponse{
+			TransactionId: protoResp.Response.TransactionId,
+			Message:       protoResp.Response.Message,
+			Status:        dto.PaymentManagerResponseStatus(protoResp.Response.Status),
+			TraceId:       protoResp.Response.TraceId,
+		}
+	}
+
+	// Mapear PurchaseOrderRecord
+	response.PurchaseOrder = m.fromGRPCPurchaseOrderRecord(protoResp.PurchaseOrder)
+
+	return response
+}
+
+// fromGRPCPurchaseOrderRecord mapea el registro proto de orden de compra al DTO interno (nil-safe)
+func (m *PaymentInfraGRPCMapper) fromGRPCPurchaseOrderRecord(record *paymentpb.PurchaseOrderRecord) *dto.PurchaseOrderRecord {
+	if record == nil {
+		return nil
+	}
+
+	return &dto.PurchaseOrderRecord{
+		CouponId:           record.CouponId,
+		BookingReference:   record.BookingReference,
+		Oc:                 record.Oc,
+		Email:              record.Email,
+		Phone:              record.Phone,
+		Discount:           record.Discount,
+		ProductPrice:       record.ProductPrice,
+		FinalProductPrice:  record.FinalProductPrice,
+		ProductName:        record.ProductName,
+		ProductDescription: record.ProductDescription,
+		LockerPosition:     record.LockerPosition,
+		InstallationName:   record.InstallationName,
+		DeviceSerieNum:     record.DeviceSerieNum,
+		Status:             record.Status,
+	}
+}
@@ -0,0 +1,94 @@
+package idempotency
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval es cada cuánto se eliminan las claves expiradas
+const sweepInterval = time.Minute
+
+// memoryEntry es una clave almacenada con su fecha de expiración
+type memoryEntry struct {
+	record    ports.IdempotencyRecord
+	expiresAt time.Time
+}
+
+// MemoryStore implementa IdempotencyStore en memoria (válido para una sola instancia del BFF)
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+// NewMemoryStore crea un store de idempotencia en memoria
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:   make(map[string]*memoryEntry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Reserve implementa IdempotencyStore.Reserve
+func (s *MemoryStore) Reserve(ctx context.Context, key string, fingerprint string, ttl time.Duration) (*ports.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.sweep(now)
+
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expiresAt) {
+		record := entry.record
+		return &record, false, nil
+	}
+
+	s.entries[key] = &memoryEntry{
+		record:    ports.IdempotencyRecord{Fingerprint: fingerprint},
+		expiresAt: now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+// Complete implementa IdempotencyStore.Complete
+func (s *MemoryStore) Complete(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	entry.record.Completed = true
+	entry.record.Response = response
+	entry.expiresAt = time.Now().Add(ttl)
+	return nil
+}
+
+// Release implementa IdempotencyStore.Release
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && !entry.record.Completed {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep elimina las claves expiradas como máximo una vez por sweepInterval (requiere s.mu tomado)
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// Asegurar que MemoryStore implementa IdempotencyStore
+var _ ports.IdempotencyStore = (*MemoryStore)(nil)
@@ -0,0 +1,345 @@
+package mock
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"bff-graphql-payment/internal/domain/exception"
+	"bff-graphql-payment/internal/domain/model"
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultKey es la clave de la respuesta que se usa cuando ninguna otra coincide
+const DefaultKey = "*"
+
+// defaultFixtures son las respuestas del modo mock cuando no se configura un archivo
+//
+//go:embed default_fixtures.yaml
+var defaultFixtures []byte
+
+// Fixtures es el contenido de un archivo de fixtures (YAML o JSON).
+// Cada mapa se indexa por el valor que recibe la operación; DefaultKey responde al resto.
+type Fixtures struct {
+	// PaymentInfra responde getPaymentInfraByQrValue por valor QR
+	PaymentInfra map[string]PaymentInfraFixture `yaml:"paymentInfra"`
+	// Lockers responde getAvailableLockers por ID de rack
+	Lockers map[string]LockersFixture `yaml:"lockers"`
+	// Coupons responde validateDiscountCoupon por código de cupón
+	Coupons map[string]CouponFixture `yaml:"coupons"`
+	// PurchaseOrders responde generatePurchaseOrder por ID de rack
+	PurchaseOrders map[string]PurchaseOrderFixture `yaml:"purchaseOrders"`
+	// Bookings responde generateBooking por ID de rack
+	Bookings map[string]BookingFixture `yaml:"bookings"`
+	// PurchaseOrderLookups responde getPurchaseOrderByPo por orden de compra
+	PurchaseOrderLookups map[string]PurchaseOrderDataFixture `yaml:"purchaseOrderLookups"`
+	// UnlockCodes responde checkBookingStatus y executeOpen por código de apertura
+	UnlockCodes map[string]UnlockCodeFixture `yaml:"unlockCodes"`
+}
+
+// Response contiene los campos comunes a todas las respuestas simuladas
+type Response struct {
+	// Delay se espera antes de responder (respeta la cancelación del contexto)
+	Delay time.Duration `yaml:"delay"`
+	// Error es el código del error a devolver (p. ej. PAYMENT_RACK_NOT_FOUND); vacío responde con éxito
+	Error   string `yaml:"error"`
+	Message string `yaml:"message"`
+}
+
+// PaymentInfraFixture es la infraestructura de un QR
+type PaymentInfraFixture struct {
+	Response     `yaml:",inline"`
+	PaymentRack  *RackFixture         `yaml:"paymentRack"`
+	Installation *InstallationFixture `yaml:"installation"`
+	Device       *DeviceFixture       `yaml:"device"`
+	BookingTimes []BookingTimeFixture `yaml:"bookingTimes"`
+}
+
+// RackFixture es un rack de pagos
+type RackFixture struct {
+	ID          int    `yaml:"id"`
+	Description string `yaml:"description"`
+	Address     string `yaml:"address"`
+}
+
+// InstallationFixture es una instalación
+type InstallationFixture struct {
+	ID       int    `yaml:"id"`
+	Name     string `yaml:"name"`
+	Region   string `yaml:"region"`
+	City     string `yaml:"city"`
+	Address  string `yaml:"address"`
+	ImageURL string `yaml:"imageUrl"`
+}
+
+// DeviceFixture es el dispositivo del rack (online: false simula un dispositivo desconectado)
+type DeviceFixture struct {
+	Name   string `yaml:"name"`
+	Online bool   `yaml:"online"`
+	Brand  string `yaml:"brand"`
+	Model  string `yaml:"model"`
+}
+
+// BookingTimeFixture es un tiempo de reserva
+type BookingTimeFixture struct {
+	ID              int    `yaml:"id"`
+	Name            string `yaml:"name"`
+	UnitMeasurement string `yaml:"unitMeasurement"`
+	Amount          int    `yaml:"amount"`
+}
+
+// LockersFixture son los grupos de lockers disponibles de un rack (una lista vacía es válida)
+type LockersFixture struct {
+	Response `yaml:",inline"`
+	Groups   []LockerGroupFixture `yaml:"groups"`
+}
+
+// LockerGroupFixture es un grupo de lockers disponibles
+type LockerGroupFixture struct {
+	GroupID     int     `yaml:"groupId"`
+	Name        string  `yaml:"name"`
+	Price       float64 `yaml:"price"`
+	Description string  `yaml:"description"`
+	ImageURL    string  `yaml:"imageUrl"`
+}
+
+// CouponFixture es el resultado de validar un cupón
+type CouponFixture struct {
+	Response           `yaml:",inline"`
+	DiscountPercentage float64 `yaml:"discountPercentage"`
+}
+
+// PurchaseOrderFixture es una orden de compra generada
+type PurchaseOrderFixture struct {
+	Response `yaml:",inline"`
+	URL      string `yaml:"url"`
+}
+
+// BookingFixture es una reserva generada
+type BookingFixture struct {
+	Response `yaml:",inline"`
+	Code     string `yaml:"code"`
+}
+
+// PurchaseOrderDataFixture es una orden de compra consultada (la orden se toma de la solicitud)
+type PurchaseOrderDataFixture struct {
+	Response           `yaml:",inline"`
+	CouponID           int    `yaml:"couponId"`
+	BookingReference   int    `yaml:"bookingReference"`
+	Email              string `yaml:"email"`
+	Phone              string `yaml:"phone"`
+	Discount           int    `yaml:"discount"`
+	ProductPrice       int    `yaml:"productPrice"`
+	FinalProductPrice  int64  `yaml:"finalProductPrice"`
+	ProductName        string `yaml:"productName"`
+	ProductDescription string `yaml:"productDescription"`
+	LockerPosition     int    `yaml:"lockerPosition"`
+	InstallationName   string `yaml:"installationName"`
+	DeviceSerieNum     string `yaml:"deviceSerieNum"`
+	Status             string `yaml:"status"`
+}
+
+// UnlockCodeFixture es la reserva asociada a un código de apertura y la secuencia de su apertura
+type UnlockCodeFixture struct {
+	Response `yaml:",inline"`
+	Booking  *BookingStatusFixture `yaml:"booking"`
+	// Open es la secuencia de estados que emite executeOpen, cada uno tras su delay
+	Open []OpenStepFixture `yaml:"open"`
+}
+
+// BookingStatusFixture es una reserva vigente (fechas vacías se calculan respecto de ahora)
+type BookingStatusFixture struct {
+	ID                     int    `yaml:"id"`
+	ConfigurationBookingID int    `yaml:"configurationBookingId"`
+	InitBooking            string `yaml:"initBooking"`
+	FinishBooking          string `yaml:"finishBooking"`
+	InstallationName       string `yaml:"installationName"`
+	NumberLocker           int    `yaml:"numberLocker"`
+	DeviceID               string `yaml:"deviceId"`
+	Openings               int    `yaml:"openings"`
+	EmailRecipient         string `yaml:"emailRecipient"`
+}
+
+// OpenStepFixture es un estado de la secuencia de executeOpen
+type OpenStepFixture struct {
+	Delay          time.Duration `yaml:"delay"`
+	Status         string        `yaml:"status"`
+	PhysicalStatus string        `yaml:"physicalStatus"`
+	Message        string        `yaml:"message"`
+}
+
+// result convierte el paso en el resultado de dominio (sin estado físico es UNSPECIFIED)
+func (s OpenStepFixture) result() *model.ExecuteOpenResult {
+	physicalStatus := model.PhysicalStatus(s.PhysicalStatus)
+	if physicalStatus == "" {
+		physicalStatus = model.PhysicalStatusUnspecified
+	}
+	return &model.ExecuteOpenResult{
+		Message:        s.Message,
+		OpenStatus:     model.OpenStatus(s.Status),
+		PhysicalStatus: physicalStatus,
+	}
+}
+
+// fixtureErrors traduce los códigos de error de los fixtures a errores de dominio.
+// Los códigos coinciden con extensions.code de la API GraphQL.
+var fixtureErrors = map[string]error{
+	"PAYMENT_RACK_NOT_FOUND":    exception.ErrPaymentRackNotFound,
+	"INVALID_PAYMENT_RACK_ID":   exception.ErrInvalidPaymentRackID,
+	"UPSTREAM_UNAVAILABLE":      exception.ErrPaymentInfraServiceUnavailable,
+	"UPSTREAM_TIMEOUT":          exception.ErrPaymentInfraServiceTimeout,
+	"INVALID_BOOKING_TIME_ID":   exception.ErrInvalidBookingTimeID,
+	"NO_LOCKERS_AVAILABLE":      exception.ErrNoLockersAvailable,
+	"INVALID_COUPON_CODE":       exception.ErrInvalidCouponCode,
+	"COUPON_NOT_FOUND":          exception.ErrCouponNotFound,
+	"COUPON_INVALID":            exception.ErrInvalidCoupon,
+	"PURCHASE_ORDER_FAILED":     exception.ErrPurchaseOrderFailed,
+	"BOOKING_GENERATION_FAILED": exception.ErrBookingGenerationFailed,
+	"PURCHASE_ORDER_NOT_FOUND":  exception.ErrPurchaseOrderNotFound,
+	"BOOKING_NOT_FOUND":         exception.ErrBookingNotFound,
+	"EXECUTE_OPEN_FAILED":       exception.ErrExecuteOpenFailed,
+	"VALIDATION_FAILED":         appException.ErrValidationFailed,
+}
+
+// Valores aceptados en los campos enumerados de los fixtures
+var (
+	openStatuses = map[string]bool{
+		string(model.OpenStatusReceived):  true,
+		string(model.OpenStatusRequested): true,
+		string(model.OpenStatusExecuted):  true,
+		string(model.OpenStatusError):     true,
+		string(model.OpenStatusSuccess):   true,
+	}
+	physicalStatuses = map[string]bool{
+		string(model.PhysicalStatusUnspecified): true,
+		string(model.PhysicalStatusWaiting):     true,
+		string(model.PhysicalStatusSuccess):     true,
+		string(model.PhysicalStatusFailed):      true,
+		string(model.PhysicalStatusAlreadyOpen): true,
+		string(model.PhysicalStatusUnexpected):  true,
+	}
+	unitMeasurements = map[string]bool{
+		string(model.UnitMeasurementHour):  true,
+		string(model.UnitMeasurementDay):   true,
+		string(model.UnitMeasurementWeek):  true,
+		string(model.UnitMeasurementMonth): true,
+	}
+)
+
+// LoadFixtures lee y valida un archivo de fixtures. JSON se acepta porque es YAML válido.
+// Las claves desconocidas y los valores inválidos se reportan juntos.
+func LoadFixtures(path string) (*Fixtures, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mock fixtures: %w", err)
+	}
+	return ParseFixtures(data)
+}
+
+// ParseFixtures decodifica y valida el contenido de un archivo de fixtures
+func ParseFixtures(data []byte) (*Fixtures, error) {
+	fixtures := &Fixtures{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	// Un archivo vacío es válido: todas las operaciones responden "no encontrado"
+	if err := decoder.Decode(fixtures); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid mock fixtures: %w", err)
+	}
+	if err := fixtures.validate(); err != nil {
+		return nil, fmt.Errorf("invalid mock fixtures:\n%w", err)
+	}
+	return fixtures, nil
+}
+
+// validate revisa los códigos de error y los valores enumerados de todas las respuestas
+func (f *Fixtures) validate() error {
+	var errs []error
+	check := func(path string, response Response) {
+		if response.Error != "" && fixtureErrors[response.Error] == nil {
+			errs = append(errs, fmt.Errorf("%s.error: unknown error code %q", path, response.Error))
+		}
+		if response.Delay < 0 {
+			errs = append(errs, fmt.Errorf("%s.delay: must not be negative", path))
+		}
+	}
+
+	for _, key := range sortedKeys(f.PaymentInfra) {
+		fixture := f.PaymentInfra[key]
+		path := fmt.Sprintf("paymentInfra[%q]", key)
+		check(path, fixture.Response)
+		for i, bookingTime := range fixture.BookingTimes {
+			if !unitMeasurements[bookingTime.UnitMeasurement] {
+				errs = append(errs, fmt.Errorf("%s.bookingTimes[%d].unitMeasurement: unknown unit %q", path, i, bookingTime.UnitMeasurement))
+			}
+		}
+	}
+	for _, key := range sortedKeys(f.Lockers) {
+		check(fmt.Sprintf("lockers[%q]", key), f.Lockers[key].Response)
+	}
+	for _, key := range sortedKeys(f.Coupons) {
+		check(fmt.Sprintf("coupons[%q]", key), f.Coupons[key].Response)
+	}
+	for _, key := range sortedKeys(f.PurchaseOrders) {
+		check(fmt.Sprintf("purchaseOrders[%q]", key), f.PurchaseOrders[key].Response)
+	}
+	for _, key := range sortedKeys(f.Bookings) {
+		check(fmt.Sprintf("bookings[%q]", key), f.Bookings[key].Response)
+	}
+	for _, key := range sortedKeys(f.PurchaseOrderLookups) {
+		check(fmt.Sprintf("purchaseOrderLookups[%q]", key), f.PurchaseOrderLookups[key].Response)
+	}
+	for _, key := range sortedKeys(f.UnlockCodes) {
+		fixture := f.UnlockCodes[key]
+		path := fmt.Sprintf("unlockCodes[%q]", key)
+		check(path, fixture.Response)
+		// La secuencia debe respetar la máquina de estados: el servicio descartaría los pasos inválidos
+		stateMachine := model.NewOpenStateMachine()
+		sequenceValid := true
+		for i, step := range fixture.Open {
+			if !openStatuses[step.Status] {
+				errs = append(errs, fmt.Errorf("%s.open[%d].status: unknown open status %q", path, i, step.Status))
+				sequenceValid = false
+			}
+			if step.PhysicalStatus != "" && !physicalStatuses[step.PhysicalStatus] {
+				errs = append(errs, fmt.Errorf("%s.open[%d].physicalStatus: unknown physical status %q", path, i, step.PhysicalStatus))
+				sequenceValid = false
+			}
+			if step.Delay < 0 {
+				errs = append(errs, fmt.Errorf("%s.open[%d].delay: must not be negative", path, i))
+			}
+			if !sequenceValid {
+				continue
+			}
+			if err := stateMachine.Apply(step.result()); err != nil {
+				errs = append(errs, fmt.Errorf("%s.open[%d]: %w", path, i, err))
+				sequenceValid = false
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lookup devuelve la respuesta de la clave o, si no existe, la respuesta por defecto
+func lookup[T any](fixtures map[string]T, key string) (T, bool) {
+	if fixture, ok := fixtures[key]; ok {
+		return fixture, true
+	}
+	fixture, ok := fixtures[DefaultKey]
+	return fixture, ok
+}
+
+// sortedKeys devuelve las claves ordenadas para reportar los errores de forma estable
+func sortedKeys[T any](fixtures map[string]T) []string {
+	keys := make([]string, 0, len(fixtures))
+	for key := range fixtures {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+package mock
+
+import (
+	"bff-graphql-payment/internal/domain/exception"
+	"bff-graphql-payment/internal/domain/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFixtures(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantErrs []string
+	}{
+		{name: "empty file"},
+		{
+			name: "valid open sequence",
+			data: `
+unlockCodes:
+  ABC123:
+    open:
+      - { status: OPEN_STATUS_RECEIVED, physicalStatus: PHYSICAL_STATUS_WAITING }
+      - { delay: 10ms, status: OPEN_STATUS_REQUESTED }
+      - { delay: 10ms, status: OPEN_STATUS_EXECUTED, physicalStatus: PHYSICAL_STATUS_WAITING }
+      - { delay: 10ms, status: OPEN_STATUS_SUCCESS, physicalStatus: PHYSICAL_STATUS_ALREADY_OPEN }
+`,
+		},
+		{name: "JSON", data: `{"coupons": {"DESCUENTO10": {"discountPercentage": 10}}}`},
+		{name: "unknown top-level key", data: "coupon:\n  X: { discountPercentage: 10 }\n", wantErrs: []string{"field coupon not found"}},
+		{name: "unknown nested key", data: "coupons:\n  X: { discount: 10 }\n", wantErrs: []string{"field discount not found"}},
+		{name: "unknown error code", data: "lockers:\n  \"1\": { error: LOCKERS_GONE }\n", wantErrs: []string{`lockers["1"].error: unknown error code "LOCKERS_GONE"`}},
+		{name: "negative response delay", data: "bookings:\n  \"*\": { delay: -1s }\n", wantErrs: []string{`bookings["*"].delay: must not be negative`}},
+		{name: "invalid delay", data: "bookings:\n  \"*\": { delay: soon }\n", wantErrs: []string{"invalid mock fixtures"}},
+		{
+			name:     "unknown unit measurement",
+			data:     "paymentInfra:\n  QR1:\n    bookingTimes:\n      - { id: 1, unitMeasurement: YEAR }\n",
+			wantErrs: []string{`paymentInfra["QR1"].bookingTimes[0].unitMeasurement: unknown unit "YEAR"`},
+		},
+		{
+			name:     "unknown open status",
+			data:     "unlockCodes:\n  X:\n    open:\n      - { status: OPEN_STATUS_DONE }\n",
+			wantErrs: []string{`unlockCodes["X"].open[0].status: unknown open status "OPEN_STATUS_DONE"`},
+		},
+		{
+			name:     "unknown physical status",
+			data:     "unlockCodes:\n  X:\n    open:\n      - { status: OPEN_STATUS_RECEIVED, physicalStatus: PHYSICAL_STATUS_AJAR }\n",
+			wantErrs: []string{`unlockCodes["X"].open[0].physicalStatus: unknown physical status "PHYSICAL_STATUS_AJAR"`},
+		},
+		{
+			name:     "negative step delay",
+			data:     "unlockCodes:\n  X:\n    open:\n      - { delay: -5ms, status: OPEN_STATUS_RECEIVED }\n",
+			wantErrs: []string{`unlockCodes["X"].open[0].delay: must not be negative`},
+		},
+		{
+			name:     "sequence skips a status",
+			data:     "unlockCodes:\n  X:\n    open:\n      - { status: OPEN_STATUS_RECEIVED }\n      - { status: OPEN_STATUS_EXECUTED }\n",
+			wantErrs: []string{`unlockCodes["X"].open[1]: ` + exception.ErrInvalidOpenTransition.Error()},
+		},
+		{
+			name: "success with a failed door",
+			data: `
+unlockCodes:
+  X:
+    open:
+      - { status: OPEN_STATUS_RECEIVED }
+      - { status: OPEN_STATUS_REQUESTED }
+      - { status: OPEN_STATUS_EXECUTED }
+      - { status: OPEN_STATUS_SUCCESS, physicalStatus: PHYSICAL_STATUS_FAILED }
+`,
+			wantErrs: []string{`unlockCodes["X"].open[3]: ` + exception.ErrInvalidPhysicalStatus.Error()},
+		},
+		{
+			name: "every problem is reported",
+			data: `
+coupons:
+  A: { error: NOPE }
+  B: { delay: -1s }
+unlockCodes:
+  X:
+    open:
+      - { status: OPEN_STATUS_SUCCESS }
+`,
+			wantErrs: []string{
+				`coupons["A"].error: unknown error code "NOPE"`,
+				`coupons["B"].delay: must not be negative`,
+				`unlockCodes["X"].open[0]: ` + exception.ErrInvalidOpenTransition.Error(),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixtures, err := ParseFixtures([]byte(tt.data))
+			if len(tt.wantErrs) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if fixtures == nil {
+					t.Fatal("expected fixtures")
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("expected a validation error")
+			}
+			for _, want := range tt.wantErrs {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("expected %q in the error, got:\n%v", want, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseFixturesDecodesSteps(t *testing.T) {
+	fixtures, err := ParseFixtures([]byte(`
+unlockCodes:
+  X:
+    open:
+      - { delay: 1500ms, status: OPEN_STATUS_RECEIVED, message: Recibida }
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	step := fixtures.UnlockCodes["X"].Open[0]
+	if step.Delay != 1500*time.Millisecond || step.Message != "Recibida" {
+		t.Errorf("unexpected step %+v", step)
+	}
+	// Sin estado físico el paso se emite como UNSPECIFIED
+	if result := step.result(); result.PhysicalStatus != model.PhysicalStatusUnspecified {
+		t.Errorf("expected %s, got %s", model.PhysicalStatusUnspecified, result.PhysicalStatus)
+	}
+}
+
+func TestBundledFixturesAreValid(t *testing.T) {
+	if _, err := ParseFixtures(defaultFixtures); err != nil {
+		t.Errorf("default fixtures: %v", err)
+	}
+	if _, err := LoadFixtures("../../../../fixtures/mock.yaml"); err != nil {
+		t.Errorf("fixtures/mock.yaml: %v", err)
+	}
+}
+
+func TestLoadFixturesMissingFile(t *testing.T) {
+	_, err := LoadFixtures("does-not-exist.yaml")
+	if err == nil || !strings.Contains(err.Error(), "failed to read mock fixtures") {
+		t.Errorf("expected a read error, got %v", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	fixtures := map[string]CouponFixture{
+		"DESCUENTO10": {DiscountPercentage: 10},
+		DefaultKey:    {DiscountPercentage: 0},
+	}
+	if fixture, ok := lookup(fixtures, "DESCUENTO10"); !ok || fixture.DiscountPercentage != 10 {
+		t.Errorf("expected the exact key, got %+v", fixture)
+	}
+	if fixture, ok := lookup(fixtures, "OTRO"); !ok || fixture.DiscountPercentage != 0 {
+		t.Errorf("expected the default response, got %+v", fixture)
+	}
+	delete(fixtures, DefaultKey)
+	if _, ok := lookup(fixtures, "OTRO"); ok {
+		t.Error("expected no response without a default")
+	}
+}
@@ -0,0 +1,352 @@
+package mock
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"bff-graphql-payment/internal/domain/exception"
+	"bff-graphql-payment/internal/domain/model"
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// MockPaymentInfraRepository implementa PaymentInfraRepository con respuestas simuladas, sin
+// levantar Payment ni Booking Manager. Sin archivo usa los fixtures por defecto (default_fixtures.yaml);
+// con archivo permite simular escenarios (dispositivo desconectado, sin lockers, cupón vencido,
+// aperturas fallidas, latencia) y el archivo se recarga con Watch.
+type MockPaymentInfraRepository struct {
+	path     string
+	fixtures atomic.Pointer[Fixtures]
+	logger   *slog.Logger
+
+	stopWatch context.CancelFunc
+	watchDone chan struct{}
+}
+
+// NewMockPaymentInfraRepository crea el repositorio con el archivo de fixtures indicado
+// o, si path está vacío, con los fixtures por defecto
+func NewMockPaymentInfraRepository(path string, logger *slog.Logger) (*MockPaymentInfraRepository, error) {
+	var fixtures *Fixtures
+	var err error
+	if path == "" {
+		fixtures, err = ParseFixtures(defaultFixtures)
+	} else {
+		fixtures, err = LoadFixtures(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	repository := &MockPaymentInfraRepository{
+		path:   path,
+		logger: logger.With("component", "mock-repository"),
+	}
+	repository.fixtures.Store(fixtures)
+	return repository, nil
+}
+
+// Path devuelve la ruta del archivo de fixtures (vacía con los fixtures por defecto)
+func (r *MockPaymentInfraRepository) Path() string {
+	return r.path
+}
+
+// Reload vuelve a leer el archivo. Si es inválido se conservan los fixtures vigentes.
+func (r *MockPaymentInfraRepository) Reload() error {
+	if r.path == "" {
+		return nil
+	}
+	fixtures, err := LoadFixtures(r.path)
+	if err != nil {
+		r.logger.Error("mock fixtures reload failed, keeping current fixtures", "file", r.path, "error", err)
+		return err
+	}
+	r.fixtures.Store(fixtures)
+	r.logger.Info("mock fixtures reloaded", "file", r.path)
+	return nil
+}
+
+// GetPaymentInfraByQrValue implementa PaymentInfraRepository.GetPaymentInfraByQrValue
+func (r *MockPaymentInfraRepository) GetPaymentInfraByQrValue(ctx context.Context, qrValue string) (*model.PaymentInfra, error) {
+	fixture, ok := lookup(r.current().PaymentInfra, qrValue)
+	if !ok {
+		return nil, exception.ErrPaymentRackNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	infra := &model.PaymentInfra{
+		TransactionID: transactionID(),
+		Message:       messageOr(fixture.Message, "Success"),
+		Status:        model.ResponseStatusOK,
+		TraceID:       "trace-" + transactionID(),
+	}
+	if rack := fixture.PaymentRack; rack != nil {
+		infra.PaymentRack = &model.PaymentRack{ID: rack.ID, Description: rack.Description, Address: rack.Address}
+	}
+	if installation := fixture.Installation; installation != nil {
+		infra.Installation = &model.PaymentInstallation{
+			ID:       installation.ID,
+			Name:     installation.Name,
+			Region:   installation.Region,
+			City:     installation.City,
+			Address:  installation.Address,
+			ImageURL: installation.ImageURL,
+		}
+	}
+	if device := fixture.Device; device != nil {
+		infra.Device = &model.PaymentDevice{Name: device.Name, Online: device.Online, Brand: device.Brand, Model: device.Model}
+	}
+	for _, bookingTime := range fixture.BookingTimes {
+		infra.BookingTimes = append(infra.BookingTimes, model.PaymentBookingTime{
+			ID:              bookingTime.ID,
+			Name:            bookingTime.Name,
+			UnitMeasurement: model.UnitMeasurement(bookingTime.UnitMeasurement),
+			Amount:          bookingTime.Amount,
+		})
+	}
+	return infra, nil
+}
+
+// GetAvailableLockers implementa PaymentInfraRepository.GetAvailableLockers
+func (r *MockPaymentInfraRepository) GetAvailableLockers(ctx context.Context, paymentRackID int, bookingTimeID int, traceID string) (*model.AvailableLockers, error) {
+	fixture, ok := lookup(r.current().Lockers, strconv.Itoa(paymentRackID))
+	if !ok {
+		return nil, exception.ErrPaymentRackNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	lockers := &model.AvailableLockers{
+		TransactionID:   transactionID(),
+		Message:         messageOr(fixture.Message, "Success"),
+		Status:          model.ResponseStatusOK,
+		TraceID:         traceID,
+		AvailableGroups: []model.AvailablePaymentGroup{},
+	}
+	for _, group := range fixture.Groups {
+		lockers.AvailableGroups = append(lockers.AvailableGroups, model.AvailablePaymentGroup{
+			GroupID:     group.GroupID,
+			Name:        group.Name,
+			Price:       group.Price,
+			Description: group.Description,
+			ImageURL:    group.ImageURL,
+		})
+	}
+	return lockers, nil
+}
+
+// ValidateDiscountCoupon implementa PaymentInfraRepository.ValidateDiscountCoupon
+func (r *MockPaymentInfraRepository) ValidateDiscountCoupon(ctx context.Context, couponCode string, rackID int, traceID string) (*model.DiscountCouponValidation, error) {
+	fixture, ok := lookup(r.current().Coupons, couponCode)
+	if !ok {
+		return nil, exception.ErrCouponNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	return &model.DiscountCouponValidation{
+		TransactionID:      transactionID(),
+		Message:            messageOr(fixture.Message, "Coupon validation completed"),
+		Status:             model.ResponseStatusOK,
+		TraceID:            traceID,
+		DiscountPercentage: fixture.DiscountPercentage,
+	}, nil
+}
+
+// GeneratePurchaseOrder implementa PaymentInfraRepository.GeneratePurchaseOrder
+func (r *MockPaymentInfraRepository) GeneratePurchaseOrder(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string, gatewayName string) (*model.PurchaseOrder, error) {
+	fixture, ok := lookup(r.current().PurchaseOrders, strconv.Itoa(rackIdReference))
+	if !ok {
+		return nil, exception.ErrPaymentRackNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	return &model.PurchaseOrder{
+		TransactionID: transactionID(),
+		Message:       messageOr(fixture.Message, "Purchase order generated successfully"),
+		Status:        model.ResponseStatusOK,
+		TraceID:       traceID,
+		URL:           fixture.URL,
+	}, nil
+}
+
+// GenerateBooking implementa PaymentInfraRepository.GenerateBooking
+func (r *MockPaymentInfraRepository) GenerateBooking(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string) (*model.Booking, error) {
+	fixture, ok := lookup(r.current().Bookings, strconv.Itoa(rackIdReference))
+	if !ok {
+		return nil, exception.ErrPaymentRackNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	return &model.Booking{
+		TransactionID: transactionID(),
+		Message:       messageOr(fixture.Message, "Reserva generada exitosamente"),
+		Status:        model.ResponseStatusOK,
+		TraceID:       traceID,
+		Code:          fixture.Code,
+	}, nil
+}
+
+// GetPurchaseOrderByPo implementa PaymentInfraRepository.GetPurchaseOrderByPo
+func (r *MockPaymentInfraRepository) GetPurchaseOrderByPo(ctx context.Context, purchaseOrder string, traceID string) (*model.PurchaseOrderData, error) {
+	fixture, ok := lookup(r.current().PurchaseOrderLookups, purchaseOrder)
+	if !ok {
+		return nil, exception.ErrPurchaseOrderNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	return &model.PurchaseOrderData{
+		TransactionID:      transactionID(),
+		Message:            messageOr(fixture.Message, "Orden de compra encontrada"),
+		Status:             model.ResponseStatusOK,
+		TraceID:            traceID,
+		CouponID:           fixture.CouponID,
+		BookingReference:   fixture.BookingReference,
+		OC:                 purchaseOrder,
+		Email:              fixture.Email,
+		Phone:              fixture.Phone,
+		Discount:           fixture.Discount,
+		ProductPrice:       fixture.ProductPrice,
+		FinalProductPrice:  fixture.FinalProductPrice,
+		ProductName:        fixture.ProductName,
+		ProductDescription: fixture.ProductDescription,
+		LockerPosition:     fixture.LockerPosition,
+		InstallationName:   fixture.InstallationName,
+		DeviceSerieNum:     fixture.DeviceSerieNum,
+		OrderStatus:        fixture.Status,
+	}, nil
+}
+
+// CheckBookingStatus implementa PaymentInfraRepository.CheckBookingStatus
+func (r *MockPaymentInfraRepository) CheckBookingStatus(ctx context.Context, serviceName string, currentCode string) (*model.BookingStatusCheck, error) {
+	fixture, ok := lookup(r.current().UnlockCodes, currentCode)
+	if !ok || fixture.Booking == nil {
+		return nil, exception.ErrBookingNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	booking := fixture.Booking
+	return &model.BookingStatusCheck{
+		TransactionID: transactionID(),
+		Message:       messageOr(fixture.Message, "Success"),
+		Status:        model.ResponseStatusOK,
+		Booking: &model.BookingStatusData{
+			ID:                     booking.ID,
+			ConfigurationBookingID: booking.ConfigurationBookingID,
+			InitBooking:            messageOr(booking.InitBooking, now.Add(-24*time.Hour).Format(time.RFC3339)),
+			FinishBooking:          messageOr(booking.FinishBooking, now.Add(24*time.Hour).Format(time.RFC3339)),
+			InstallationName:       booking.InstallationName,
+			NumberLocker:           booking.NumberLocker,
+			DeviceID:               booking.DeviceID,
+			CurrentCode:            currentCode,
+			Openings:               booking.Openings,
+			ServiceName:            serviceName,
+			EmailRecipient:         booking.EmailRecipient,
+			CreatedAt:              now.Add(-48 * time.Hour).Format(time.RFC3339),
+			UpdatedAt:              now.Format(time.RFC3339),
+		},
+	}, nil
+}
+
+// ExecuteOpenStream implementa PaymentInfraRepository.ExecuteOpenStream emitiendo la secuencia
+// "open" del código de apertura. Cada estado se emite tras su delay; el stream termina al agotar
+// la secuencia (aunque no tenga estado final) o al cancelarse el contexto.
+func (r *MockPaymentInfraRepository) ExecuteOpenStream(ctx context.Context, serviceName string, currentCode string) (<-chan *model.ExecuteOpenResult, error) {
+	fixture, ok := lookup(r.current().UnlockCodes, currentCode)
+	if !ok {
+		return nil, exception.ErrBookingNotFound
+	}
+	if err := respond(ctx, fixture.Response); err != nil {
+		return nil, err
+	}
+
+	r.logger.InfoContext(ctx, "mock ExecuteOpen sequence starting", "currentCode", currentCode, "steps", len(fixture.Open))
+
+	// La secuencia se copia al iniciar: una recarga no altera los streams en curso
+	steps := append([]OpenStepFixture(nil), fixture.Open...)
+	resultChan := make(chan *model.ExecuteOpenResult, len(steps))
+	txID := "mock-tx-" + transactionID()
+
+	go func() {
+		defer close(resultChan)
+		for _, step := range steps {
+			if err := wait(ctx, step.Delay); err != nil {
+				return
+			}
+			result := step.result()
+			result.TransactionID = txID
+			select {
+			case resultChan <- result:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return resultChan, nil
+}
+
+// current devuelve los fixtures vigentes
+func (r *MockPaymentInfraRepository) current() *Fixtures {
+	return r.fixtures.Load()
+}
+
+// respond espera el delay de la respuesta y devuelve su error, si tiene
+func respond(ctx context.Context, response Response) error {
+	if err := wait(ctx, response.Delay); err != nil {
+		return err
+	}
+	if response.Error == "" {
+		return nil
+	}
+	err := fixtureErrors[response.Error]
+	if response.Message != "" {
+		return fmt.Errorf("%w: %s", err, response.Message)
+	}
+	return err
+}
+
+// wait espera la duración indicada o hasta que se cancele el contexto
+func wait(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// messageOr devuelve el valor o, si está vacío, el valor por defecto
+func messageOr(value string, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// transactionID genera un ID de transacción simulado
+func transactionID() string {
+	return time.Now().Format("20060102150405")
+}
+
+// Asegurar que MockPaymentInfraRepository implementa PaymentInfraRepository
+var _ ports.PaymentInfraRepository = (*MockPaymentInfraRepository)(nil)
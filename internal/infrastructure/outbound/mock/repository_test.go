@@ -0,0 +1,188 @@
+package mock
+
+import (
+	"bff-graphql-payment/internal/domain/exception"
+	"bff-graphql-payment/internal/domain/model"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// newTestRepository crea el repositorio con los fixtures indicados escritos en un archivo temporal
+func newTestRepository(t *testing.T, content string) *MockPaymentInfraRepository {
+	t.Helper()
+	repository, err := NewMockPaymentInfraRepository(writeFixtures(t, content), discardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+func TestExecuteOpenStreamScript(t *testing.T) {
+	const stepDelay = 20 * time.Millisecond
+	repository := newTestRepository(t, `
+unlockCodes:
+  ABC123:
+    open:
+      - { status: OPEN_STATUS_RECEIVED, physicalStatus: PHYSICAL_STATUS_WAITING, message: Recibida }
+      - { delay: 20ms, status: OPEN_STATUS_REQUESTED, physicalStatus: PHYSICAL_STATUS_WAITING, message: Enviada }
+      - { delay: 20ms, status: OPEN_STATUS_EXECUTED, physicalStatus: PHYSICAL_STATUS_WAITING, message: Ejecutada }
+      - { delay: 20ms, status: OPEN_STATUS_ERROR, physicalStatus: PHYSICAL_STATUS_FAILED, message: Puerta trabada }
+`)
+
+	started := time.Now()
+	stream, err := repository.ExecuteOpenStream(context.Background(), "booking", "ABC123")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []*model.ExecuteOpenResult
+	for result := range stream {
+		got = append(got, result)
+	}
+	elapsed := time.Since(started)
+
+	want := []struct {
+		status         model.OpenStatus
+		physicalStatus model.PhysicalStatus
+		message        string
+	}{
+		{model.OpenStatusReceived, model.PhysicalStatusWaiting, "Recibida"},
+		{model.OpenStatusRequested, model.PhysicalStatusWaiting, "Enviada"},
+		{model.OpenStatusExecuted, model.PhysicalStatusWaiting, "Ejecutada"},
+		{model.OpenStatusError, model.PhysicalStatusFailed, "Puerta trabada"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d statuses, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].OpenStatus != w.status || got[i].PhysicalStatus != w.physicalStatus || got[i].Message != w.message {
+			t.Errorf("step %d: expected %s/%s %q, got %s/%s %q", i, w.status, w.physicalStatus, w.message,
+				got[i].OpenStatus, got[i].PhysicalStatus, got[i].Message)
+		}
+		// Todos los estados de una apertura comparten el ID de transacción
+		if !strings.HasPrefix(got[i].TransactionID, "mock-tx-") || got[i].TransactionID != got[0].TransactionID {
+			t.Errorf("step %d: unexpected transaction id %q", i, got[i].TransactionID)
+		}
+	}
+	if elapsed < 3*stepDelay {
+		t.Errorf("expected the step delays to be honoured, the sequence took %s", elapsed)
+	}
+}
+
+func TestExecuteOpenStreamCancelled(t *testing.T) {
+	repository := newTestRepository(t, `
+unlockCodes:
+  "*":
+    open:
+      - { status: OPEN_STATUS_RECEIVED }
+      - { delay: 1m, status: OPEN_STATUS_REQUESTED }
+`)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := repository.ExecuteOpenStream(ctx, "booking", "ABC123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result := <-stream; result.OpenStatus != model.OpenStatusReceived {
+		t.Fatalf("expected RECEIVED, got %s", result.OpenStatus)
+	}
+
+	cancel()
+	select {
+	case result, ok := <-stream:
+		if ok {
+			t.Errorf("expected the stream to close, got %s", result.OpenStatus)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream not closed after cancelling the context")
+	}
+}
+
+func TestMockRepositoryErrors(t *testing.T) {
+	repository := newTestRepository(t, `
+coupons:
+  VENCIDO: { error: COUPON_INVALID, message: El cupón venció }
+unlockCodes:
+  BLOQUEADO: { error: EXECUTE_OPEN_FAILED }
+`)
+
+	tests := []struct {
+		name    string
+		call    func() error
+		wantErr error
+		wantMsg string
+	}{
+		{
+			name: "fixture error with message",
+			call: func() error {
+				_, err := repository.ValidateDiscountCoupon(context.Background(), "VENCIDO", 1, "trace")
+				return err
+			},
+			wantErr: exception.ErrInvalidCoupon,
+			wantMsg: "El cupón venció",
+		},
+		{
+			name: "unknown coupon without default",
+			call: func() error {
+				_, err := repository.ValidateDiscountCoupon(context.Background(), "OTRO", 1, "trace")
+				return err
+			},
+			wantErr: exception.ErrCouponNotFound,
+		},
+		{
+			name: "open fails before the stream starts",
+			call: func() error {
+				_, err := repository.ExecuteOpenStream(context.Background(), "booking", "BLOQUEADO")
+				return err
+			},
+			wantErr: exception.ErrExecuteOpenFailed,
+		},
+		{
+			name: "unknown unlock code",
+			call: func() error {
+				_, err := repository.ExecuteOpenStream(context.Background(), "booking", "OTRO")
+				return err
+			},
+			wantErr: exception.ErrBookingNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("expected %q in the error, got %v", tt.wantMsg, err)
+			}
+		})
+	}
+}
+
+func TestMockRepositoryDefaultFixtures(t *testing.T) {
+	repository, err := NewMockPaymentInfraRepository("", discardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	coupon, err := repository.ValidateDiscountCoupon(context.Background(), "DESCUENTO20", 1, "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if coupon.DiscountPercentage != 20 || coupon.TraceID != "trace" {
+		t.Errorf("unexpected coupon validation %+v", coupon)
+	}
+	// Sin archivo no hay nada que recargar ni observar
+	if err := repository.Reload(); err != nil {
+		t.Errorf("unexpected reload error: %v", err)
+	}
+	if err := repository.Watch(); err != nil {
+		t.Errorf("unexpected watch error: %v", err)
+	}
+}
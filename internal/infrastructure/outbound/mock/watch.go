@@ -0,0 +1,87 @@
+package mock
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// reloadDebounce agrupa los eventos del archivo (los editores escriben en varios pasos)
+const reloadDebounce = 300 * time.Millisecond
+
+// Watch recarga los fixtures cada vez que cambia el contenido del archivo. Se detiene con Close.
+// Con los fixtures por defecto no hay nada que observar.
+func (r *MockPaymentInfraRepository) Watch() error {
+	if r.path == "" {
+		return nil
+	}
+	// El hash se toma antes de observar: un cambio escrito mientras arranca la goroutine no se pierde
+	lastHash := fileHash(r.path)
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch mock fixtures: %w", err)
+	}
+	// Se observa el directorio para detectar reemplazos atómicos y symlinks de ConfigMaps
+	if err := watcher.Add(filepath.Dir(r.path)); err != nil {
+		watcher.Close()
+		return fmt.Errorf("failed to watch mock fixtures: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.stopWatch = cancel
+	r.watchDone = make(chan struct{})
+
+	go func() {
+		defer close(r.watchDone)
+		defer watcher.Close()
+
+		debounce := time.NewTimer(reloadDebounce)
+		debounce.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-watcher.Events:
+				debounce.Reset(reloadDebounce)
+			case <-debounce.C:
+				// Solo recargar si el contenido cambió (evita recargas por eventos de otros archivos)
+				if hash := fileHash(r.path); hash != nil && !bytes.Equal(hash, lastHash) {
+					lastHash = hash
+					_ = r.Reload()
+				}
+			case err := <-watcher.Errors:
+				r.logger.Warn("mock fixtures watcher error", "error", err)
+			}
+		}
+	}()
+
+	r.logger.Info("watching mock fixtures for changes", "file", r.path)
+	return nil
+}
+
+// Close deja de observar el archivo de fixtures
+func (r *MockPaymentInfraRepository) Close() error {
+	if r.stopWatch != nil {
+		r.stopWatch()
+		<-r.watchDone
+		r.stopWatch = nil
+	}
+	return nil
+}
+
+// fileHash devuelve el hash del contenido del archivo (nil si no existe o no se puede leer)
+func fileHash(path string) []byte {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	hash := sha256.Sum256(data)
+	return hash[:]
+}
@@ -0,0 +1,124 @@
+package mock
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFixtures escribe el archivo de fixtures en un directorio temporal
+func writeFixtures(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "mock.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// discountFor devuelve el descuento que el mock responde hoy para el cupón
+func discountFor(t *testing.T, repository *MockPaymentInfraRepository, code string) float64 {
+	t.Helper()
+	coupon, err := repository.ValidateDiscountCoupon(context.Background(), code, 1, "trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return coupon.DiscountPercentage
+}
+
+// waitForDiscount espera a que la recarga en segundo plano aplique el descuento esperado
+func waitForDiscount(t *testing.T, repository *MockPaymentInfraRepository, code string, want float64) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for discountFor(t, repository, code) != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected discount %v after the reload, got %v", want, discountFor(t, repository, code))
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWatchReloadsOnChange(t *testing.T) {
+	path := writeFixtures(t, "coupons:\n  PROMO: { discountPercentage: 10 }\n")
+	repository, err := NewMockPaymentInfraRepository(path, discardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+
+	if err := os.WriteFile(path, []byte("coupons:\n  PROMO: { discountPercentage: 30 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitForDiscount(t, repository, "PROMO", 30)
+
+	// Un reemplazo atómico (como el de los ConfigMaps) también se detecta
+	replacement := filepath.Join(filepath.Dir(path), "mock.yaml.tmp")
+	if err := os.WriteFile(replacement, []byte("coupons:\n  PROMO: { discountPercentage: 40 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(replacement, path); err != nil {
+		t.Fatal(err)
+	}
+	waitForDiscount(t, repository, "PROMO", 40)
+}
+
+func TestWatchKeepsFixturesWhenInvalid(t *testing.T) {
+	path := writeFixtures(t, "coupons:\n  PROMO: { discountPercentage: 10 }\n")
+	repository, err := NewMockPaymentInfraRepository(path, discardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	defer repository.Close()
+
+	if err := os.WriteFile(path, []byte("coupons:\n  PROMO: { error: NOPE }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Reload(); err == nil {
+		t.Fatal("expected the invalid fixtures to be rejected")
+	}
+	// Se espera más que el debounce para que la recarga en segundo plano también lo descarte
+	time.Sleep(2 * reloadDebounce)
+	if got := discountFor(t, repository, "PROMO"); got != 10 {
+		t.Errorf("expected the previous fixtures to be kept, got discount %v", got)
+	}
+
+	// Al corregir el archivo la recarga vuelve a aplicarse
+	if err := os.WriteFile(path, []byte("coupons:\n  PROMO: { discountPercentage: 25 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	waitForDiscount(t, repository, "PROMO", 25)
+}
+
+func TestCloseStopsWatching(t *testing.T) {
+	path := writeFixtures(t, "coupons:\n  PROMO: { discountPercentage: 10 }\n")
+	repository, err := NewMockPaymentInfraRepository(path, discardLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Watch(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repository.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Cerrar dos veces no bloquea
+	if err := repository.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte("coupons:\n  PROMO: { discountPercentage: 30 }\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * reloadDebounce)
+	if got := discountFor(t, repository, "PROMO"); got != 10 {
+		t.Errorf("expected no reload after Close, got discount %v", got)
+	}
+}
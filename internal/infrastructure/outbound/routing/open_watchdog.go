@@ -0,0 +1,151 @@
+package routing
+
+import (
+	"bff-graphql-payment/internal/domain/model"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Fases de ExecuteOpen vigiladas por el watchdog (se usan en los logs y en las métricas)
+const (
+	OpenPhaseReceived = "received"
+	OpenPhaseTerminal = "terminal"
+	OpenPhaseStream   = "stream"
+)
+
+// openTimeoutMessages es el mensaje que recibe el frontend cuando vence cada fase
+var openTimeoutMessages = map[string]string{
+	OpenPhaseReceived: "El dispositivo no confirmó la recepción de la solicitud de apertura",
+	OpenPhaseTerminal: "El dispositivo no informó el resultado de la apertura a tiempo",
+	OpenPhaseStream:   "La apertura superó el tiempo máximo permitido",
+}
+
+// errOpenPhaseTimeout es la causa con la que el watchdog cancela el stream upstream al vencer un plazo.
+// Envuelve context.DeadlineExceeded para que el circuit breaker lo cuente como falla del upstream.
+var errOpenPhaseTimeout = fmt.Errorf("ExecuteOpen phase timed out: %w", context.DeadlineExceeded)
+
+// OpenTimeouts define los plazos de cada fase de ExecuteOpen (0 desactiva el plazo)
+type OpenTimeouts struct {
+	// Received es el tiempo máximo desde que se abre el stream hasta el primer estado (RECEIVED)
+	Received time.Duration
+	// Terminal es el tiempo máximo desde REQUESTED hasta SUCCESS o ERROR
+	Terminal time.Duration
+	// Stream es la duración máxima del stream completo
+	Stream time.Duration
+}
+
+// watchOpenStream reenvía los estados de la apertura vigilando los plazos de cada fase.
+// Si un plazo vence emite OPEN_STATUS_ERROR con PHYSICAL_STATUS_UNEXPECTED (no se sabe si la puerta
+// se abrió), cancela el stream upstream con cancel (causa errOpenPhaseTimeout) y cierra el canal de salida.
+func watchOpenStream(ctx context.Context, cancel context.CancelCauseFunc, timeouts OpenTimeouts, upstream <-chan *model.ExecuteOpenResult, logger *slog.Logger) <-chan *model.ExecuteOpenResult {
+	watched := make(chan *model.ExecuteOpenResult, cap(upstream))
+
+	go func() {
+		defer close(watched)
+		defer cancel(nil)
+		// Descartar lo que el upstream emita tras cancelarlo, sin bloquear al productor
+		defer func() {
+			go func() {
+				for range upstream {
+				}
+			}()
+		}()
+
+		started := time.Now()
+		deadlines := map[string]time.Time{}
+		if timeouts.Received > 0 {
+			deadlines[OpenPhaseReceived] = started.Add(timeouts.Received)
+		}
+		if timeouts.Stream > 0 {
+			deadlines[OpenPhaseStream] = started.Add(timeouts.Stream)
+		}
+
+		timer := time.NewTimer(time.Hour)
+		defer timer.Stop()
+
+		transactionID := ""
+		terminated := false
+		for {
+			phase, deadline := nextDeadline(deadlines)
+			timer.Stop()
+			var expired <-chan time.Time
+			if phase != "" {
+				timer.Reset(time.Until(deadline))
+				expired = timer.C
+			}
+
+			select {
+			case result, ok := <-upstream:
+				if !ok {
+					return
+				}
+				if result == nil {
+					continue
+				}
+				if result.TransactionID != "" {
+					transactionID = result.TransactionID
+				}
+
+				// Cualquier estado confirma la recepción; REQUESTED inicia el plazo del resultado
+				delete(deadlines, OpenPhaseReceived)
+				switch {
+				case result.OpenStatus.IsTerminal():
+					terminated = true
+					delete(deadlines, OpenPhaseTerminal)
+				case result.OpenStatus == model.OpenStatusRequested && timeouts.Terminal > 0:
+					if _, running := deadlines[OpenPhaseTerminal]; !running {
+						deadlines[OpenPhaseTerminal] = time.Now().Add(timeouts.Terminal)
+					}
+				}
+
+				select {
+				case watched <- result:
+				case <-ctx.Done():
+					return
+				}
+
+			case <-expired:
+				// Tras el estado final solo se espera el cierre del upstream hasta el plazo del stream
+				if terminated {
+					return
+				}
+				telemetry.ObserveExecuteOpenTimeout(phase)
+				cancel(fmt.Errorf("%w (%s)", errOpenPhaseTimeout, phase))
+				logger.WarnContext(ctx, "ExecuteOpenStream phase timed out, cancelling stream",
+					"phase", phase,
+					"elapsed", time.Since(started).Round(time.Millisecond),
+				)
+				select {
+				case watched <- &model.ExecuteOpenResult{
+					TransactionID:  transactionID,
+					Message:        openTimeoutMessages[phase],
+					OpenStatus:     model.OpenStatusError,
+					PhysicalStatus: model.PhysicalStatusUnexpected,
+				}:
+				case <-ctx.Done():
+				}
+				return
+
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return watched
+}
+
+// nextDeadline devuelve la fase cuyo plazo vence primero ("" si no hay plazos activos)
+func nextDeadline(deadlines map[string]time.Time) (string, time.Time) {
+	var phase string
+	var earliest time.Time
+	for candidate, deadline := range deadlines {
+		if phase == "" || deadline.Before(earliest) {
+			phase, earliest = candidate, deadline
+		}
+	}
+	return phase, earliest
+}
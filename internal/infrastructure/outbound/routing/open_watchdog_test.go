@@ -0,0 +1,181 @@
+package routing
+
+import (
+	"bff-graphql-payment/internal/domain/model"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.DiscardHandler)
+
+// timedResult es un estado que el upstream emite tras delay
+type timedResult struct {
+	delay  time.Duration
+	status model.OpenStatus
+}
+
+// produce emite los estados con sus demoras y, si holdOpen, deja el stream abierto hasta que se cancele
+func produce(ctx context.Context, steps []timedResult, holdOpen bool) <-chan *model.ExecuteOpenResult {
+	upstream := make(chan *model.ExecuteOpenResult)
+	go func() {
+		defer close(upstream)
+		for _, step := range steps {
+			select {
+			case <-time.After(step.delay):
+			case <-ctx.Done():
+				return
+			}
+			physicalStatus := model.PhysicalStatusWaiting
+			if step.status == model.OpenStatusSuccess {
+				physicalStatus = model.PhysicalStatusSuccess
+			}
+			select {
+			case upstream <- &model.ExecuteOpenResult{TransactionID: "tx-1", OpenStatus: step.status, PhysicalStatus: physicalStatus}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if holdOpen {
+			<-ctx.Done()
+		}
+	}()
+	return upstream
+}
+
+func TestWatchOpenStreamTimeouts(t *testing.T) {
+	const short = 50 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		timeouts    OpenTimeouts
+		steps       []timedResult
+		holdOpen    bool
+		want        []model.OpenStatus
+		wantMessage string
+	}{
+		{
+			name:        "no status received",
+			timeouts:    OpenTimeouts{Received: short, Terminal: time.Minute, Stream: time.Minute},
+			holdOpen:    true,
+			want:        []model.OpenStatus{model.OpenStatusError},
+			wantMessage: openTimeoutMessages[OpenPhaseReceived],
+		},
+		{
+			name:     "device never reports the result",
+			timeouts: OpenTimeouts{Received: time.Minute, Terminal: short, Stream: time.Minute},
+			steps: []timedResult{
+				{status: model.OpenStatusReceived},
+				{status: model.OpenStatusRequested},
+			},
+			holdOpen:    true,
+			want:        []model.OpenStatus{model.OpenStatusReceived, model.OpenStatusRequested, model.OpenStatusError},
+			wantMessage: openTimeoutMessages[OpenPhaseTerminal],
+		},
+		{
+			name:     "terminal deadline starts at REQUESTED",
+			timeouts: OpenTimeouts{Received: time.Minute, Terminal: short, Stream: time.Minute},
+			steps: []timedResult{
+				{status: model.OpenStatusReceived},
+				{delay: 2 * short, status: model.OpenStatusRequested},
+				{status: model.OpenStatusExecuted},
+				{status: model.OpenStatusSuccess},
+			},
+			want: []model.OpenStatus{model.OpenStatusReceived, model.OpenStatusRequested, model.OpenStatusExecuted, model.OpenStatusSuccess},
+		},
+		{
+			name:     "whole stream too long",
+			timeouts: OpenTimeouts{Received: time.Minute, Terminal: time.Minute, Stream: short},
+			steps: []timedResult{
+				{status: model.OpenStatusReceived},
+			},
+			holdOpen:    true,
+			want:        []model.OpenStatus{model.OpenStatusReceived, model.OpenStatusError},
+			wantMessage: openTimeoutMessages[OpenPhaseStream],
+		},
+		{
+			name:     "stream completes in time",
+			timeouts: OpenTimeouts{Received: time.Minute, Terminal: time.Minute, Stream: time.Minute},
+			steps: []timedResult{
+				{status: model.OpenStatusReceived},
+				{status: model.OpenStatusRequested},
+				{status: model.OpenStatusExecuted},
+				{status: model.OpenStatusSuccess},
+			},
+			want: []model.OpenStatus{model.OpenStatusReceived, model.OpenStatusRequested, model.OpenStatusExecuted, model.OpenStatusSuccess},
+		},
+		{
+			name:     "upstream left open after the terminal status",
+			timeouts: OpenTimeouts{Received: time.Minute, Terminal: time.Minute, Stream: short},
+			steps: []timedResult{
+				{status: model.OpenStatusReceived},
+				{status: model.OpenStatusRequested},
+				{status: model.OpenStatusExecuted},
+				{status: model.OpenStatusSuccess},
+			},
+			holdOpen: true,
+			want:     []model.OpenStatus{model.OpenStatusReceived, model.OpenStatusRequested, model.OpenStatusExecuted, model.OpenStatusSuccess},
+		},
+		{
+			name:     "disabled timeouts",
+			timeouts: OpenTimeouts{},
+			steps: []timedResult{
+				{delay: 2 * short, status: model.OpenStatusReceived},
+				{status: model.OpenStatusRequested},
+			},
+			want: []model.OpenStatus{model.OpenStatusReceived, model.OpenStatusRequested},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamCtx, cancelStream := context.WithCancelCause(context.Background())
+			defer cancelStream(nil)
+			watched := watchOpenStream(context.Background(), cancelStream, tt.timeouts, produce(streamCtx, tt.steps, tt.holdOpen), discardLogger)
+
+			var got []*model.ExecuteOpenResult
+			deadline := time.After(2 * time.Second)
+		collect:
+			for {
+				select {
+				case result, ok := <-watched:
+					if !ok {
+						break collect
+					}
+					got = append(got, result)
+				case <-deadline:
+					t.Fatalf("watched stream never closed, got %d statuses", len(got))
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %d statuses", tt.want, len(got))
+			}
+			for i, status := range tt.want {
+				if got[i].OpenStatus != status {
+					t.Errorf("status %d: expected %s, got %s", i, status, got[i].OpenStatus)
+				}
+			}
+
+			last := got[len(got)-1]
+			if tt.wantMessage != "" {
+				if last.PhysicalStatus != model.PhysicalStatusUnexpected || last.Message != tt.wantMessage {
+					t.Errorf("expected UNEXPECTED with %q, got %s with %q", tt.wantMessage, last.PhysicalStatus, last.Message)
+				}
+				if len(got) > 1 && last.TransactionID != "tx-1" {
+					t.Errorf("expected the upstream transaction id, got %q", last.TransactionID)
+				}
+			}
+			// Solo un plazo vencido cancela con una causa que el circuit breaker cuenta como falla
+			if cut := errors.Is(context.Cause(streamCtx), context.DeadlineExceeded); cut != (tt.wantMessage != "") {
+				t.Errorf("unexpected cancel cause %v", context.Cause(streamCtx))
+			}
+			// El watchdog siempre cancela el stream upstream al terminar
+			if streamCtx.Err() == nil {
+				t.Error("expected the upstream stream to be cancelled")
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package routing
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"bff-graphql-payment/internal/domain/model"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Adaptadores disponibles para cada upstream
+const (
+	AdapterGRPC = "grpc"
+	AdapterMock = "mock"
+)
+
+// Selection indica el adaptador que atiende cada upstream
+type Selection struct {
+	// Payment atiende infraestructura, lockers, cupones, órdenes de compra y reservas
+	Payment string
+	// Booking atiende checkBookingStatus y executeOpen
+	Booking string
+}
+
+// Repository implementa PaymentInfraRepository enrutando cada operación al adaptador elegido
+// para su upstream (p. ej. Payment Manager real y Booking Manager simulado). La selección se
+// puede cambiar en caliente. También vigila los plazos de ExecuteOpen de cualquier adaptador.
+type Repository struct {
+	// adapters se reemplaza completo al agregar un adaptador: las lecturas no toman locks
+	adapters     atomic.Pointer[map[string]ports.PaymentInfraRepository]
+	adaptersMu   sync.Mutex
+	selection    atomic.Pointer[Selection]
+	openTimeouts OpenTimeouts
+	logger       *slog.Logger
+}
+
+// NewRepository crea el repositorio con los adaptadores disponibles (indexados por AdapterGRPC o AdapterMock)
+func NewRepository(adapters map[string]ports.PaymentInfraRepository, selection Selection, openTimeouts OpenTimeouts, logger *slog.Logger) (*Repository, error) {
+	repository := &Repository{
+		openTimeouts: openTimeouts,
+		logger:       logger.With("component", "upstream-router"),
+	}
+	repository.adapters.Store(&adapters)
+	if err := repository.Select(selection); err != nil {
+		return nil, err
+	}
+	return repository, nil
+}
+
+// Select cambia el adaptador de cada upstream. Las operaciones en curso terminan en el adaptador anterior.
+func (r *Repository) Select(selection Selection) error {
+	adapters := *r.adapters.Load()
+	for upstream, adapter := range map[string]string{client.UpstreamPayment: selection.Payment, client.UpstreamBooking: selection.Booking} {
+		if adapters[adapter] == nil {
+			return fmt.Errorf("no %q adapter available for the %s upstream", adapter, upstream)
+		}
+	}
+	if previous := r.selection.Swap(&selection); previous != nil && *previous != selection {
+		r.logger.Info("upstream adapters changed", "payment", selection.Payment, "booking", selection.Booking)
+	}
+	return nil
+}
+
+// AddAdapter registra un adaptador creado después del arranque (p. ej. el mock al pasar a él en caliente).
+// Debe llamarse antes de Select con la selección que lo usa.
+func (r *Repository) AddAdapter(name string, adapter ports.PaymentInfraRepository) {
+	r.adaptersMu.Lock()
+	defer r.adaptersMu.Unlock()
+
+	adapters := make(map[string]ports.PaymentInfraRepository, len(*r.adapters.Load())+1)
+	for existing, repository := range *r.adapters.Load() {
+		adapters[existing] = repository
+	}
+	adapters[name] = adapter
+	r.adapters.Store(&adapters)
+}
+
+// Adapters devuelve el adaptador vigente de cada upstream
+func (r *Repository) Adapters() map[string]string {
+	selection := r.selection.Load()
+	return map[string]string{
+		client.UpstreamPayment: selection.Payment,
+		client.UpstreamBooking: selection.Booking,
+	}
+}
+
+// GetPaymentInfraByQrValue implementa PaymentInfraRepository.GetPaymentInfraByQrValue
+func (r *Repository) GetPaymentInfraByQrValue(ctx context.Context, qrValue string) (*model.PaymentInfra, error) {
+	return r.payment().GetPaymentInfraByQrValue(ctx, qrValue)
+}
+
+// GetAvailableLockers implementa PaymentInfraRepository.GetAvailableLockers
+func (r *Repository) GetAvailableLockers(ctx context.Context, paymentRackID int, bookingTimeID int, traceID string) (*model.AvailableLockers, error) {
+	return r.payment().GetAvailableLockers(ctx, paymentRackID, bookingTimeID, traceID)
+}
+
+// ValidateDiscountCoupon implementa PaymentInfraRepository.ValidateDiscountCoupon
+func (r *Repository) ValidateDiscountCoupon(ctx context.Context, couponCode string, rackID int, traceID string) (*model.DiscountCouponValidation, error) {
+	return r.payment().ValidateDiscountCoupon(ctx, couponCode, rackID, traceID)
+}
+
+// GeneratePurchaseOrder implementa PaymentInfraRepository.GeneratePurchaseOrder
+func (r *Repository) GeneratePurchaseOrder(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string, gatewayName string) (*model.PurchaseOrder, error) {
+	return r.payment().GeneratePurchaseOrder(ctx, rackIdReference, groupID, couponCode, userEmail, userPhone, traceID, gatewayName)
+}
+
+// GenerateBooking implementa PaymentInfraRepository.GenerateBooking (lo expone Payment Manager)
+func (r *Repository) GenerateBooking(ctx context.Context, rackIdReference int, groupID int, couponCode *string, userEmail string, userPhone string, traceID string) (*model.Booking, error) {
+	return r.payment().GenerateBooking(ctx, rackIdReference, groupID, couponCode, userEmail, userPhone, traceID)
+}
+
+// GetPurchaseOrderByPo implementa PaymentInfraRepository.GetPurchaseOrderByPo
+func (r *Repository) GetPurchaseOrderByPo(ctx context.Context, purchaseOrder string, traceID string) (*model.PurchaseOrderData, error) {
+	return r.payment().GetPurchaseOrderByPo(ctx, purchaseOrder, traceID)
+}
+
+// CheckBookingStatus implementa PaymentInfraRepository.CheckBookingStatus
+func (r *Repository) CheckBookingStatus(ctx context.Context, serviceName string, currentCode string) (*model.BookingStatusCheck, error) {
+	return r.booking().CheckBookingStatus(ctx, serviceName, currentCode)
+}
+
+// ExecuteOpenStream implementa PaymentInfraRepository.ExecuteOpenStream.
+// El watchdog corta el stream con OPEN_STATUS_ERROR si vence el plazo de alguna fase (ver OpenTimeouts).
+func (r *Repository) ExecuteOpenStream(ctx context.Context, serviceName string, currentCode string) (<-chan *model.ExecuteOpenResult, error) {
+	// streamCtx permite al watchdog cancelar el stream upstream al vencer un plazo
+	streamCtx, cancelStream := context.WithCancelCause(ctx)
+
+	upstream, err := r.booking().ExecuteOpenStream(streamCtx, serviceName, currentCode)
+	if err != nil {
+		cancelStream(nil)
+		return nil, err
+	}
+	return watchOpenStream(ctx, cancelStream, r.openTimeouts, upstream, r.logger), nil
+}
+
+// payment devuelve el adaptador vigente de Payment Manager
+func (r *Repository) payment() ports.PaymentInfraRepository {
+	return (*r.adapters.Load())[r.selection.Load().Payment]
+}
+
+// booking devuelve el adaptador vigente de Booking Manager
+func (r *Repository) booking() ports.PaymentInfraRepository {
+	return (*r.adapters.Load())[r.selection.Load().Booking]
+}
+
+// Asegurar que Repository implementa PaymentInfraRepository
+var _ ports.PaymentInfraRepository = (*Repository)(nil)
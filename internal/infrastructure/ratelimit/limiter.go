@@ -0,0 +1,175 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// sweepInterval es cada cuánto se eliminan los clientes inactivos
+const sweepInterval = time.Minute
+
+// Policy define el presupuesto de una operación por cliente
+type Policy struct {
+	// RequestsPerMinute es la tasa sostenida permitida por clave
+	RequestsPerMinute int
+	// Burst es la cantidad de solicitudes permitidas de forma consecutiva
+	Burst int
+	// MaxFailures es la cantidad de fallas dentro de FailureWindow que bloquea la clave (0 desactiva el bloqueo)
+	MaxFailures int
+	// FailureWindow es la ventana en la que se cuentan las fallas consecutivas
+	FailureWindow time.Duration
+	// LockoutDuration es el tiempo que la clave queda bloqueada tras superar MaxFailures
+	LockoutDuration time.Duration
+}
+
+// Decision es el resultado de evaluar una solicitud
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	// Locked indica que la solicitud se rechazó por fallas repetidas y no por volumen
+	Locked bool
+}
+
+// clientState es el estado de una clave: su bucket de tokens y sus fallas recientes
+type clientState struct {
+	limiter      *rate.Limiter
+	failures     int
+	firstFailure time.Time
+	lockedUntil  time.Time
+	lastSeen     time.Time
+}
+
+// Limiter aplica una Policy por clave (en memoria, válido para una sola instancia del BFF)
+type Limiter struct {
+	policy    Policy
+	mu        sync.Mutex
+	clients   map[string]*clientState
+	lastSweep time.Time
+}
+
+// NewLimiter crea un limitador con la política indicada
+func NewLimiter(policy Policy) *Limiter {
+	return &Limiter{
+		policy:    normalizePolicy(policy),
+		clients:   make(map[string]*clientState),
+		lastSweep: time.Now(),
+	}
+}
+
+// SetPolicy reemplaza la política; los clientes existentes conservan sus tokens y bloqueos vigentes
+func (l *Limiter) SetPolicy(policy Policy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.policy = normalizePolicy(policy)
+	now := time.Now()
+	for _, state := range l.clients {
+		state.limiter.SetLimitAt(now, l.limit())
+		state.limiter.SetBurstAt(now, l.policy.Burst)
+	}
+}
+
+// normalizePolicy asegura una tasa y una ráfaga mínimas de 1
+func normalizePolicy(policy Policy) Policy {
+	if policy.RequestsPerMinute < 1 {
+		policy.RequestsPerMinute = 1
+	}
+	if policy.Burst < 1 {
+		policy.Burst = 1
+	}
+	return policy
+}
+
+// limit convierte la tasa por minuto de la política a tokens por segundo (requiere l.mu tomado)
+func (l *Limiter) limit() rate.Limit {
+	return rate.Limit(float64(l.policy.RequestsPerMinute) / 60)
+}
+
+// Allow consume un token de la clave si no está bloqueada ni agotó su presupuesto
+func (l *Limiter) Allow(key string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.sweep(now)
+	state := l.client(key, now)
+
+	if now.Before(state.lockedUntil) {
+		return Decision{RetryAfter: state.lockedUntil.Sub(now), Locked: true}
+	}
+
+	reservation := state.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return Decision{RetryAfter: delay}
+	}
+	return Decision{Allowed: true}
+}
+
+// RecordFailure registra una falla de la clave y la bloquea al superar MaxFailures
+func (l *Limiter) RecordFailure(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.policy.MaxFailures < 1 {
+		return
+	}
+
+	now := time.Now()
+	state := l.client(key, now)
+
+	if state.failures == 0 || now.Sub(state.firstFailure) > l.policy.FailureWindow {
+		state.failures = 0
+		state.firstFailure = now
+	}
+	state.failures++
+
+	if state.failures >= l.policy.MaxFailures {
+		state.lockedUntil = now.Add(l.policy.LockoutDuration)
+		state.failures = 0
+	}
+}
+
+// RecordSuccess reinicia el conteo de fallas de la clave
+func (l *Limiter) RecordSuccess(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if state, ok := l.clients[key]; ok {
+		state.failures = 0
+	}
+}
+
+// client obtiene o crea el estado de la clave (requiere l.mu tomado)
+func (l *Limiter) client(key string, now time.Time) *clientState {
+	state, ok := l.clients[key]
+	if !ok {
+		state = &clientState{limiter: rate.NewLimiter(l.limit(), l.policy.Burst)}
+		l.clients[key] = state
+	}
+	state.lastSeen = now
+	return state
+}
+
+// sweep elimina los clientes sin actividad, sin bloqueo vigente y con el bucket lleno
+// como máximo una vez por sweepInterval (requiere l.mu tomado)
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	idle := l.policy.FailureWindow
+	if refill := time.Duration(l.policy.Burst) * time.Minute / time.Duration(l.policy.RequestsPerMinute); refill > idle {
+		idle = refill
+	}
+	if idle < sweepInterval {
+		idle = sweepInterval
+	}
+	for key, state := range l.clients {
+		if now.Sub(state.lastSeen) > idle && !now.Before(state.lockedUntil) {
+			delete(l.clients, key)
+		}
+	}
+	l.lastSweep = now
+}
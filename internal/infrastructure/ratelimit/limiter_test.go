@@ -0,0 +1,113 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterBudget(t *testing.T) {
+	limiter := NewLimiter(Policy{RequestsPerMinute: 1, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if decision := limiter.Allow("203.0.113.5|1"); !decision.Allowed {
+			t.Fatalf("request %d rejected within the burst", i)
+		}
+	}
+
+	decision := limiter.Allow("203.0.113.5|1")
+	if decision.Allowed || decision.Locked {
+		t.Fatalf("expected a rate limit rejection, got %+v", decision)
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > time.Minute {
+		t.Errorf("unexpected retryAfter %v", decision.RetryAfter)
+	}
+
+	// Cada clave tiene su propio presupuesto
+	if decision := limiter.Allow("203.0.113.5|2"); !decision.Allowed {
+		t.Error("another resource shares the budget")
+	}
+	if decision := limiter.Allow("198.51.100.1|1"); !decision.Allowed {
+		t.Error("another client shares the budget")
+	}
+}
+
+func TestLimiterLockout(t *testing.T) {
+	limiter := NewLimiter(Policy{
+		RequestsPerMinute: 600,
+		Burst:             100,
+		MaxFailures:       3,
+		FailureWindow:     time.Minute,
+		LockoutDuration:   50 * time.Millisecond,
+	})
+	key := "203.0.113.5|locker-service"
+
+	for i := 0; i < 2; i++ {
+		limiter.RecordFailure(key)
+	}
+	if decision := limiter.Allow(key); !decision.Allowed {
+		t.Fatalf("locked before reaching MaxFailures: %+v", decision)
+	}
+
+	limiter.RecordFailure(key)
+	decision := limiter.Allow(key)
+	if decision.Allowed || !decision.Locked {
+		t.Fatalf("expected a lockout, got %+v", decision)
+	}
+	if decision.RetryAfter <= 0 || decision.RetryAfter > 50*time.Millisecond {
+		t.Errorf("unexpected retryAfter %v", decision.RetryAfter)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if decision := limiter.Allow(key); !decision.Allowed {
+		t.Errorf("still locked after the lockout: %+v", decision)
+	}
+}
+
+func TestLimiterSuccessResetsFailures(t *testing.T) {
+	limiter := NewLimiter(Policy{RequestsPerMinute: 600, Burst: 100, MaxFailures: 2, FailureWindow: time.Minute, LockoutDuration: time.Minute})
+	key := "203.0.113.5|1"
+
+	limiter.RecordFailure(key)
+	limiter.RecordSuccess(key)
+	limiter.RecordFailure(key)
+	if decision := limiter.Allow(key); !decision.Allowed {
+		t.Errorf("a success must reset the failure count: %+v", decision)
+	}
+}
+
+func TestLimiterFailureWindowReset(t *testing.T) {
+	limiter := NewLimiter(Policy{
+		RequestsPerMinute: 600,
+		Burst:             100,
+		MaxFailures:       3,
+		FailureWindow:     50 * time.Millisecond,
+		LockoutDuration:   time.Minute,
+	})
+	key := "203.0.113.5|1"
+
+	limiter.RecordFailure(key)
+	limiter.RecordFailure(key)
+	time.Sleep(60 * time.Millisecond)
+
+	// Las fallas fuera de la ventana no cuentan
+	limiter.RecordFailure(key)
+	if decision := limiter.Allow(key); !decision.Allowed {
+		t.Fatalf("failures outside the window caused a lockout: %+v", decision)
+	}
+
+	limiter.RecordFailure(key)
+	limiter.RecordFailure(key)
+	if decision := limiter.Allow(key); !decision.Locked {
+		t.Errorf("expected a lockout after 3 failures within the window, got %+v", decision)
+	}
+}
+
+func TestLimiterWithoutLockout(t *testing.T) {
+	limiter := NewLimiter(Policy{RequestsPerMinute: 600, Burst: 100})
+	for i := 0; i < 10; i++ {
+		limiter.RecordFailure("k")
+	}
+	if decision := limiter.Allow("k"); !decision.Allowed {
+		t.Errorf("MaxFailures 0 must disable the lockout: %+v", decision)
+	}
+}
@@ -0,0 +1,178 @@
+package ratelimit
+
+import (
+	appException "bff-graphql-payment/internal/application/exception"
+	"bff-graphql-payment/internal/domain/exception"
+	"bff-graphql-payment/internal/domain/model"
+	"bff-graphql-payment/internal/domain/ports"
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"bff-graphql-payment/internal/infrastructure/telemetry"
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Operaciones limitadas (se usan en los errores y en las métricas)
+const (
+	OperationValidateCoupon = "validateDiscountCoupon"
+	OperationUnlockCode     = "executeOpen"
+)
+
+// Settings define los presupuestos de cada operación limitada
+type Settings struct {
+	// Coupon limita validateDiscountCoupon por IP y rack
+	Coupon Policy
+	// UnlockCode limita checkBookingStatus y executeOpen por IP y servicio (ambas reciben el código de apertura)
+	UnlockCode Policy
+}
+
+// RateLimitedService decora un PaymentInfraService limitando las operaciones que permiten
+// adivinar códigos (cupones y códigos de apertura) y bloqueando a los clientes con fallas repetidas
+type RateLimitedService struct {
+	ports.PaymentInfraService
+
+	// enabled permite desactivar el límite en caliente sin quitar el decorador
+	enabled    atomic.Bool
+	coupon     *Limiter
+	unlockCode *Limiter
+	logger     *slog.Logger
+}
+
+// NewRateLimitedService crea el decorador de rate limiting sobre el servicio indicado
+func NewRateLimitedService(service ports.PaymentInfraService, enabled bool, settings Settings, logger *slog.Logger) *RateLimitedService {
+	limited := &RateLimitedService{
+		PaymentInfraService: service,
+		coupon:              NewLimiter(settings.Coupon),
+		unlockCode:          NewLimiter(settings.UnlockCode),
+		logger:              logger.With("component", "rate-limiter"),
+	}
+	limited.enabled.Store(enabled)
+	return limited
+}
+
+// Update activa o desactiva el límite y reemplaza los presupuestos de cada operación
+func (s *RateLimitedService) Update(enabled bool, settings Settings) {
+	s.coupon.SetPolicy(settings.Coupon)
+	s.unlockCode.SetPolicy(settings.UnlockCode)
+	s.enabled.Store(enabled)
+}
+
+// ValidateDiscountCoupon implementa PaymentInfraService.ValidateDiscountCoupon con rate limiting
+func (s *RateLimitedService) ValidateDiscountCoupon(ctx context.Context, couponCode string, rackID int, traceID string) (*model.DiscountCouponValidation, error) {
+	key := clientKey(ctx, strconv.Itoa(rackID))
+	if err := s.allow(ctx, s.coupon, OperationValidateCoupon, key); err != nil {
+		return nil, err
+	}
+
+	validation, err := s.PaymentInfraService.ValidateDiscountCoupon(ctx, couponCode, rackID, traceID)
+	switch {
+	case errors.Is(err, exception.ErrInvalidCoupon), errors.Is(err, exception.ErrCouponNotFound):
+		s.coupon.RecordFailure(key)
+	case err == nil:
+		s.coupon.RecordSuccess(key)
+	}
+	return validation, err
+}
+
+// CheckBookingStatus implementa PaymentInfraService.CheckBookingStatus con rate limiting
+func (s *RateLimitedService) CheckBookingStatus(ctx context.Context, serviceName string, currentCode string) (*model.BookingStatusCheck, error) {
+	key := clientKey(ctx, serviceName)
+	if err := s.allow(ctx, s.unlockCode, OperationUnlockCode, key); err != nil {
+		return nil, err
+	}
+
+	status, err := s.PaymentInfraService.CheckBookingStatus(ctx, serviceName, currentCode)
+	switch {
+	case errors.Is(err, exception.ErrBookingNotFound):
+		s.unlockCode.RecordFailure(key)
+	case err == nil:
+		s.unlockCode.RecordSuccess(key)
+	}
+	return status, err
+}
+
+// ExecuteOpenStream implementa PaymentInfraService.ExecuteOpenStream con rate limiting.
+// Un OPEN_STATUS_ERROR emitido por el stream cuenta como falla del código de apertura, salvo los que
+// sintetiza el BFF (stream sin estado final o plazo vencido), que llevan PHYSICAL_STATUS_UNEXPECTED.
+func (s *RateLimitedService) ExecuteOpenStream(ctx context.Context, serviceName string, currentCode string) (<-chan *model.ExecuteOpenResult, error) {
+	key := clientKey(ctx, serviceName)
+	if err := s.allow(ctx, s.unlockCode, OperationUnlockCode, key); err != nil {
+		return nil, err
+	}
+
+	resultChan, err := s.PaymentInfraService.ExecuteOpenStream(ctx, serviceName, currentCode)
+	if err != nil {
+		if errors.Is(err, exception.ErrBookingNotFound) {
+			s.unlockCode.RecordFailure(key)
+		}
+		return nil, err
+	}
+
+	// Reenviar los estados observando el resultado terminal
+	forwarded := make(chan *model.ExecuteOpenResult, cap(resultChan))
+	go func() {
+		defer close(forwarded)
+		for result := range resultChan {
+			if result != nil {
+				switch result.OpenStatus {
+				case model.OpenStatusError:
+					if result.PhysicalStatus != model.PhysicalStatusUnexpected {
+						s.unlockCode.RecordFailure(key)
+					}
+				case model.OpenStatusSuccess:
+					s.unlockCode.RecordSuccess(key)
+				}
+			}
+			select {
+			case forwarded <- result:
+			case <-ctx.Done():
+				// Drenar el canal original para no bloquear al productor
+				for range resultChan {
+				}
+				return
+			}
+		}
+	}()
+
+	return forwarded, nil
+}
+
+// allow evalúa la solicitud y devuelve un RateLimitError si debe rechazarse
+func (s *RateLimitedService) allow(ctx context.Context, limiter *Limiter, operation string, key string) error {
+	if !s.enabled.Load() {
+		return nil
+	}
+
+	decision := limiter.Allow(key)
+	if decision.Allowed {
+		return nil
+	}
+
+	telemetry.ObserveRateLimitRejection(operation, decision.Locked)
+	s.logger.WarnContext(ctx, "request rate limited",
+		"operation", operation,
+		"key", key,
+		"locked", decision.Locked,
+		"retryAfter", decision.RetryAfter,
+	)
+	return &appException.RateLimitError{
+		Operation:  operation,
+		RetryAfter: decision.RetryAfter,
+		Locked:     decision.Locked,
+	}
+}
+
+// clientKey combina la IP del cliente con el recurso al que accede (rack o servicio)
+func clientKey(ctx context.Context, resource string) string {
+	clientIP := "unknown"
+	if metadata, ok := requestmeta.FromContext(ctx); ok && metadata.ClientIP != "" {
+		clientIP = metadata.ClientIP
+	}
+	return clientIP + "|" + strings.TrimSpace(resource)
+}
+
+// Asegurar que RateLimitedService implementa PaymentInfraService
+var _ ports.PaymentInfraService = (*RateLimitedService)(nil)
@@ -0,0 +1,102 @@
+package requestmeta
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Headers HTTP de entrada usados para correlacionar solicitudes
+const (
+	HeaderRequestID    = "X-Request-Id"
+	HeaderTraceID      = "X-Trace-Id"
+	HeaderForwardedFor = "X-Forwarded-For"
+	HeaderRealIP       = "X-Real-Ip"
+	HeaderUserAgent    = "User-Agent"
+	HeaderOrigin       = "Origin"
+)
+
+// Metadata contiene los datos de la solicitud GraphQL que se propagan a los upstreams
+type Metadata struct {
+	TraceID   string
+	RequestID string
+	ClientIP  string
+	UserAgent string
+	Origin    string
+}
+
+type contextKey struct{}
+
+// WithMetadata guarda la metadata de la solicitud en el contexto
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, contextKey{}, metadata)
+}
+
+// FromContext obtiene la metadata de la solicitud desde el contexto
+func FromContext(ctx context.Context) (Metadata, bool) {
+	metadata, ok := ctx.Value(contextKey{}).(Metadata)
+	return metadata, ok
+}
+
+// WithTraceID sobrescribe el trace ID de la solicitud (p. ej. el traceId recibido en el input GraphQL)
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	if strings.TrimSpace(traceID) == "" {
+		return ctx
+	}
+	metadata, _ := FromContext(ctx)
+	metadata.TraceID = traceID
+	return WithMetadata(ctx, metadata)
+}
+
+// EnsureIDs genera el trace ID y el request ID que falten y los guarda en el contexto, para que
+// todas las llamadas upstream de la misma solicitud (incluidos los reintentos) compartan los mismos
+func EnsureIDs(ctx context.Context) context.Context {
+	metadata, _ := FromContext(ctx)
+	if metadata.TraceID != "" && metadata.RequestID != "" {
+		return ctx
+	}
+	if metadata.TraceID == "" {
+		metadata.TraceID = NewID()
+	}
+	if metadata.RequestID == "" {
+		metadata.RequestID = NewID()
+	}
+	return WithMetadata(ctx, metadata)
+}
+
+// NewID genera un identificador único para requests y traces
+func NewID() string {
+	return uuid.NewString()
+}
+
+// Middleware extrae la metadata de la solicitud HTTP (incluido el handshake WebSocket),
+// genera un request ID si no viene y lo devuelve en la respuesta.
+// La IP del cliente solo se toma de los headers de proxy cuando la conexión viene de trustedProxies.
+func Middleware(trustedProxies *TrustedProxies, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadata := Metadata{
+			TraceID:   r.Header.Get(HeaderTraceID),
+			RequestID: r.Header.Get(HeaderRequestID),
+			ClientIP:  trustedProxies.clientIP(r),
+			UserAgent: r.Header.Get(HeaderUserAgent),
+			Origin:    r.Header.Get(HeaderOrigin),
+		}
+		if metadata.RequestID == "" {
+			metadata.RequestID = NewID()
+		}
+		// Sin X-Trace-Id se usa el trace ID de OpenTelemetry para correlacionar logs y spans;
+		// sin tracing se genera uno para toda la solicitud
+		if spanContext := trace.SpanContextFromContext(r.Context()); metadata.TraceID == "" && spanContext.HasTraceID() {
+			metadata.TraceID = spanContext.TraceID().String()
+		}
+		if metadata.TraceID == "" {
+			metadata.TraceID = NewID()
+		}
+
+		w.Header().Set(HeaderRequestID, metadata.RequestID)
+		next.ServeHTTP(w, r.WithContext(WithMetadata(r.Context(), metadata)))
+	})
+}
@@ -0,0 +1,36 @@
+package requestmeta
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddlewareGeneratesTraceID(t *testing.T) {
+	var got Metadata
+	handler := Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = FromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/query", nil))
+	if got.TraceID == "" || got.RequestID == "" {
+		t.Errorf("expected generated ids, got %+v", got)
+	}
+
+	request := httptest.NewRequest(http.MethodPost, "/query", nil)
+	request.Header.Set(HeaderTraceID, "trace-1")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+	if got.TraceID != "trace-1" {
+		t.Errorf("expected the X-Trace-Id header, got %q", got.TraceID)
+	}
+}
+
+func TestEnsureIDsKeepsExistingIDs(t *testing.T) {
+	ctx := EnsureIDs(context.Background())
+	first, _ := FromContext(ctx)
+	second, _ := FromContext(EnsureIDs(ctx))
+	if first.TraceID == "" || first != second {
+		t.Errorf("expected the generated ids to be kept, got %+v and %+v", first, second)
+	}
+}
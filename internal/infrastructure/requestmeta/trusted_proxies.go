@@ -0,0 +1,113 @@
+package requestmeta
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// ErrInvalidTrustedProxy se devuelve cuando un proxy confiable no es una IP ni un rango CIDR
+var ErrInvalidTrustedProxy = errors.New("invalid trusted proxy")
+
+// TrustedProxies son los proxies y load balancers propios. Solo cuando la conexión llega desde uno
+// de ellos se consideran X-Forwarded-For y X-Real-Ip, que el cliente puede falsificar.
+type TrustedProxies struct {
+	prefixes []netip.Prefix
+}
+
+// ParseTrustedProxies valida la lista de proxies confiables: IPs ("10.0.0.1") o rangos CIDR ("10.0.0.0/8")
+func ParseTrustedProxies(entries []string) (*TrustedProxies, error) {
+	proxies := &TrustedProxies{}
+	var errs []error
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%w %q: %v", ErrInvalidTrustedProxy, entry, err))
+				continue
+			}
+			proxies.prefixes = append(proxies.prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w %q: %v", ErrInvalidTrustedProxy, entry, err))
+			continue
+		}
+		addr = addr.Unmap()
+		proxies.prefixes = append(proxies.prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return proxies, nil
+}
+
+// trusted indica si la dirección pertenece a un proxy confiable (nil no confía en ninguno)
+func (t *TrustedProxies) trusted(addr netip.Addr) bool {
+	if t == nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range t.prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP obtiene la IP del cliente. Se parte de la dirección de la conexión y, mientras el salto
+// actual sea un proxy confiable, se avanza de derecha a izquierda por X-Forwarded-For: la IP del
+// cliente es el primer salto que no es un proxy confiable. Los valores a la izquierda de ese salto
+// los escribe el propio cliente y se ignoran.
+func (t *TrustedProxies) clientIP(r *http.Request) string {
+	remote, err := netip.ParseAddrPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	client := remote.Addr().Unmap()
+	if !t.trusted(client) {
+		return client.String()
+	}
+
+	hops := forwardedHops(r.Header.Values(HeaderForwardedFor))
+	if len(hops) == 0 {
+		if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get(HeaderRealIP))); err == nil {
+			return realIP.Unmap().String()
+		}
+		return client.String()
+	}
+
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(hops[i])
+		if err != nil {
+			// Un valor mal formado no identifica a nadie: se usa el último salto válido
+			break
+		}
+		client = hop.Unmap()
+		if !t.trusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
+
+// forwardedHops separa los saltos de todos los headers X-Forwarded-For en orden
+func forwardedHops(values []string) []string {
+	var hops []string
+	for _, value := range values {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	return hops
+}
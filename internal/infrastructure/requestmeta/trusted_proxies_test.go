@@ -0,0 +1,58 @@
+package requestmeta
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		proxies      *TrustedProxies
+		remoteAddr   string
+		forwardedFor []string
+		realIP       string
+		want         string
+	}{
+		{name: "no trusted proxies ignores headers", remoteAddr: "203.0.113.5:4000", forwardedFor: []string{"198.51.100.1"}, realIP: "198.51.100.2", want: "203.0.113.5"},
+		{name: "untrusted peer ignores headers", proxies: proxies, remoteAddr: "203.0.113.5:4000", forwardedFor: []string{"198.51.100.1"}, want: "203.0.113.5"},
+		{name: "trusted proxy", proxies: proxies, remoteAddr: "10.1.2.3:4000", forwardedFor: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		{name: "spoofed leftmost value is ignored", proxies: proxies, remoteAddr: "10.1.2.3:4000", forwardedFor: []string{"1.2.3.4, 198.51.100.1"}, want: "198.51.100.1"},
+		{name: "chain of trusted proxies", proxies: proxies, remoteAddr: "10.1.2.3:4000", forwardedFor: []string{"1.2.3.4, 198.51.100.1, 192.0.2.10", "10.9.9.9"}, want: "198.51.100.1"},
+		{name: "malformed hop stops the walk", proxies: proxies, remoteAddr: "10.1.2.3:4000", forwardedFor: []string{"198.51.100.1, not-an-ip"}, want: "10.1.2.3"},
+		{name: "only trusted hops", proxies: proxies, remoteAddr: "10.1.2.3:4000", forwardedFor: []string{"10.4.4.4"}, want: "10.4.4.4"},
+		{name: "real ip from trusted proxy", proxies: proxies, remoteAddr: "10.1.2.3:4000", realIP: "198.51.100.2", want: "198.51.100.2"},
+		{name: "ipv6 peer", remoteAddr: "[2001:db8::1]:4000", want: "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest("POST", "/query", nil)
+			request.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwardedFor {
+				request.Header.Add(HeaderForwardedFor, value)
+			}
+			if tt.realIP != "" {
+				request.Header.Set(HeaderRealIP, tt.realIP)
+			}
+
+			if got := tt.proxies.clientIP(request); got != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.0.2.1 ", "", "::1"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := ParseTrustedProxies([]string{"10.0.0.0/33", "proxy.internal"}); err == nil {
+		t.Error("expected error for invalid entries")
+	}
+}
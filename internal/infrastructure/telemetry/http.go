@@ -0,0 +1,19 @@
+package telemetry
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// HTTPHandler envuelve un handler con un span por solicitud. Extrae el traceparent entrante
+// y devuelve el traceparent del span actual en la respuesta para que el frontend pueda correlacionar.
+func HTTPHandler(operation string, next http.Handler) http.Handler {
+	withTraceparent := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		otel.GetTextMapPropagator().Inject(r.Context(), propagation.HeaderCarrier(w.Header()))
+		next.ServeHTTP(w, r)
+	})
+	return otelhttp.NewHandler(withTraceparent, operation)
+}
@@ -0,0 +1,175 @@
+package telemetry
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "bff_payment"
+
+// registry contiene todas las métricas expuestas en /metrics
+var registry = prometheus.NewRegistry()
+
+var (
+	graphqlOperationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "graphql_operations_total",
+		Help:      "Operaciones GraphQL ejecutadas por nombre y tipo.",
+	}, []string{"operation", "type"})
+
+	graphqlErrorsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "graphql_errors_total",
+		Help:      "Errores GraphQL devueltos por operación y código (extensions.code).",
+	}, []string{"operation", "code"})
+
+	graphqlOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "graphql_operation_duration_seconds",
+		Help:      "Latencia de las operaciones GraphQL (en subscriptions, por evento emitido).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"operation", "type"})
+
+	grpcClientRequestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "grpc_client_requests_total",
+		Help:      "Llamadas gRPC salientes por método y código de estado.",
+	}, []string{"method", "code"})
+
+	grpcClientRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "grpc_client_request_duration_seconds",
+		Help:      "Latencia de las llamadas gRPC salientes (en streams, duración total).",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	executeOpenActiveSubscriptions = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "execute_open_active_subscriptions",
+		Help:      "Subscriptions executeOpen activas.",
+	})
+
+	websocketConnections = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "websocket_connections",
+		Help:      "Conexiones WebSocket GraphQL inicializadas y abiertas.",
+	})
+
+	executeOpenOutcomesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "execute_open_outcomes_total",
+		Help:      "Resultados terminales de ExecuteOpen por estado de apertura y estado físico.",
+	}, []string{"open_status", "physical_status"})
+
+	executeOpenTimeoutsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "execute_open_timeouts_total",
+		Help:      "Aperturas cortadas por el watchdog por fase vencida (received/terminal/stream).",
+	}, []string{"phase"})
+
+	cacheLookupsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cache_lookups_total",
+		Help:      "Consultas al cache de respuestas por operación y resultado (hit/miss).",
+	}, []string{"operation", "result"})
+
+	rateLimitRejectionsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "rate_limit_rejections_total",
+		Help:      "Solicitudes rechazadas por rate limiting por operación y motivo (rate_limit/lockout).",
+	}, []string{"operation", "reason"})
+)
+
+func init() {
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		graphqlOperationsTotal,
+		graphqlErrorsTotal,
+		graphqlOperationDuration,
+		grpcClientRequestsTotal,
+		grpcClientRequestDuration,
+		executeOpenActiveSubscriptions,
+		websocketConnections,
+		executeOpenOutcomesTotal,
+		executeOpenTimeoutsTotal,
+		cacheLookupsTotal,
+		rateLimitRejectionsTotal,
+	)
+}
+
+// MetricsHandler expone las métricas en formato Prometheus
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{Registry: registry})
+}
+
+// ObserveGraphQLOperation registra una operación GraphQL, su latencia y los códigos de error devueltos
+func ObserveGraphQLOperation(operation string, operationType string, duration time.Duration, errorCodes []string) {
+	graphqlOperationsTotal.WithLabelValues(operation, operationType).Inc()
+	graphqlOperationDuration.WithLabelValues(operation, operationType).Observe(duration.Seconds())
+	for _, code := range errorCodes {
+		graphqlErrorsTotal.WithLabelValues(operation, code).Inc()
+	}
+}
+
+// ObserveGRPCCall registra una llamada gRPC saliente con su código de estado y latencia
+func ObserveGRPCCall(method string, code string, duration time.Duration) {
+	grpcClientRequestsTotal.WithLabelValues(method, code).Inc()
+	grpcClientRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
+// ExecuteOpenSubscriptionStarted incrementa las subscriptions executeOpen activas.
+// Devuelve la función que debe llamarse cuando la subscription termina.
+func ExecuteOpenSubscriptionStarted() func() {
+	executeOpenActiveSubscriptions.Inc()
+	return executeOpenActiveSubscriptions.Dec
+}
+
+// ObserveExecuteOpenOutcome registra el resultado terminal de una apertura
+func ObserveExecuteOpenOutcome(openStatus string, physicalStatus string) {
+	executeOpenOutcomesTotal.WithLabelValues(openStatus, physicalStatus).Inc()
+}
+
+// ObserveExecuteOpenTimeout registra una apertura cortada por vencer el plazo de una fase
+func ObserveExecuteOpenTimeout(phase string) {
+	executeOpenTimeoutsTotal.WithLabelValues(phase).Inc()
+}
+
+// ObserveCacheLookup registra un hit o miss del cache de respuestas
+func ObserveCacheLookup(operation string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookupsTotal.WithLabelValues(operation, result).Inc()
+}
+
+// ObserveRateLimitRejection registra una solicitud rechazada por el rate limiter
+func ObserveRateLimitRejection(operation string, locked bool) {
+	reason := "rate_limit"
+	if locked {
+		reason = "lockout"
+	}
+	rateLimitRejectionsTotal.WithLabelValues(operation, reason).Inc()
+}
+
+type websocketTrackedKey struct{}
+
+// WebSocketConnectionInitialized registra una conexión WebSocket inicializada (connection_init)
+// y marca el contexto para descontarla al cerrarse
+func WebSocketConnectionInitialized(ctx context.Context) context.Context {
+	websocketConnections.Inc()
+	return context.WithValue(ctx, websocketTrackedKey{}, true)
+}
+
+// WebSocketConnectionClosed descuenta la conexión si había sido registrada al inicializarse
+func WebSocketConnectionClosed(ctx context.Context) {
+	if tracked, _ := ctx.Value(websocketTrackedKey{}).(bool); tracked {
+		websocketConnections.Dec()
+	}
+}
@@ -0,0 +1,96 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// Exportadores de trazas soportados
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// TracingSettings define cómo se exportan las trazas de OpenTelemetry
+type TracingSettings struct {
+	// Exporter es "otlp", "stdout" o "none"
+	Exporter string
+	// OTLPEndpoint es el host:port del collector OTLP/gRPC
+	OTLPEndpoint string
+	// OTLPInsecure desactiva TLS hacia el collector
+	OTLPInsecure bool
+	// SampleRatio es la fracción de trazas raíz que se muestrean (0.0 - 1.0)
+	SampleRatio float64
+	// ServiceName y Environment se agregan como atributos del recurso
+	ServiceName string
+	Environment string
+	// Logger registra la configuración aplicada
+	Logger *slog.Logger
+}
+
+// ShutdownFunc vacía y cierra el exportador de trazas
+type ShutdownFunc func(ctx context.Context) error
+
+// SetupTracing configura el TracerProvider y el propagador W3C (traceparent + baggage) globales
+func SetupTracing(ctx context.Context, settings TracingSettings) (ShutdownFunc, error) {
+	// El propagador se registra siempre para que traceparent fluya aunque no se exporte
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+
+	switch strings.ToLower(settings.Exporter) {
+	case ExporterOTLP:
+		options := []otlptracegrpc.Option{}
+		if settings.OTLPEndpoint != "" {
+			options = append(options, otlptracegrpc.WithEndpoint(settings.OTLPEndpoint))
+		}
+		if settings.OTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, options...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	case ExporterNone, "":
+		settings.Logger.Info("tracing exporter disabled")
+		return func(context.Context) error { return nil }, nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", settings.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", settings.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(settings.ServiceName),
+		semconv.DeploymentEnvironmentName(settings.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(settings.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	settings.Logger.Info("tracing enabled", "exporter", settings.Exporter, "sampleRatio", settings.SampleRatio)
+	return provider.Shutdown, nil
+}
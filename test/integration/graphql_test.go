@@ -0,0 +1,672 @@
+package integration
+
+import (
+	bookingpb "bff-graphql-payment/gen/go/proto/booking/v1"
+	paymentpb "bff-graphql-payment/gen/go/proto/payment/v1"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/subscription"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/fake"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// paymentOK es la respuesta genérica exitosa de Payment Manager
+func paymentOK(transactionID string) *paymentpb.PaymentManagerGenericResponse {
+	return &paymentpb.PaymentManagerGenericResponse{
+		TransactionId: transactionID,
+		Message:       "ok",
+		Status:        paymentpb.ResponseStatus_RESPONSE_STATUS_OK,
+		TraceId:       "trace-" + transactionID,
+	}
+}
+
+// paymentError es la respuesta genérica con estado ERROR de Payment Manager
+func paymentError(message string) *paymentpb.PaymentManagerGenericResponse {
+	return &paymentpb.PaymentManagerGenericResponse{
+		TransactionId: "tx-error",
+		Message:       message,
+		Status:        paymentpb.ResponseStatus_RESPONSE_STATUS_ERROR,
+	}
+}
+
+// openStep es un paso del guion de apertura sin espera
+func openStep(openStatus bookingpb.OpenStatus, physicalStatus bookingpb.PhysicalStatus) fake.OpenStep {
+	return fake.OpenStep{Response: &bookingpb.ExecuteOpenResponse{
+		TransactionId:  "tx-open",
+		Message:        openStatus.String(),
+		Status:         openStatus,
+		PhysicalStatus: physicalStatus,
+	}}
+}
+
+const paymentInfraQuery = `query($qr: String!) {
+  getPaymentInfraByQrValue(input: {qrValue: $qr}) {
+    transactionId status
+    paymentRack { id description }
+    installation { name city }
+    device { name online }
+    bookingTimes { id unitMeasurement amount }
+  }
+}`
+
+func TestGetPaymentInfraByQrValue(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetPaymentInfra("QR-1", &paymentpb.GetPaymentInfraByQrValueResponse{
+		Response:     paymentOK("tx-infra"),
+		PaymentRack:  &paymentpb.RackRecord{Id: 7, Description: "Rack lobby", Address: "Av. Siempre Viva 742"},
+		Installation: &paymentpb.InstallationRecord{Id: 3, Name: "Mall Plaza", City: "Santiago"},
+		Device:       &paymentpb.DeviceRecord{Name: "LCK-7", Online: true},
+		BookingTimes: []*paymentpb.BookingTimeRecord{
+			{Id: 1, Name: "1 hora", UnitMeasurement: paymentpb.UnitMeasurement_UNIT_MEASUREMENT_HOUR, Amount: 1},
+		},
+	})
+
+	var data struct {
+		Result struct {
+			TransactionID string `json:"transactionId"`
+			Status        string `json:"status"`
+			PaymentRack   struct {
+				ID int `json:"id"`
+			} `json:"paymentRack"`
+			Installation struct {
+				Name string `json:"name"`
+			} `json:"installation"`
+			Device struct {
+				Online bool `json:"online"`
+			} `json:"device"`
+			BookingTimes []struct {
+				UnitMeasurement string `json:"unitMeasurement"`
+				Amount          int    `json:"amount"`
+			} `json:"bookingTimes"`
+		} `json:"getPaymentInfraByQrValue"`
+	}
+	h.mustDo(t, paymentInfraQuery, map[string]any{"qr": "QR-1"}, &data)
+
+	result := data.Result
+	if result.TransactionID != "tx-infra" || result.Status != "RESPONSE_STATUS_OK" {
+		t.Errorf("unexpected response metadata: %+v", result)
+	}
+	if result.PaymentRack.ID != 7 || result.Installation.Name != "Mall Plaza" || !result.Device.Online {
+		t.Errorf("unexpected infrastructure: %+v", result)
+	}
+	if len(result.BookingTimes) != 1 || result.BookingTimes[0].UnitMeasurement != "HOUR" || result.BookingTimes[0].Amount != 1 {
+		t.Errorf("unexpected booking times: %+v", result.BookingTimes)
+	}
+
+	requests := h.managers.Payment.Requests(paymentpb.PaymentService_GetPaymentInfraByQrValue_FullMethodName)
+	if len(requests) != 1 || requests[0].(*paymentpb.GetPaymentInfraByQrValueRequest).GetQrValue() != "QR-1" {
+		t.Errorf("unexpected upstream requests: %v", requests)
+	}
+}
+
+func TestGetAvailableLockers(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetAvailableLockers("7", &paymentpb.GetAvailableLockersByRackIDAndBookingTimeResponse{
+		Response: paymentOK("tx-lockers"),
+		AvailableGroup: []*paymentpb.AvailablePaymentGroupRecord{
+			{GroupId: 1, Name: "Pequeño", Price: 1500},
+			{GroupId: 2, Name: "Grande", Price: 2500},
+		},
+	})
+
+	var data struct {
+		Result struct {
+			AvailableGroups []struct {
+				GroupID int     `json:"groupId"`
+				Price   float64 `json:"price"`
+			} `json:"availableGroups"`
+		} `json:"getAvailableLockersByRackIDAndBookingTime"`
+	}
+	h.mustDo(t, `query {
+  getAvailableLockersByRackIDAndBookingTime(input: {paymentRackId: 7, bookingTimeId: 1, traceId: "trace-lockers"}) {
+    availableGroups { groupId price }
+  }
+}`, nil, &data)
+
+	if groups := data.Result.AvailableGroups; len(groups) != 2 || groups[1].GroupID != 2 || groups[1].Price != 2500 {
+		t.Errorf("unexpected groups: %+v", groups)
+	}
+
+	requests := h.managers.Payment.Requests(paymentpb.PaymentService_GetAvailableLockersByRackIDAndBookingTime_FullMethodName)
+	request := requests[0].(*paymentpb.GetAvailableLockersByRackIDAndBookingTimeRequest)
+	if request.GetBookingTimeId() != 1 || request.GetTraceId() != "trace-lockers" {
+		t.Errorf("unexpected upstream request: %v", request)
+	}
+}
+
+func TestValidateDiscountCoupon(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetCoupon("VERANO", &paymentpb.ValidateDiscountCouponResponse{
+		Response:           paymentOK("tx-coupon"),
+		DiscountPercentage: 15,
+	})
+
+	var data struct {
+		Result struct {
+			DiscountPercentage float64 `json:"discountPercentage"`
+		} `json:"validateDiscountCoupon"`
+	}
+	h.mustDo(t, `query {
+  validateDiscountCoupon(input: {couponCode: "VERANO", rackId: 7, traceId: "trace-coupon"}) { discountPercentage }
+}`, nil, &data)
+
+	if data.Result.DiscountPercentage != 15 {
+		t.Errorf("expected 15%% discount, got %v", data.Result.DiscountPercentage)
+	}
+}
+
+func TestNonASCIIRequestMetadata(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetCoupon("VERANO", &paymentpb.ValidateDiscountCouponResponse{
+		Response:           paymentOK("tx-coupon"),
+		DiscountPercentage: 15,
+	})
+
+	// gRPC rechaza metadata fuera de ASCII imprimible: el BFF la codifica en vez de fallar la llamada
+	header := http.Header{}
+	header.Set("User-Agent", "Navegador/1.0 (España)")
+	for i := 0; i < 6; i++ {
+		response := h.doWithHeader(t, `query {
+  validateDiscountCoupon(input: {couponCode: "VERANO", rackId: 7, traceId: "traza-ñ"}) { discountPercentage }
+}`, nil, header)
+		if len(response.Errors) > 0 {
+			t.Fatalf("request %d: unexpected errors: %+v", i, response.Errors)
+		}
+	}
+}
+
+const generatePurchaseOrderMutation = `mutation($coupon: String, $key: String) {
+  generatePurchaseOrder(
+    input: {rackIdReference: 7, groupId: 2, couponCode: $coupon, userEmail: "ana@example.com", userPhone: "+56911111111", traceId: "trace-po", gatewayName: "webpay"}
+    idempotencyKey: $key
+  ) { transactionId url }
+}`
+
+func TestGeneratePurchaseOrder(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetPurchaseOrder("7", &paymentpb.GeneratePurchaseOrderResponse{
+		Response: paymentOK("tx-po"),
+		Url:      "https://pay.example.com/oc/1",
+	})
+
+	var data struct {
+		Result struct {
+			URL string `json:"url"`
+		} `json:"generatePurchaseOrder"`
+	}
+	variables := map[string]any{"coupon": "VERANO", "key": "po-key-1"}
+	h.mustDo(t, generatePurchaseOrderMutation, variables, &data)
+	if data.Result.URL != "https://pay.example.com/oc/1" {
+		t.Errorf("unexpected url: %q", data.Result.URL)
+	}
+
+	// Reintento con la misma clave de idempotencia: se responde sin volver a llamar al upstream
+	h.mustDo(t, generatePurchaseOrderMutation, variables, &data)
+
+	requests := h.managers.Payment.Requests(paymentpb.PaymentService_GeneratePurchaseOrder_FullMethodName)
+	if len(requests) != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", len(requests))
+	}
+	request := requests[0].(*paymentpb.GeneratePurchaseOrderRequest)
+	if request.GetCouponCode() != "VERANO" || request.GetGatewayName() != "webpay" || request.GetGroupId() != 2 {
+		t.Errorf("unexpected upstream request: %v", request)
+	}
+}
+
+func TestGenerateBooking(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetBooking(fake.DefaultKey, &paymentpb.GenerateBookingResponse{
+		Response: paymentOK("tx-booking"),
+		Code:     "ABC123",
+	})
+
+	var data struct {
+		Result struct {
+			Code string `json:"code"`
+		} `json:"generateBooking"`
+	}
+	h.mustDo(t, `mutation {
+  generateBooking(input: {rackIdReference: 9, groupId: 1, userEmail: "ana@example.com", userPhone: "+56911111111", traceId: "trace-booking"}) { code }
+}`, nil, &data)
+
+	if data.Result.Code != "ABC123" {
+		t.Errorf("unexpected booking code: %q", data.Result.Code)
+	}
+
+	requests := h.managers.Payment.Requests(paymentpb.PaymentService_GenerateBooking_FullMethodName)
+	if request := requests[0].(*paymentpb.GenerateBookingRequest); request.CouponCode != nil {
+		t.Errorf("expected no coupon code, got %q", request.GetCouponCode())
+	}
+}
+
+func TestGetPurchaseOrderByPo(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Payment.SetPurchaseOrderLookup("OC-1", &paymentpb.GetPurchaseOrderByPoResponse{
+		Response: paymentOK("tx-lookup"),
+		PurchaseOrder: &paymentpb.PurchaseOrderRecord{
+			Oc:                "OC-1",
+			Email:             "ana@example.com",
+			ProductPrice:      2500,
+			FinalProductPrice: 2125,
+			LockerPosition:    4,
+			Status:            "PAID",
+		},
+	})
+
+	var data struct {
+		Result struct {
+			PurchaseOrderData struct {
+				Oc                string `json:"oc"`
+				FinalProductPrice string `json:"finalProductPrice"`
+				LockerPosition    int    `json:"lockerPosition"`
+				Status            string `json:"status"`
+			} `json:"purchaseOrderData"`
+		} `json:"getPurchaseOrderByPo"`
+	}
+	h.mustDo(t, `query {
+  getPurchaseOrderByPo(input: {purchaseOrder: "OC-1", traceId: "trace-lookup"}) {
+    purchaseOrderData { oc finalProductPrice lockerPosition status }
+  }
+}`, nil, &data)
+
+	order := data.Result.PurchaseOrderData
+	if order.Oc != "OC-1" || order.FinalProductPrice != "2125" || order.LockerPosition != 4 || order.Status != "PAID" {
+		t.Errorf("unexpected purchase order: %+v", order)
+	}
+}
+
+func TestCheckBookingStatus(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Booking.SetBookingStatus("123456", &bookingpb.CheckBookingStatusResponse{
+		Response: &bookingpb.BookingManagerGenericResponse{
+			TransactionId: "tx-status",
+			Status:        bookingpb.ResponseStatus_RESPONSE_STATUS_OK,
+		},
+		Booking: &bookingpb.BookingRecord{
+			Id:           42,
+			NumberLocker: 4,
+			CurrentCode:  "123456",
+			Openings:     2,
+			ServiceName:  "lockers",
+		},
+	})
+
+	var data struct {
+		Result struct {
+			Status  string `json:"status"`
+			Booking struct {
+				ID       int `json:"id"`
+				Openings int `json:"openings"`
+			} `json:"booking"`
+		} `json:"checkBookingStatus"`
+	}
+	h.mustDo(t, `query {
+  checkBookingStatus(input: {serviceName: "lockers", currentCode: "123456"}) { status booking { id openings } }
+}`, nil, &data)
+
+	if data.Result.Status != "RESPONSE_STATUS_OK" || data.Result.Booking.ID != 42 || data.Result.Booking.Openings != 2 {
+		t.Errorf("unexpected booking status: %+v", data.Result)
+	}
+}
+
+const executeOpenSubscription = `subscription($code: String!) {
+  executeOpen(input: {serviceName: "lockers", currentCode: $code}) { transactionId openStatus physicalStatus }
+}`
+
+// openStatuses extrae openStatus y physicalStatus de cada mensaje de la subscription
+func openStatuses(t *testing.T, payloads []graphQLResponse) [][2]string {
+	t.Helper()
+
+	var statuses [][2]string
+	for _, payload := range payloads {
+		if len(payload.Errors) > 0 {
+			t.Fatalf("unexpected subscription errors: %+v", payload.Errors)
+		}
+		var data struct {
+			ExecuteOpen struct {
+				OpenStatus     string `json:"openStatus"`
+				PhysicalStatus string `json:"physicalStatus"`
+			} `json:"executeOpen"`
+		}
+		if err := json.Unmarshal(payload.Data, &data); err != nil {
+			t.Fatalf("decode subscription payload: %v", err)
+		}
+		statuses = append(statuses, [2]string{data.ExecuteOpen.OpenStatus, data.ExecuteOpen.PhysicalStatus})
+	}
+	return statuses
+}
+
+func TestExecuteOpen(t *testing.T) {
+	tests := []struct {
+		name   string
+		script []fake.OpenStep
+		want   [][2]string
+	}{
+		{
+			name: "success",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_EXECUTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_SUCCESS, bookingpb.PhysicalStatus_PHYSICAL_STATUS_SUCCESS),
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_REQUESTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_EXECUTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_SUCCESS", "PHYSICAL_STATUS_SUCCESS"},
+			},
+		},
+		{
+			name: "device reports failure",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_ERROR, bookingpb.PhysicalStatus_PHYSICAL_STATUS_FAILED),
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_REQUESTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_ERROR", "PHYSICAL_STATUS_FAILED"},
+			},
+		},
+		{
+			name: "out of order status is dropped",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_EXECUTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_SUCCESS, bookingpb.PhysicalStatus_PHYSICAL_STATUS_SUCCESS),
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_REQUESTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_EXECUTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_SUCCESS", "PHYSICAL_STATUS_SUCCESS"},
+			},
+		},
+		{
+			name: "skipped status is dropped",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_SUCCESS, bookingpb.PhysicalStatus_PHYSICAL_STATUS_SUCCESS),
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_REQUESTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_ERROR", "PHYSICAL_STATUS_UNEXPECTED"},
+			},
+		},
+		{
+			name: "terminal status with mismatched physical status is dropped",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_EXECUTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_SUCCESS, bookingpb.PhysicalStatus_PHYSICAL_STATUS_FAILED),
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_REQUESTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_EXECUTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_ERROR", "PHYSICAL_STATUS_UNEXPECTED"},
+			},
+		},
+		{
+			name: "stream ends without terminal status",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_ERROR", "PHYSICAL_STATUS_UNEXPECTED"},
+			},
+		},
+		{
+			name: "device stalls after request",
+			script: []fake.OpenStep{
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+				{Delay: time.Minute, Response: openStep(bookingpb.OpenStatus_OPEN_STATUS_SUCCESS, bookingpb.PhysicalStatus_PHYSICAL_STATUS_SUCCESS).Response},
+			},
+			want: [][2]string{
+				{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_REQUESTED", "PHYSICAL_STATUS_WAITING"},
+				{"OPEN_STATUS_ERROR", "PHYSICAL_STATUS_UNEXPECTED"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t)
+			h.managers.Booking.SetOpenScript("123456", tt.script...)
+
+			got := openStatuses(t, h.subscribe(t, executeOpenSubscription, map[string]any{"code": "123456"}))
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("message %d: expected %v, got %v", i, tt.want[i], got[i])
+				}
+			}
+
+			requests := h.managers.Booking.Requests(bookingpb.BookingService_ExecuteOpen_FullMethodName)
+			if len(requests) != 1 || requests[0].(*bookingpb.ExecuteOpenRequest).GetServiceName() != "lockers" {
+				t.Errorf("unexpected upstream requests: %v", requests)
+			}
+		})
+	}
+}
+
+func TestExecuteOpenDrainTimeout(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Booking.SetOpenScript("123456",
+		openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+		fake.OpenStep{Delay: time.Minute, Response: openStep(bookingpb.OpenStatus_OPEN_STATUS_REQUESTED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING).Response},
+	)
+
+	payloads := make(chan []graphQLResponse, 1)
+	go func() {
+		payloads <- h.subscribe(t, executeOpenSubscription, map[string]any{"code": "123456"})
+	}()
+	for h.subscriptions.Active() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// El plazo de drenaje vence con la apertura en curso: el frontend recibe un estado final
+	// antes de que se cierre la conexión
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := h.subscriptions.Drain(ctx); !errors.Is(err, subscription.ErrDrainTimeout) {
+		t.Fatalf("expected ErrDrainTimeout, got %v", err)
+	}
+
+	got := openStatuses(t, <-payloads)
+	want := [][2]string{
+		{"OPEN_STATUS_RECEIVED", "PHYSICAL_STATUS_WAITING"},
+		{"OPEN_STATUS_ERROR", "PHYSICAL_STATUS_UNEXPECTED"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestUpstreamErrors(t *testing.T) {
+	badRequest, err := status.New(codes.InvalidArgument, "invalid email").WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{{Field: "user_email", Description: "domain not allowed"}},
+	})
+	if err != nil {
+		t.Fatalf("status details: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		setup    func(managers *fake.Managers)
+		query    string
+		wantCode string
+		// wantCalls es la cantidad de llamadas esperadas al método (0 = no se verifica)
+		method    string
+		wantCalls int
+		// hidden es texto del upstream que no debe llegar al mensaje del error
+		hidden string
+	}{
+		{
+			name: "response status error",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.SetPaymentInfra("QR-1", &paymentpb.GetPaymentInfraByQrValueResponse{Response: paymentError("rack not found")})
+			},
+			query:    `query { getPaymentInfraByQrValue(input: {qrValue: "QR-1"}) { transactionId } }`,
+			wantCode: "PAYMENT_RACK_NOT_FOUND",
+		},
+		{
+			name:     "no response programmed",
+			setup:    func(managers *fake.Managers) {},
+			query:    `query { validateDiscountCoupon(input: {couponCode: "NOPE", rackId: 7, traceId: "t"}) { discountPercentage } }`,
+			wantCode: "COUPON_NOT_FOUND",
+		},
+		{
+			name: "coupon rejected",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.SetCoupon("VENCIDO", &paymentpb.ValidateDiscountCouponResponse{Response: paymentError("coupon expired")})
+			},
+			query:    `query { validateDiscountCoupon(input: {couponCode: "VENCIDO", rackId: 7, traceId: "t"}) { discountPercentage } }`,
+			wantCode: "COUPON_INVALID",
+		},
+		{
+			name: "upstream unavailable",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.InjectError(paymentpb.PaymentService_GetPaymentInfraByQrValue_FullMethodName, status.Error(codes.Unavailable, "down"), 0)
+			},
+			query:     `query { getPaymentInfraByQrValue(input: {qrValue: "QR-1"}) { transactionId } }`,
+			wantCode:  "UPSTREAM_UNAVAILABLE",
+			method:    paymentpb.PaymentService_GetPaymentInfraByQrValue_FullMethodName,
+			wantCalls: 2,
+		},
+		{
+			name: "transient failure is retried",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.InjectError(paymentpb.PaymentService_GetPurchaseOrderByPo_FullMethodName, status.Error(codes.Unavailable, "blip"), 1)
+				managers.Payment.SetPurchaseOrderLookup(fake.DefaultKey, &paymentpb.GetPurchaseOrderByPoResponse{
+					Response:      paymentOK("tx-lookup"),
+					PurchaseOrder: &paymentpb.PurchaseOrderRecord{Oc: "OC-1"},
+				})
+			},
+			query:     `query { getPurchaseOrderByPo(input: {purchaseOrder: "OC-1", traceId: "t"}) { transactionId } }`,
+			method:    paymentpb.PaymentService_GetPurchaseOrderByPo_FullMethodName,
+			wantCalls: 2,
+		},
+		{
+			name: "mutations are not retried",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.InjectError(paymentpb.PaymentService_GenerateBooking_FullMethodName, status.Error(codes.Unavailable, "blip"), 1)
+			},
+			query:     `mutation { generateBooking(input: {rackIdReference: 7, groupId: 1, userEmail: "ana@example.com", userPhone: "+56911111111", traceId: "t"}) { code } }`,
+			wantCode:  "UPSTREAM_UNAVAILABLE",
+			method:    paymentpb.PaymentService_GenerateBooking_FullMethodName,
+			wantCalls: 1,
+		},
+		{
+			name: "field violations",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.InjectError(paymentpb.PaymentService_GeneratePurchaseOrder_FullMethodName, badRequest.Err(), 0)
+			},
+			query:    `mutation { generatePurchaseOrder(input: {rackIdReference: 7, groupId: 2, userEmail: "ana@example.com", userPhone: "+56911111111", traceId: "t", gatewayName: "webpay"}) { url } }`,
+			wantCode: "VALIDATION_FAILED",
+		},
+		{
+			name: "upstream too slow",
+			setup: func(managers *fake.Managers) {
+				managers.Booking.SetDelay(bookingpb.BookingService_CheckBookingStatus_FullMethodName, 2*callTimeout)
+				managers.Booking.SetBookingStatus(fake.DefaultKey, &bookingpb.CheckBookingStatusResponse{})
+			},
+			query:    `query { checkBookingStatus(input: {serviceName: "lockers", currentCode: "123456"}) { status } }`,
+			wantCode: "UPSTREAM_TIMEOUT",
+		},
+		{
+			name: "internal upstream message is not exposed",
+			setup: func(managers *fake.Managers) {
+				managers.Payment.InjectError(paymentpb.PaymentService_ValidateDiscountCoupon_FullMethodName, status.Error(codes.Internal, "dial tcp 10.0.3.7:5432: connection refused"), 0)
+			},
+			query:    `query { validateDiscountCoupon(input: {couponCode: "VERANO", rackId: 7, traceId: "t"}) { discountPercentage } }`,
+			wantCode: "UPSTREAM_UNAVAILABLE",
+			hidden:   "10.0.3.7",
+		},
+		{
+			name: "unknown unlock code",
+			setup: func(managers *fake.Managers) {
+				managers.Booking.InjectError(bookingpb.BookingService_CheckBookingStatus_FullMethodName, status.Error(codes.NotFound, "no booking"), 0)
+			},
+			query:    `query { checkBookingStatus(input: {serviceName: "lockers", currentCode: "000000"}) { status } }`,
+			wantCode: "BOOKING_NOT_FOUND",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHarness(t)
+			tt.setup(h.managers)
+
+			response := h.do(t, tt.query, nil)
+			if got := response.code(); got != tt.wantCode {
+				t.Errorf("expected code %q, got %q (errors: %+v)", tt.wantCode, got, response.Errors)
+			}
+			if tt.hidden != "" && strings.Contains(response.Errors[0].Message, tt.hidden) {
+				t.Errorf("upstream detail leaked to the client: %q", response.Errors[0].Message)
+			}
+			if tt.wantCode == "VALIDATION_FAILED" {
+				violations, _ := response.Errors[0].Extensions["fieldViolations"].([]any)
+				if len(violations) != 1 {
+					t.Errorf("expected 1 field violation, got %v", response.Errors[0].Extensions["fieldViolations"])
+				}
+			}
+			if tt.method != "" {
+				calls := h.managers.Payment.Calls(tt.method) + h.managers.Booking.Calls(tt.method)
+				if calls != tt.wantCalls {
+					t.Errorf("expected %d calls to %s, got %d", tt.wantCalls, tt.method, calls)
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteOpenUpstreamError(t *testing.T) {
+	h := newHarness(t)
+	h.managers.Booking.SetOpenScript("123456",
+		openStep(bookingpb.OpenStatus_OPEN_STATUS_RECEIVED, bookingpb.PhysicalStatus_PHYSICAL_STATUS_WAITING),
+		fake.OpenStep{Err: status.Error(codes.Internal, "device gateway crashed")},
+	)
+
+	payloads := h.subscribe(t, `subscription($code: String!) {
+  executeOpen(input: {serviceName: "lockers", currentCode: $code}) { openStatus physicalStatus message }
+}`, map[string]any{"code": "123456"})
+	got := openStatuses(t, payloads)
+	if len(got) != 2 || got[0][0] != "OPEN_STATUS_RECEIVED" || got[1][0] != "OPEN_STATUS_ERROR" {
+		t.Fatalf("expected RECEIVED then ERROR, got %v", got)
+	}
+
+	// El detalle del error upstream no llega al frontend
+	var data struct {
+		ExecuteOpen struct {
+			Message string `json:"message"`
+		} `json:"executeOpen"`
+	}
+	if err := json.Unmarshal(payloads[1].Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if data.ExecuteOpen.Message == "" || strings.Contains(data.ExecuteOpen.Message, "crashed") {
+		t.Errorf("expected a fixed user-facing message, got %q", data.ExecuteOpen.Message)
+	}
+}
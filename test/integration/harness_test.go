@@ -0,0 +1,245 @@
+package integration
+
+import (
+	"bff-graphql-payment/internal/application/ports"
+	"bff-graphql-payment/internal/application/service"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/resolver"
+	graphqlServer "bff-graphql-payment/internal/infrastructure/inbound/graphql/server"
+	"bff-graphql-payment/internal/infrastructure/inbound/graphql/subscription"
+	"bff-graphql-payment/internal/infrastructure/origin"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/client"
+	"bff-graphql-payment/internal/infrastructure/outbound/grpc/fake"
+	"bff-graphql-payment/internal/infrastructure/outbound/idempotency"
+	"bff-graphql-payment/internal/infrastructure/outbound/routing"
+	"bff-graphql-payment/internal/infrastructure/requestmeta"
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+)
+
+// Plazos del harness: cortos para que los escenarios de timeout no alarguen la suite
+const (
+	callTimeout          = 500 * time.Millisecond
+	openReceivedTimeout  = 300 * time.Millisecond
+	openTerminalTimeout  = 300 * time.Millisecond
+	openStreamTimeout    = 2 * time.Second
+	subscriptionDeadline = 5 * time.Second
+)
+
+// harness levanta el BFF completo (handler GraphQL → servicio → cliente gRPC real) contra
+// Payment y Booking Manager falsos servidos en memoria
+type harness struct {
+	managers      *fake.Managers
+	subscriptions *subscription.Registry
+	server        *httptest.Server
+}
+
+// newHarness arma el BFF con el mismo cableado que config.NewContainer, sin cache ni rate limiting
+func newHarness(t *testing.T) *harness {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	managers := fake.NewManagers()
+	t.Cleanup(managers.Close)
+
+	grpcClient := client.NewPaymentServiceGRPCClient(
+		"passthrough:///payment-manager",
+		"passthrough:///booking-manager",
+		callTimeout,
+		callTimeout,
+		client.RetryPolicy{
+			MaxAttempts:       2,
+			InitialBackoff:    time.Millisecond,
+			MaxBackoff:        5 * time.Millisecond,
+			Multiplier:        2,
+			PerAttemptTimeout: callTimeout,
+			RetryableCodes:    []codes.Code{codes.Unavailable},
+		},
+		client.CircuitBreakerSettings{Enabled: false},
+		client.TLSSettings{Insecure: true},
+		logger,
+	)
+	grpcClient.SetDialOptions(managers.DialOptions()...)
+	for _, upstream := range []string{client.UpstreamPayment, client.UpstreamBooking} {
+		if err := grpcClient.Connect(upstream); err != nil {
+			t.Fatalf("connect %s: %v", upstream, err)
+		}
+	}
+	t.Cleanup(func() { _ = grpcClient.Close() })
+
+	upstreams, err := routing.NewRepository(
+		map[string]ports.PaymentInfraRepository{routing.AdapterGRPC: grpcClient},
+		routing.Selection{Payment: routing.AdapterGRPC, Booking: routing.AdapterGRPC},
+		routing.OpenTimeouts{Received: openReceivedTimeout, Terminal: openTerminalTimeout, Stream: openStreamTimeout},
+		logger,
+	)
+	if err != nil {
+		t.Fatalf("routing repository: %v", err)
+	}
+
+	paymentInfraService := service.NewPaymentInfraService(upstreams, idempotency.NewMemoryStore(), service.IdempotencySettings{TTL: time.Minute}, logger)
+	subscriptions := subscription.NewRegistry(logger)
+	originPolicy, err := origin.NewPolicy(nil, false)
+	if err != nil {
+		t.Fatalf("origin policy: %v", err)
+	}
+
+	handler := graphqlServer.NewHandler(resolver.NewResolver(paymentInfraService, subscriptions, logger), graphqlServer.Settings{
+		Production:    true,
+		OriginPolicy:  originPolicy,
+		Subscriptions: subscriptions,
+		Logger:        logger,
+	})
+	server := httptest.NewServer(requestmeta.Middleware(nil, handler))
+	t.Cleanup(server.Close)
+
+	return &harness{managers: managers, subscriptions: subscriptions, server: server}
+}
+
+// graphQLError es un error de la respuesta GraphQL con sus extensions
+type graphQLError struct {
+	Message    string         `json:"message"`
+	Extensions map[string]any `json:"extensions"`
+}
+
+// graphQLResponse es el cuerpo de una respuesta GraphQL
+type graphQLResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []graphQLError  `json:"errors"`
+}
+
+// code devuelve extensions.code del primer error o "" si la respuesta no tiene errores
+func (r graphQLResponse) code() string {
+	if len(r.Errors) == 0 {
+		return ""
+	}
+	code, _ := r.Errors[0].Extensions["code"].(string)
+	return code
+}
+
+// do envía una query o mutation por HTTP POST
+func (h *harness) do(t *testing.T, query string, variables map[string]any) graphQLResponse {
+	t.Helper()
+	return h.doWithHeader(t, query, variables, nil)
+}
+
+// doWithHeader envía la operación con headers HTTP adicionales
+func (h *harness) doWithHeader(t *testing.T, query string, variables map[string]any, header http.Header) graphQLResponse {
+	t.Helper()
+
+	body, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		t.Fatalf("marshal request: %v", err)
+	}
+	request, err := http.NewRequest(http.MethodPost, h.server.URL, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", "application/json")
+	httpResponse, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer httpResponse.Body.Close()
+
+	var response graphQLResponse
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	return response
+}
+
+// mustDo envía la operación, falla si la respuesta trae errores y decodifica data en out
+func (h *harness) mustDo(t *testing.T, query string, variables map[string]any, out any) {
+	t.Helper()
+
+	response := h.do(t, query, variables)
+	if len(response.Errors) > 0 {
+		t.Fatalf("unexpected errors: %+v", response.Errors)
+	}
+	if err := json.Unmarshal(response.Data, out); err != nil {
+		t.Fatalf("decode data: %v", err)
+	}
+}
+
+// wsMessage es un mensaje del protocolo graphql-transport-ws
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// subscribe ejecuta una subscription por WebSocket (graphql-transport-ws) y devuelve los payloads
+// recibidos hasta que el servidor la completa
+func (h *harness) subscribe(t *testing.T, query string, variables map[string]any) []graphQLResponse {
+	t.Helper()
+
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(h.server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("websocket dial: %v", err)
+	}
+	defer conn.Close()
+	if err := conn.SetReadDeadline(time.Now().Add(subscriptionDeadline)); err != nil {
+		t.Fatalf("set read deadline: %v", err)
+	}
+
+	send := func(message any) {
+		if err := conn.WriteJSON(message); err != nil {
+			t.Fatalf("websocket write: %v", err)
+		}
+	}
+	receive := func() wsMessage {
+		var message wsMessage
+		if err := conn.ReadJSON(&message); err != nil {
+			t.Fatalf("websocket read: %v", err)
+		}
+		return message
+	}
+
+	send(wsMessage{Type: "connection_init"})
+	if ack := receive(); ack.Type != "connection_ack" {
+		t.Fatalf("expected connection_ack, got %q", ack.Type)
+	}
+	send(map[string]any{
+		"id":      "1",
+		"type":    "subscribe",
+		"payload": map[string]any{"query": query, "variables": variables},
+	})
+
+	var payloads []graphQLResponse
+	for {
+		message := receive()
+		switch message.Type {
+		case "next":
+			var payload graphQLResponse
+			if err := json.Unmarshal(message.Payload, &payload); err != nil {
+				t.Fatalf("decode next payload: %v", err)
+			}
+			payloads = append(payloads, payload)
+		case "error":
+			var errs []graphQLError
+			if err := json.Unmarshal(message.Payload, &errs); err != nil {
+				t.Fatalf("decode error payload: %v", err)
+			}
+			return append(payloads, graphQLResponse{Errors: errs})
+		case "complete":
+			return payloads
+		case "ping":
+			send(wsMessage{Type: "pong"})
+		}
+	}
+}